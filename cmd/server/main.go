@@ -1,11 +1,14 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 
 	"vericred/internal/db"
+	"vericred/internal/eth/ipfs"
+	"vericred/internal/jobs"
 	"vericred/internal/logging"
 	"vericred/internal/router"
 )
@@ -14,6 +17,17 @@ func main() {
 	logging.Init()
 
 	db.Init()
+	// Resolve the content store now so a bad configuration fails at startup
+	ipfs.Store()
+
+	// Background reconciliation of minted tokens against credential rows
+	go jobs.GetReconciler().Start(context.Background())
+	// Batch issuance worker
+	go jobs.GetIssuer().Start(context.Background())
+	// Garbage collection and presence checks for pinned content
+	go jobs.GetPinKeeper().Start(context.Background())
+	// Fee bumping and final status of transactions from the server wallet
+	go jobs.GetTxMonitor().Start(context.Background())
 
 	r := router.RegisterRouter()
 	fmt.Println("Port :8080 is active....")
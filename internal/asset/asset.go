@@ -0,0 +1,151 @@
+// Package asset validates and normalizes images and media uploaded for
+// credential metadata before they are pinned.
+package asset
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Kinds of asset, named after the metadata field they are meant for.
+const (
+	// KindImage is a still image for "image": PNG, JPEG or GIF.
+	KindImage = "image"
+	// KindAnimation is media for "animation_url": animated GIF, MP4 or WebM.
+	KindAnimation = "animation"
+)
+
+var (
+	ErrTooLarge    = errors.New("asset is too large")
+	ErrUnsupported = errors.New("unsupported asset type")
+	ErrDimensions  = errors.New("image dimensions out of range")
+	ErrCorrupt     = errors.New("image could not be decoded")
+)
+
+// Limits bound what uploads accept.
+type Limits struct {
+	// MaxBytes caps the uploaded file.
+	MaxBytes int64
+	// MaxDimension caps image width and height in pixels.
+	MaxDimension int
+}
+
+// DefaultLimits reads ASSET_MAX_BYTES (default 5 MiB) and
+// ASSET_MAX_DIMENSION (default 4096).
+func DefaultLimits() Limits {
+	l := Limits{MaxBytes: 5 << 20, MaxDimension: 4096}
+	if v := strings.TrimSpace(os.Getenv("ASSET_MAX_BYTES")); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			l.MaxBytes = n
+		} else {
+			log.Printf("ignoring ASSET_MAX_BYTES %q", v)
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("ASSET_MAX_DIMENSION")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			l.MaxDimension = n
+		} else {
+			log.Printf("ignoring ASSET_MAX_DIMENSION %q", v)
+		}
+	}
+	return l
+}
+
+// Asset is a validated upload ready to pin.
+type Asset struct {
+	Data        []byte
+	ContentType string
+	// Ext is the file extension matching ContentType, with the dot.
+	Ext    string
+	Width  int
+	Height int
+}
+
+// Normalize checks data against the limits for kind and returns what should
+// be pinned. The type is sniffed from the bytes, never taken from the client.
+// Still images are decoded and re-encoded, which drops EXIF and other
+// embedded metadata (such as the GPS position of a phone scan) and rejects
+// files that only pretend to be images. Animated GIFs are re-encoded frame
+// by frame; video is checked by type and size only.
+func Normalize(data []byte, kind string, l Limits) (*Asset, error) {
+	if int64(len(data)) > l.MaxBytes {
+		return nil, fmt.Errorf("%w: %d bytes, limit is %d", ErrTooLarge, len(data), l.MaxBytes)
+	}
+	ct := http.DetectContentType(data)
+	switch kind {
+	case KindImage:
+		switch ct {
+		case "image/png", "image/jpeg", "image/gif":
+			return normalizeImage(data, ct, l)
+		}
+		return nil, fmt.Errorf("%w: %s (image takes PNG, JPEG or GIF)", ErrUnsupported, ct)
+	case KindAnimation:
+		switch ct {
+		case "image/gif":
+			return normalizeImage(data, ct, l)
+		case "video/mp4":
+			return &Asset{Data: data, ContentType: ct, Ext: ".mp4"}, nil
+		case "video/webm":
+			return &Asset{Data: data, ContentType: ct, Ext: ".webm"}, nil
+		}
+		return nil, fmt.Errorf("%w: %s (animation takes GIF, MP4 or WebM)", ErrUnsupported, ct)
+	}
+	return nil, fmt.Errorf("%w: unknown kind %q", ErrUnsupported, kind)
+}
+
+func normalizeImage(data []byte, ct string, l Limits) (*Asset, error) {
+	// Check the header before decoding so oversized images are refused
+	// without allocating their pixels
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	if cfg.Width < 1 || cfg.Height < 1 || cfg.Width > l.MaxDimension || cfg.Height > l.MaxDimension {
+		return nil, fmt.Errorf("%w: %dx%d, limit is %dx%d", ErrDimensions, cfg.Width, cfg.Height, l.MaxDimension, l.MaxDimension)
+	}
+
+	var buf bytes.Buffer
+	out := &Asset{ContentType: ct, Width: cfg.Width, Height: cfg.Height}
+	switch ct {
+	case "image/png":
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+		enc := png.Encoder{CompressionLevel: png.BestCompression}
+		if err := enc.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+		out.Ext = ".png"
+	case "image/jpeg":
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+			return nil, err
+		}
+		out.Ext = ".jpg"
+	case "image/gif":
+		g, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+		if err := gif.EncodeAll(&buf, g); err != nil {
+			return nil, err
+		}
+		out.Ext = ".gif"
+	}
+	out.Data = buf.Bytes()
+	return out, nil
+}
@@ -0,0 +1,183 @@
+// Package branding applies an organization's branding profile to the
+// metadata, QR codes and documents generated for its credentials.
+package branding
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+	"gorm.io/gorm"
+
+	"vericred/internal/certificate"
+	"vericred/internal/db"
+	"vericred/internal/eth/ipfs"
+	"vericred/internal/models"
+)
+
+// Load returns the org's branding, or the zero profile when none has been
+// saved.
+func Load(orgID uint) (models.OrganizationBranding, error) {
+	var b models.OrganizationBranding
+	err := db.DB.Where("organization_id = ?", orgID).First(&b).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.OrganizationBranding{OrganizationID: orgID}, nil
+	}
+	return b, err
+}
+
+// Name is the name credentials are presented under: the display name when
+// set, otherwise the registered org name.
+func Name(b models.OrganizationBranding, org models.Organization) string {
+	if n := strings.TrimSpace(b.DisplayName); n != "" {
+		return n
+	}
+	return org.OrgName
+}
+
+// MetadataImage is the image credentials get when the issuance names none:
+// the seal, or the logo when there is no seal.
+func MetadataImage(b models.OrganizationBranding) string {
+	if b.SealURI != "" {
+		return b.SealURI
+	}
+	return b.LogoURI
+}
+
+// Assets lists the asset URIs the profile uses.
+func Assets(b models.OrganizationBranding) []string {
+	var out []string
+	for _, uri := range []string{b.LogoURI, b.SealURI} {
+		if uri != "" {
+			out = append(out, uri)
+		}
+	}
+	return out
+}
+
+// ApplyToMetadata fills image and background_color from the profile where
+// the issuance left them empty.
+func ApplyToMetadata(md *ipfs.Credentials, b models.OrganizationBranding) {
+	if md.Image == "" {
+		md.Image = MetadataImage(b)
+	}
+	if md.BgColor == "" && b.PrimaryColor != "" {
+		// ERC-721 background_color is six hex digits without the '#'
+		md.BgColor = strings.ToUpper(strings.TrimPrefix(b.PrimaryColor, "#"))
+	}
+}
+
+// View is the public part of the profile, as shown on verification pages.
+func View(b models.OrganizationBranding, org models.Organization) map[string]any {
+	out := map[string]any{
+		"display_name":        Name(b, org),
+		"primary_color":       b.PrimaryColor,
+		"secondary_color":     b.SecondaryColor,
+		"verification_footer": b.VerificationFooter,
+	}
+	if b.LogoURI != "" {
+		out["logo_url"] = ipfs.GatewayURL(b.LogoURI)
+	}
+	if b.SealURI != "" {
+		out["seal_url"] = ipfs.GatewayURL(b.SealURI)
+	}
+	return out
+}
+
+// LoadImage fetches and decodes an image asset.
+func LoadImage(ctx context.Context, uri string) (image.Image, error) {
+	res, err := ipfs.ResolveAsset(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(res.Data))
+	if err != nil {
+		return nil, fmt.Errorf("decode %s: %w", uri, err)
+	}
+	return img, nil
+}
+
+// QRCode renders content as a PNG QR code of size pixels in the profile's
+// primary color, with logo in the middle when given. Colors too light to
+// scan reliably fall back to black. A logo raises error correction to the
+// highest level so the modules it covers can be recovered.
+func QRCode(content string, size int, b models.OrganizationBranding, logo image.Image) ([]byte, error) {
+	level := qrcode.Medium
+	if logo != nil {
+		level = qrcode.Highest
+	}
+	q, err := qrcode.New(content, level)
+	if err != nil {
+		return nil, err
+	}
+	if r, g, bl, ok := certificate.ParseColor(b.PrimaryColor); ok && luminance(r, g, bl) < 0.4 {
+		q.ForegroundColor = color.RGBA{R: r, G: g, B: bl, A: 0xff}
+	}
+	img := q.Image(size)
+	if logo != nil {
+		img = overlayLogo(img, logo)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// luminance is the relative luminance of an sRGB color, 0 (black) to 1.
+func luminance(r, g, b uint8) float64 {
+	return (0.2126*float64(r) + 0.7152*float64(g) + 0.0722*float64(b)) / 255
+}
+
+// overlayLogo draws logo on a white pad covering at most a fifth of the
+// code's width, well within what the highest error correction restores.
+func overlayLogo(code image.Image, logo image.Image) image.Image {
+	bounds := code.Bounds()
+	out := image.NewRGBA(bounds)
+	draw.Draw(out, bounds, code, bounds.Min, draw.Src)
+
+	box := bounds.Dx() / 5
+	pad := box / 10
+	cx, cy := bounds.Min.X+bounds.Dx()/2, bounds.Min.Y+bounds.Dy()/2
+	padRect := image.Rect(cx-box/2-pad, cy-box/2-pad, cx+box/2+pad, cy+box/2+pad)
+	draw.Draw(out, padRect, image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	w, h := fit(logo.Bounds().Dx(), logo.Bounds().Dy(), box, box)
+	scaled := scale(logo, w, h)
+	at := image.Rect(cx-w/2, cy-h/2, cx-w/2+w, cy-h/2+h)
+	draw.Draw(out, at, scaled, image.Point{}, draw.Over)
+	return out
+}
+
+// fit scales w×h to fit within maxW×maxH keeping the aspect ratio.
+func fit(w, h, maxW, maxH int) (int, int) {
+	if w <= 0 || h <= 0 {
+		return maxW, maxH
+	}
+	if w*maxH > h*maxW {
+		return maxW, max(1, h*maxW/w)
+	}
+	return max(1, w*maxH/h), maxH
+}
+
+// scale resizes img to w×h by nearest-neighbour sampling.
+func scale(img image.Image, w, h int) image.Image {
+	src := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		sy := src.Min.Y + y*src.Dy()/h
+		for x := 0; x < w; x++ {
+			out.Set(x, y, img.At(src.Min.X+x*src.Dx()/w, sy))
+		}
+	}
+	return out
+}
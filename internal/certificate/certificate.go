@@ -0,0 +1,234 @@
+// Package certificate renders printable PDF certificates for credentials,
+// laid out by the issuing organization's CertificateTemplate and carrying a
+// QR code that links to a signed verification URL.
+package certificate
+
+import (
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+
+	"vericred/internal/models"
+	"vericred/internal/pdf"
+)
+
+// Layouts supported by Render.
+const (
+	LayoutClassic = "classic"
+	LayoutModern  = "modern"
+)
+
+// Data is everything printed on a certificate.
+type Data struct {
+	CredentialID   string
+	StudentName    string
+	DegreeName     string
+	Major          string
+	Type           string
+	IssuedDate     string
+	GraduationDate string
+	OrgName        string
+	CredentialHash string
+	VerifyURL      string
+	// Logo and Seal come from the org's branding profile; nil leaves them
+	// out.
+	Logo image.Image
+	Seal image.Image
+}
+
+// Defaults fills unset template fields with the platform's standard wording.
+func Defaults(t models.CertificateTemplate) models.CertificateTemplate {
+	if t.Layout != LayoutModern {
+		t.Layout = LayoutClassic
+	}
+	if t.Title == "" {
+		t.Title = "Certificate of Achievement"
+	}
+	if t.Preamble == "" {
+		t.Preamble = "This is to certify that"
+	}
+	if t.Body == "" {
+		t.Body = "has been awarded the {{degree_name}} in {{major}} by {{org_name}} on {{issued_date}}."
+	}
+	if _, _, _, ok := ParseColor(t.AccentColor); !ok {
+		t.AccentColor = "#1F3A68"
+	}
+	return t
+}
+
+// ParseColor parses a #RRGGBB color.
+func ParseColor(hex string) (r, g, b uint8, ok bool) {
+	hex = strings.TrimPrefix(strings.TrimSpace(hex), "#")
+	if len(hex) != 6 {
+		return 0, 0, 0, false
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return uint8(v >> 16), uint8(v >> 8), uint8(v), true
+}
+
+// fill substitutes the template placeholders, dropping an " in {{major}}"
+// clause when the credential has no major.
+func (d Data) fill(s string) string {
+	if d.Major == "" {
+		s = strings.ReplaceAll(s, " in {{major}}", "")
+	}
+	return strings.NewReplacer(
+		"{{student_name}}", d.StudentName,
+		"{{degree_name}}", d.DegreeName,
+		"{{major}}", d.Major,
+		"{{type}}", d.Type,
+		"{{issued_date}}", d.IssuedDate,
+		"{{graduation_date}}", d.GraduationDate,
+		"{{org_name}}", d.OrgName,
+	).Replace(s)
+}
+
+// Render produces the certificate PDF.
+func Render(d Data, tpl models.CertificateTemplate) ([]byte, error) {
+	tpl = Defaults(tpl)
+	ar, ag, ab, _ := ParseColor(tpl.AccentColor)
+
+	qr, err := qrcode.New(d.VerifyURL, qrcode.Medium)
+	if err != nil {
+		return nil, fmt.Errorf("qr code: %w", err)
+	}
+	qr.DisableBorder = true
+	qrImg := qr.Image(256)
+
+	doc := pdf.New(pdf.A4Width, pdf.A4Height)
+	doc.SetInfo("Title", fmt.Sprintf("%s - %s", tpl.Title, d.StudentName))
+	doc.SetInfo("Author", d.OrgName)
+	doc.SetInfo("Subject", d.DegreeName)
+	doc.SetInfo("Keywords", "credential:"+d.CredentialID+" hash:"+d.CredentialHash)
+	doc.SetInfo("CredentialID", d.CredentialID)
+	doc.SetInfo("CredentialHash", d.CredentialHash)
+	doc.SetInfo("VerificationURL", d.VerifyURL)
+
+	page := doc.AddPage()
+	if tpl.Layout == LayoutModern {
+		renderModern(page, d, tpl, ar, ag, ab, qrImg)
+	} else {
+		renderClassic(page, d, tpl, ar, ag, ab, qrImg)
+	}
+	return doc.Bytes()
+}
+
+func renderClassic(p *pdf.Page, d Data, tpl models.CertificateTemplate, r, g, b uint8, qr image.Image) {
+	w, h := pdf.A4Width, pdf.A4Height
+	cx := w / 2
+
+	p.SetStrokeColor(r, g, b)
+	p.SetLineWidth(4)
+	p.Rect(24, 24, w-48, h-48, false, true)
+	p.SetLineWidth(1)
+	p.Rect(34, 34, w-68, h-68, false, true)
+
+	p.SetFillColor(r, g, b)
+	p.TextCentered(pdf.TimesBold, 26, cx, h-95, d.OrgName)
+	p.SetFillColor(40, 40, 40)
+	p.TextCentered(pdf.TimesRoman, 34, cx, h-150, d.fill(tpl.Title))
+	p.TextCentered(pdf.TimesRoman, 15, cx, h-200, d.fill(tpl.Preamble))
+	p.SetFillColor(r, g, b)
+	p.TextCentered(pdf.TimesBold, 30, cx, h-245, d.StudentName)
+	p.SetFillColor(40, 40, 40)
+	y := h - 285.0
+	for _, line := range pdf.Wrap(pdf.TimesRoman, 15, w-220, d.fill(tpl.Body)) {
+		p.TextCentered(pdf.TimesRoman, 15, cx, y, line)
+		y -= 21
+	}
+
+	drawImageFit(p, d.Logo, 52, h-116, 64, 64)
+	drawImageFit(p, d.Seal, cx-45, 70, 90, 90)
+	drawSignatory(p, tpl, 90, 110)
+	drawQR(p, qr, w-170, 70, 100)
+	drawFooter(p, d, tpl, cx, 46)
+}
+
+func renderModern(p *pdf.Page, d Data, tpl models.CertificateTemplate, r, g, b uint8, qr image.Image) {
+	w, h := pdf.A4Width, pdf.A4Height
+	left := 190.0
+	cx := left + (w-left)/2
+
+	p.SetFillColor(r, g, b)
+	p.Rect(0, 0, 150, h, true, false)
+	y := h - 80.0
+	if d.Logo != nil {
+		drawImageFit(p, d.Logo, 25, h-135, 100, 100)
+		y = h - 160
+	}
+	drawImageFit(p, d.Seal, 25, 50, 100, 100)
+	p.SetFillColor(255, 255, 255)
+	for _, line := range pdf.Wrap(pdf.HelveticaBold, 16, 120, d.OrgName) {
+		p.Text(pdf.HelveticaBold, 16, 15, y, line)
+		y -= 20
+	}
+
+	p.SetFillColor(r, g, b)
+	p.TextCentered(pdf.HelveticaBold, 30, cx, h-120, strings.ToUpper(d.fill(tpl.Title)))
+	p.SetFillColor(70, 70, 70)
+	p.TextCentered(pdf.Helvetica, 14, cx, h-175, d.fill(tpl.Preamble))
+	p.SetFillColor(20, 20, 20)
+	p.TextCentered(pdf.HelveticaBold, 28, cx, h-220, d.StudentName)
+	p.SetStrokeColor(r, g, b)
+	p.SetLineWidth(1.5)
+	p.Line(cx-160, h-232, cx+160, h-232)
+	p.SetFillColor(70, 70, 70)
+	y = h - 265.0
+	for _, line := range pdf.Wrap(pdf.Helvetica, 14, w-left-120, d.fill(tpl.Body)) {
+		p.TextCentered(pdf.Helvetica, 14, cx, y, line)
+		y -= 20
+	}
+
+	drawSignatory(p, tpl, left+20, 110)
+	drawQR(p, qr, w-150, 70, 100)
+	drawFooter(p, d, tpl, cx, 40)
+}
+
+func drawSignatory(p *pdf.Page, tpl models.CertificateTemplate, x, y float64) {
+	if tpl.SignatoryName == "" {
+		return
+	}
+	p.SetStrokeColor(60, 60, 60)
+	p.SetLineWidth(0.75)
+	p.Line(x, y, x+180, y)
+	p.SetFillColor(40, 40, 40)
+	p.Text(pdf.TimesBold, 12, x, y-16, tpl.SignatoryName)
+	if tpl.SignatoryTitle != "" {
+		p.Text(pdf.TimesRoman, 11, x, y-30, tpl.SignatoryTitle)
+	}
+}
+
+// drawImageFit draws img as large as fits the box, centered, keeping its
+// aspect ratio.
+func drawImageFit(p *pdf.Page, img image.Image, x, y, boxW, boxH float64) {
+	if img == nil {
+		return
+	}
+	iw, ih := float64(img.Bounds().Dx()), float64(img.Bounds().Dy())
+	if iw <= 0 || ih <= 0 {
+		return
+	}
+	s := min(boxW/iw, boxH/ih)
+	w, h := iw*s, ih*s
+	p.Image(img, x+(boxW-w)/2, y+(boxH-h)/2, w, h)
+}
+
+func drawQR(p *pdf.Page, qr image.Image, x, y, size float64) {
+	p.Image(qr, x, y, size, size)
+	p.SetFillColor(80, 80, 80)
+	p.TextCentered(pdf.Helvetica, 8, x+size/2, y-12, "Scan to verify")
+}
+
+func drawFooter(p *pdf.Page, d Data, tpl models.CertificateTemplate, cx, y float64) {
+	p.SetFillColor(110, 110, 110)
+	if tpl.Footer != "" {
+		p.TextCentered(pdf.Helvetica, 8, cx, y+11, d.fill(tpl.Footer))
+	}
+	p.TextCentered(pdf.Helvetica, 7, cx, y, "Credential "+d.CredentialID+"  |  "+d.CredentialHash)
+}
@@ -47,6 +47,9 @@ func Init() {
 	if err = DB.AutoMigrate(&models.PendingRequest{}); err != nil {
 		log.Fatal("AutoMigration failed for PendingRequest: ", err)
 	}
+	if err = DB.AutoMigrate(&models.Program{}); err != nil {
+		log.Fatal("AutoMigration failed for Program: ", err)
+	}
 	if err = DB.AutoMigrate(&models.Credential{}); err != nil {
 		log.Fatal("AutoMigration failed for Credential: ", err)
 	}
@@ -56,6 +59,60 @@ func Init() {
 	if err = DB.AutoMigrate(&models.LegacyCredential{}); err != nil {
 		log.Fatal("AutoMigration failed for LegacyCredential: ", err)
 	}
+	if err = DB.AutoMigrate(&models.ChainCursor{}); err != nil {
+		log.Fatal("AutoMigration failed for ChainCursor: ", err)
+	}
+	if err = DB.AutoMigrate(&models.CredentialDiscrepancy{}); err != nil {
+		log.Fatal("AutoMigration failed for CredentialDiscrepancy: ", err)
+	}
+	if err = DB.AutoMigrate(&models.CertificateTemplate{}); err != nil {
+		log.Fatal("AutoMigration failed for CertificateTemplate: ", err)
+	}
+	if err = DB.AutoMigrate(&models.OrganizationBranding{}); err != nil {
+		log.Fatal("AutoMigration failed for OrganizationBranding: ", err)
+	}
+	if err = DB.AutoMigrate(&models.CredentialTemplate{}); err != nil {
+		log.Fatal("AutoMigration failed for CredentialTemplate: ", err)
+	}
+	if err = DB.AutoMigrate(&models.IssuanceJob{}); err != nil {
+		log.Fatal("AutoMigration failed for IssuanceJob: ", err)
+	}
+	if err = DB.AutoMigrate(&models.IssuanceJobItem{}); err != nil {
+		log.Fatal("AutoMigration failed for IssuanceJobItem: ", err)
+	}
+	if err = DB.AutoMigrate(&models.Signatory{}); err != nil {
+		log.Fatal("AutoMigration failed for Signatory: ", err)
+	}
+	if err = DB.AutoMigrate(&models.Transcript{}); err != nil {
+		log.Fatal("AutoMigration failed for Transcript: ", err)
+	}
+	if err = DB.AutoMigrate(&models.TranscriptTerm{}); err != nil {
+		log.Fatal("AutoMigration failed for TranscriptTerm: ", err)
+	}
+	if err = DB.AutoMigrate(&models.TranscriptCourse{}); err != nil {
+		log.Fatal("AutoMigration failed for TranscriptCourse: ", err)
+	}
+	if err = DB.AutoMigrate(&models.PrivateEnvelope{}); err != nil {
+		log.Fatal("AutoMigration failed for PrivateEnvelope: ", err)
+	}
+	if err = DB.AutoMigrate(&models.DisclosureSet{}); err != nil {
+		log.Fatal("AutoMigration failed for DisclosureSet: ", err)
+	}
+	if err = DB.AutoMigrate(&models.Pin{}); err != nil {
+		log.Fatal("AutoMigration failed for Pin: ", err)
+	}
+	if err = DB.AutoMigrate(&models.ChainTransaction{}); err != nil {
+		log.Fatal("AutoMigration failed for ChainTransaction: ", err)
+	}
+
+	// Older rows hold gateway URLs (https://ipfs.io/ipfs/<cid>); store the
+	// canonical ipfs://<cid> instead. Gateways are applied at read time.
+	for _, table := range []string{"credentials", "issuance_job_items"} {
+		if err = DB.Exec(`UPDATE ` + table + ` SET ipfs_link = 'ipfs://' || substring(btrim(ipfs_link, E' \n\t\r') from '/ipfs/(.+)$')
+			WHERE ipfs_link ~ '^\s*https?://[^/]+/ipfs/.+'`).Error; err != nil {
+			log.Println("Canonicalizing ipfs links failed for "+table+": ", err)
+		}
+	}
 
 	// AutoMigrate already manages FKs from struct tags; no need to create constraints manually
 }
@@ -0,0 +1,329 @@
+// Package disclosure implements SD-JWT style selective disclosure for
+// credentials. At issuance every field is wrapped in a salted disclosure,
+// base64url(JSON [salt, name, value]), and only the SHA-256 digests of the
+// disclosures are published. A holder later reveals a subset of disclosures;
+// a verifier recomputes their digests and checks them against the published
+// set, learning nothing about the fields left out. When the server builds
+// the metadata, the digests are salted before pinning and their Root goes
+// into the metadata, while attributes other than the standard traits stay
+// out of it.
+package disclosure
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"vericred/internal/eth/ipfs"
+	"vericred/internal/models"
+)
+
+// Field names for the credential columns that can be disclosed. Metadata
+// attributes beyond the standard traits are disclosable under their
+// snake_cased trait type, e.g. "GPA" becomes "gpa".
+const (
+	FieldStudentName    = "student_name"
+	FieldStudentWallet  = "student_wallet"
+	FieldDegreeName     = "degree_name"
+	FieldType           = "type"
+	FieldMajor          = "major"
+	FieldIssuedDate     = "issued_date"
+	FieldGraduationDate = "graduation_date"
+	FieldDescription    = "description"
+)
+
+const saltBytes = 16
+
+// ErrMalformed is returned for disclosures that can't be decoded.
+var ErrMalformed = errors.New("malformed disclosure")
+
+// Disclosure is one salted field.
+type Disclosure struct {
+	Salt  string
+	Name  string
+	Value string
+}
+
+// New wraps a field in a disclosure with a fresh random salt.
+func New(name, value string) (Disclosure, error) {
+	salt := make([]byte, saltBytes)
+	if _, err := rand.Read(salt); err != nil {
+		return Disclosure{}, err
+	}
+	return Disclosure{Salt: base64.RawURLEncoding.EncodeToString(salt), Name: name, Value: value}, nil
+}
+
+// Encode returns the disclosure in its transport form.
+func (d Disclosure) Encode() string {
+	b, _ := json.Marshal([]string{d.Salt, d.Name, d.Value})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Decode parses an encoded disclosure.
+func Decode(s string) (Disclosure, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return Disclosure{}, ErrMalformed
+	}
+	var parts []string
+	if err := json.Unmarshal(b, &parts); err != nil || len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+		return Disclosure{}, ErrMalformed
+	}
+	return Disclosure{Salt: parts[0], Name: parts[1], Value: parts[2]}, nil
+}
+
+// Digest is the commitment published for an encoded disclosure.
+func Digest(encoded string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(encoded)))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Issue salts every field and returns the encoded disclosures, ordered by
+// field name, and their digests, sorted so their order says nothing about
+// which field each one commits to.
+func Issue(fields map[string]string) (disclosures, digests []string, err error) {
+	names := make([]string, 0, len(fields))
+	for n := range fields {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		d, err := New(n, fields[n])
+		if err != nil {
+			return nil, nil, err
+		}
+		enc := d.Encode()
+		disclosures = append(disclosures, enc)
+		digests = append(digests, Digest(enc))
+	}
+	sort.Strings(digests)
+	return disclosures, digests, nil
+}
+
+// Select returns the encoded disclosures for the named fields. Unknown
+// names are reported so callers can reject the request.
+func Select(disclosures []string, names []string) (selected []string, unknown []string) {
+	byName := map[string]string{}
+	for _, enc := range disclosures {
+		if d, err := Decode(enc); err == nil {
+			byName[d.Name] = enc
+		}
+	}
+	seen := map[string]bool{}
+	for _, n := range names {
+		n = strings.TrimSpace(n)
+		if n == "" || seen[n] {
+			continue
+		}
+		seen[n] = true
+		if enc, ok := byName[n]; ok {
+			selected = append(selected, enc)
+		} else {
+			unknown = append(unknown, n)
+		}
+	}
+	return selected, unknown
+}
+
+// Names lists the field names covered by a set of encoded disclosures.
+func Names(disclosures []string) []string {
+	var names []string
+	for _, enc := range disclosures {
+		if d, err := Decode(enc); err == nil {
+			names = append(names, d.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Root is the commitment to a set of digests that is pinned in metadata:
+// the SHA-256 of the sorted digests joined by ".", base64url encoded. It is
+// "" for an empty set.
+func Root(digests []string) string {
+	if len(digests) == 0 {
+		return ""
+	}
+	sorted := append([]string(nil), digests...)
+	sort.Strings(sorted)
+	sum := sha256.Sum256([]byte(strings.Join(sorted, ".")))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Verified is a disclosure checked against a credential's digests.
+type Verified struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Verified bool   `json:"verified"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// Verify decodes each disclosure and checks its digest is one of the
+// published ones. Every disclosure gets a result, verified or not.
+func Verify(disclosures []string, digests []string) []Verified {
+	published := map[string]bool{}
+	for _, d := range digests {
+		published[d] = true
+	}
+	out := make([]Verified, 0, len(disclosures))
+	for _, enc := range disclosures {
+		d, err := Decode(enc)
+		if err != nil {
+			out = append(out, Verified{Detail: err.Error()})
+			continue
+		}
+		v := Verified{Name: d.Name, Value: d.Value, Verified: published[Digest(enc)]}
+		if !v.Verified {
+			v.Detail = "digest is not among the credential's commitments"
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// standardTraits are metadata attributes already covered by a credential
+// column, or that identify the credential rather than describe the holder.
+var standardTraits = map[string]bool{
+	strings.ToLower(ipfs.TraitRecipientWallet):      true,
+	strings.ToLower(ipfs.TraitIssuerWallet):         true,
+	strings.ToLower(ipfs.TraitDegreeName):           true,
+	strings.ToLower(ipfs.TraitCredentialType):       true,
+	strings.ToLower(ipfs.TraitMajor):                true,
+	strings.ToLower(ipfs.TraitIssueDate):            true,
+	"issued date":                                   true,
+	strings.ToLower(ipfs.TraitGraduationDate):       true,
+	strings.ToLower(ipfs.TraitCredentialHash):       true,
+	strings.ToLower(ipfs.TraitTranscriptHash):       true,
+	strings.ToLower(ipfs.TraitPrivateCommitment):    true,
+	strings.ToLower(ipfs.TraitDisclosureCommitment): true,
+}
+
+// fieldName snake_cases a trait type: "Class Rank" becomes "class_rank".
+func fieldName(trait string) string {
+	var b strings.Builder
+	under := false
+	for _, r := range strings.ToLower(strings.TrimSpace(trait)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if under && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+			under = false
+		} else {
+			under = true
+		}
+	}
+	return b.String()
+}
+
+// CredentialFields collects a credential's disclosable fields: its columns,
+// the student's name and any extra traits (trait type to value) from its
+// metadata. Empty values are left out.
+func CredentialFields(cred models.Credential, studentName string, traits map[string]string) map[string]string {
+	fields := map[string]string{}
+	set := func(name, value string) {
+		if v := strings.TrimSpace(value); v != "" {
+			fields[name] = v
+		}
+	}
+	for trait, value := range traits {
+		if standardTraits[strings.ToLower(strings.TrimSpace(trait))] {
+			continue
+		}
+		if name := fieldName(trait); name != "" {
+			set(name, value)
+		}
+	}
+	// Columns win over traits that snake_case to the same name.
+	set(FieldStudentName, studentName)
+	set(FieldStudentWallet, cred.StudentWallet)
+	set(FieldDegreeName, cred.DegreeName)
+	set(FieldType, cred.Type)
+	set(FieldMajor, cred.Major)
+	if !cred.IssuedDate.IsZero() {
+		set(FieldIssuedDate, cred.IssuedDate.UTC().Format("2006-01-02"))
+	}
+	set(FieldGraduationDate, cred.GraduationDate)
+	set(FieldDescription, cred.Description)
+	return fields
+}
+
+// MetadataTraits returns md's attributes as trait type to value.
+func MetadataTraits(md *ipfs.Metadata) map[string]string {
+	traits := map[string]string{}
+	if md == nil {
+		return traits
+	}
+	for _, a := range md.Attributes {
+		traits[a.TraitType] = a.Value
+	}
+	return traits
+}
+
+// Commit salts cred's disclosable fields and stores the disclosures and
+// their digests on it.
+func Commit(cred *models.Credential, studentName string, traits map[string]string) error {
+	disclosures, digests, err := Issue(CredentialFields(*cred, studentName, traits))
+	if err != nil {
+		return fmt.Errorf("salt disclosures: %w", err)
+	}
+	cred.SDDisclosures = disclosures
+	cred.SDDigests = digests
+	return nil
+}
+
+// Set is the disclosures salted for metadata before it is pinned.
+type Set struct {
+	Disclosures []string
+	Digests     []string
+	// Withheld names the attributes taken out of the metadata.
+	Withheld []string
+	Root     string
+}
+
+// Withhold salts every disclosable field of the credential md describes,
+// moves the attributes that aren't standard traits out of md and records
+// the digests' Root as its Disclosure Commitment trait. Standard traits stay
+// in md: issuance and verification compare them with the credential.
+func Withhold(md *ipfs.Credentials, studentName string) (Set, error) {
+	f, err := ipfs.CanonicalFields(md.Attributes, md.Name)
+	if err != nil {
+		return Set{}, err
+	}
+	issued, _ := time.Parse("2006-01-02", f.IssuedDate)
+	cred := models.Credential{
+		StudentWallet:  f.Recipient,
+		DegreeName:     f.DegreeName,
+		Type:           f.CredentialType,
+		Major:          f.Major,
+		IssuedDate:     issued,
+		GraduationDate: f.GraduationDate,
+		Description:    md.Description,
+	}
+	var public []ipfs.Attribute
+	var withheld []string
+	traits := map[string]string{}
+	for _, a := range md.Attributes {
+		if standardTraits[strings.ToLower(strings.TrimSpace(a.TraitType))] {
+			public = append(public, a)
+			continue
+		}
+		traits[a.TraitType] = a.Value
+		withheld = append(withheld, a.TraitType)
+	}
+	disclosures, digests, err := Issue(CredentialFields(cred, studentName, traits))
+	if err != nil {
+		return Set{}, fmt.Errorf("salt disclosures: %w", err)
+	}
+	set := Set{Disclosures: disclosures, Digests: digests, Withheld: withheld, Root: Root(digests)}
+	md.Attributes = public
+	ipfs.SetTrait(md, ipfs.TraitDisclosureCommitment, set.Root)
+	return set, nil
+}
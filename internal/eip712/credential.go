@@ -0,0 +1,104 @@
+package eip712
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+
+	"vericred/internal/models"
+)
+
+// CredentialAttestationType is the primary type an authorized signatory
+// (dean, registrar) signs to attest to a credential's canonical fields.
+const CredentialAttestationType = "CredentialAttestation"
+
+// CredentialAttestationFields are the members of CredentialAttestation.
+var CredentialAttestationFields = []apitypes.Type{
+	{Name: "recipient", Type: "address"},
+	{Name: "issuer", Type: "address"},
+	{Name: "degreeName", Type: "string"},
+	{Name: "credentialType", Type: "string"},
+	{Name: "major", Type: "string"},
+	{Name: "issuedDate", Type: "string"},
+	{Name: "graduationDate", Type: "string"},
+}
+
+// CredentialFields are a credential's canonical fields. Dates are
+// YYYY-MM-DD and text is trimmed, so the same credential always produces the
+// same typed data.
+type CredentialFields struct {
+	Recipient      string `json:"recipient"`
+	Issuer         string `json:"issuer"`
+	DegreeName     string `json:"degree_name"`
+	CredentialType string `json:"credential_type"`
+	Major          string `json:"major"`
+	IssuedDate     string `json:"issued_date"`
+	GraduationDate string `json:"graduation_date"`
+}
+
+// FieldsOf extracts the canonical fields of cred.
+func FieldsOf(cred models.Credential) CredentialFields {
+	return CredentialFields{
+		Recipient:      cred.StudentWallet,
+		Issuer:         cred.UniversityWallet,
+		DegreeName:     cred.DegreeName,
+		CredentialType: cred.Type,
+		Major:          cred.Major,
+		IssuedDate:     cred.IssuedDate.UTC().Format("2006-01-02"),
+		GraduationDate: cred.GraduationDate,
+	}
+}
+
+// CredentialTypedData is the CredentialAttestation typed data for f.
+func CredentialTypedData(f CredentialFields) apitypes.TypedData {
+	return New(CredentialAttestationType, CredentialAttestationFields, apitypes.TypedDataMessage{
+		"recipient":      common.HexToAddress(strings.TrimSpace(f.Recipient)).Hex(),
+		"issuer":         common.HexToAddress(strings.TrimSpace(f.Issuer)).Hex(),
+		"degreeName":     strings.TrimSpace(f.DegreeName),
+		"credentialType": strings.TrimSpace(f.CredentialType),
+		"major":          strings.TrimSpace(f.Major),
+		"issuedDate":     strings.TrimSpace(f.IssuedDate),
+		"graduationDate": strings.TrimSpace(f.GraduationDate),
+	})
+}
+
+// RecoverCredentialSigner returns the wallet that signed f's attestation.
+func RecoverCredentialSigner(f CredentialFields, sigHex string) (common.Address, error) {
+	return Recover(CredentialTypedData(f), sigHex)
+}
+
+// CredentialHash is the canonical hash of a credential: the EIP-712
+// hashStruct of its CredentialAttestation, which binds the database row, the
+// pinned metadata and the token to the same fields. It leaves out the
+// domain, so a new chain id or contract address doesn't change it; only
+// signatures are bound to the domain.
+func CredentialHash(f CredentialFields) (string, error) {
+	hash, err := StructHash(CredentialTypedData(f))
+	if err != nil {
+		return "", err
+	}
+	return common.BytesToHash(hash).Hex(), nil
+}
+
+// legacyCredentialHash is the canonical hash credentials were issued with
+// before it left out the domain: the full EIP-712 digest under the current
+// domain.
+func legacyCredentialHash(f CredentialFields) (string, error) {
+	digest, err := Digest(CredentialTypedData(f))
+	if err != nil {
+		return "", err
+	}
+	return common.BytesToHash(digest).Hex(), nil
+}
+
+// CredentialHashMatches reports whether hash is f's canonical hash. Hashes
+// recorded in the older, domain-bound form are accepted while the domain
+// they were made under is still configured.
+func CredentialHashMatches(f CredentialFields, hash string) bool {
+	if h, err := CredentialHash(f); err == nil && strings.EqualFold(h, hash) {
+		return true
+	}
+	h, err := legacyCredentialHash(f)
+	return err == nil && strings.EqualFold(h, hash)
+}
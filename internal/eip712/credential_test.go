@@ -0,0 +1,89 @@
+package eip712
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var testFields = CredentialFields{
+	Recipient:      "0x00000000000000000000000000000000000000a1",
+	Issuer:         "0x00000000000000000000000000000000000000b2",
+	DegreeName:     "Bachelor of Science",
+	CredentialType: "Degree",
+	Major:          "Physics",
+	IssuedDate:     "2025-06-30",
+	GraduationDate: "2025-06-15",
+}
+
+// signTypedData signs f's attestation the way eth_signTypedData_v4 does,
+// with a 27/28 recovery id.
+func signTypedData(t *testing.T, f CredentialFields) (string, string) {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, err := Digest(CredentialTypedData(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := crypto.Sign(digest, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig[64] += 27
+	return hexutil.Encode(sig), crypto.PubkeyToAddress(key.PublicKey).Hex()
+}
+
+func TestRecoverCredentialSigner(t *testing.T) {
+	sig, signer := signTypedData(t, testFields)
+	got, err := RecoverCredentialSigner(testFields, sig)
+	if err != nil {
+		t.Fatalf("RecoverCredentialSigner: %v", err)
+	}
+	if got.Hex() != signer {
+		t.Errorf("recovered %s, want %s", got.Hex(), signer)
+	}
+
+	// Canonicalisation: padding and address case don't change the message
+	padded := testFields
+	padded.DegreeName = "  " + padded.DegreeName + " "
+	padded.Recipient = strings.ToUpper(padded.Recipient[2:])
+	if got, err := RecoverCredentialSigner(padded, " "+sig+" "); err != nil || got.Hex() != signer {
+		t.Errorf("padded fields recovered %s, %v; want %s", got.Hex(), err, signer)
+	}
+
+	// A 0/1 recovery id is accepted too
+	raw := hexutil.MustDecode(sig)
+	raw[64] -= 27
+	if got, err := RecoverCredentialSigner(testFields, hexutil.Encode(raw)); err != nil || got.Hex() != signer {
+		t.Errorf("v=0/1 recovered %s, %v; want %s", got.Hex(), err, signer)
+	}
+
+	// Any other field recovers someone else
+	other := testFields
+	other.Major = "Chemistry"
+	if got, err := RecoverCredentialSigner(other, sig); err == nil && got.Hex() == signer {
+		t.Error("signature over one credential recovered the signer for another")
+	}
+}
+
+func TestRecoverCredentialSignerMalformed(t *testing.T) {
+	sig, _ := signTypedData(t, testFields)
+	raw := hexutil.MustDecode(sig)
+	raw[64] = 29
+	for name, s := range map[string]string{
+		"empty":       "",
+		"not hex":     "signature",
+		"short":       sig[:len(sig)-2],
+		"long":        sig + "00",
+		"recovery id": hexutil.Encode(raw),
+	} {
+		if _, err := RecoverCredentialSigner(testFields, s); err == nil {
+			t.Errorf("%s: RecoverCredentialSigner accepted %q", name, s)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Package eip712 holds the VeriCred EIP-712 domain and helpers to hash typed
+// data and recover the wallet that signed it (eth_signTypedData_v4).
+package eip712
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+
+	"vericred/internal/eth"
+)
+
+// DomainTypes is the EIP712Domain type matching Domain.
+var DomainTypes = []apitypes.Type{
+	{Name: "name", Type: "string"},
+	{Name: "version", Type: "string"},
+	{Name: "chainId", Type: "uint256"},
+	{Name: "verifyingContract", Type: "address"},
+}
+
+// Domain returns the signing domain bound to the credential contract.
+func Domain() apitypes.TypedDataDomain {
+	return apitypes.TypedDataDomain{
+		Name:              "VeriCred",
+		Version:           "1",
+		ChainId:           (*math.HexOrDecimal256)(big.NewInt(eth.ChainID())),
+		VerifyingContract: eth.ContractAddress(),
+	}
+}
+
+// New builds typed data for primaryType in the VeriCred domain.
+func New(primaryType string, fields []apitypes.Type, message apitypes.TypedDataMessage) apitypes.TypedData {
+	return apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": DomainTypes,
+			primaryType:    fields,
+		},
+		PrimaryType: primaryType,
+		Domain:      Domain(),
+		Message:     message,
+	}
+}
+
+// Digest returns the EIP-712 digest that wallets sign for td.
+func Digest(td apitypes.TypedData) ([]byte, error) {
+	hash, _, err := apitypes.TypedDataAndHash(td)
+	if err != nil {
+		return nil, fmt.Errorf("hash typed data: %w", err)
+	}
+	return hash, nil
+}
+
+// StructHash returns hashStruct of td's message: the EIP-712 hash of the
+// message alone, without the domain.
+func StructHash(td apitypes.TypedData) ([]byte, error) {
+	hash, err := td.HashStruct(td.PrimaryType, td.Message)
+	if err != nil {
+		return nil, fmt.Errorf("hash typed data: %w", err)
+	}
+	return hash, nil
+}
+
+// Recover returns the address that produced sigHex over td.
+func Recover(td apitypes.TypedData, sigHex string) (common.Address, error) {
+	digest, err := Digest(td)
+	if err != nil {
+		return common.Address{}, err
+	}
+	sig, err := hexutil.Decode(strings.TrimSpace(sigHex))
+	if err != nil || len(sig) != 65 {
+		return common.Address{}, errors.New("signature must be 65 hex-encoded bytes")
+	}
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	if sig[64] > 1 {
+		return common.Address{}, errors.New("invalid signature recovery id")
+	}
+	pub, err := crypto.SigToPub(digest, sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("recover signer: %w", err)
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
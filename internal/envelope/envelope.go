@@ -0,0 +1,191 @@
+// Package envelope encrypts private credential attributes for their holder
+// and issuer. The attributes and a random salt are serialized as a JSON
+// payload and encrypted with AES-256-GCM under a fresh content key. The
+// content key is then wrapped for each reader with ECIES over secp256k1
+// (go-ethereum's scheme: ECDH, concat KDF with SHA-256, AES-128-CTR and an
+// HMAC-SHA-256 tag), using the public key recovered from the reader's login
+// signature. Only the commitment, keccak256 of the payload, is published.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/ecies"
+
+	"vericred/internal/eth/ipfs"
+)
+
+// Algorithm names reported alongside envelopes so clients know how to open
+// them.
+const (
+	Cipher    = "aes-256-gcm"
+	KeyWrap   = "ecies-secp256k1-aes128ctr-hmacsha256"
+	keyBytes  = 32
+	saltBytes = 16
+)
+
+var (
+	// ErrInvalidKey is returned for public keys and content keys that don't
+	// decode.
+	ErrInvalidKey = errors.New("invalid key")
+	// ErrDecrypt is returned when a ciphertext doesn't open under a key.
+	ErrDecrypt = errors.New("envelope does not decrypt with this key")
+	// ErrCommitment is returned when decrypted data doesn't hash to the
+	// published commitment.
+	ErrCommitment = errors.New("decrypted data does not match the commitment")
+)
+
+// Payload is the plaintext inside an envelope.
+type Payload struct {
+	Salt       string           `json:"salt"`
+	Attributes []ipfs.Attribute `json:"attributes"`
+}
+
+// Sealed is an encrypted payload with its content key wrapped per reader.
+type Sealed struct {
+	Commitment string
+	Nonce      string
+	Ciphertext string
+	// WrappedKeys maps each reader's lowercased wallet to the ECIES
+	// encryption of the content key.
+	WrappedKeys map[string]string
+}
+
+// PublicKeyHex encodes a public key in the uncompressed 0x04... form stored
+// on accounts.
+func PublicKeyHex(pub *ecdsa.PublicKey) string {
+	return hexutil.Encode(crypto.FromECDSAPub(pub))
+}
+
+// ParsePublicKey decodes an uncompressed or compressed secp256k1 public key.
+func ParsePublicKey(s string) (*ecdsa.PublicKey, error) {
+	b, err := hexutil.Decode(strings.TrimSpace(s))
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	var pub *ecdsa.PublicKey
+	if len(b) == 33 {
+		pub, err = crypto.DecompressPubkey(b)
+	} else {
+		pub, err = crypto.UnmarshalPubkey(b)
+	}
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	return pub, nil
+}
+
+// Seal encrypts attrs for the readers in recipients (wallet to public key).
+// Each reader's key must belong to its wallet.
+func Seal(attrs []ipfs.Attribute, recipients map[string]*ecdsa.PublicKey) (*Sealed, error) {
+	if len(attrs) == 0 {
+		return nil, errors.New("nothing to encrypt")
+	}
+	salt := make([]byte, saltBytes)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	plain, err := json.Marshal(Payload{Salt: hexutil.Encode(salt), Attributes: attrs})
+	if err != nil {
+		return nil, err
+	}
+
+	key := make([]byte, keyBytes)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out := &Sealed{
+		Commitment:  crypto.Keccak256Hash(plain).Hex(),
+		Nonce:       hexutil.Encode(nonce),
+		Ciphertext:  hexutil.Encode(gcm.Seal(nil, nonce, plain, nil)),
+		WrappedKeys: map[string]string{},
+	}
+	for wallet, pub := range recipients {
+		if !strings.EqualFold(crypto.PubkeyToAddress(*pub).Hex(), wallet) {
+			return nil, fmt.Errorf("public key does not belong to %s", wallet)
+		}
+		wrapped, err := ecies.Encrypt(rand.Reader, ecies.ImportECDSAPublic(pub), key, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("wrap key for %s: %w", wallet, err)
+		}
+		out.WrappedKeys[strings.ToLower(wallet)] = hexutil.Encode(wrapped)
+	}
+	return out, nil
+}
+
+// Open decrypts an envelope with its hex content key and checks the payload
+// against the commitment.
+func Open(nonce, ciphertext, commitment, contentKey string) (*Payload, error) {
+	key, err := hexutil.Decode(strings.TrimSpace(contentKey))
+	if err != nil || len(key) != keyBytes {
+		return nil, ErrInvalidKey
+	}
+	n, err := hexutil.Decode(nonce)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	ct, err := hexutil.Decode(ciphertext)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(n) != gcm.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	plain, err := gcm.Open(nil, n, ct, nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	if !strings.EqualFold(crypto.Keccak256Hash(plain).Hex(), commitment) {
+		return nil, ErrCommitment
+	}
+	var p Payload
+	if err := json.Unmarshal(plain, &p); err != nil {
+		return nil, fmt.Errorf("decode payload: %w", err)
+	}
+	return &p, nil
+}
+
+// UnwrapKey recovers the hex content key from a wrapped key with the
+// reader's private key. The server never holds these keys; this is for Go
+// clients and tooling.
+func UnwrapKey(prv *ecdsa.PrivateKey, wrapped string) (string, error) {
+	ct, err := hexutil.Decode(strings.TrimSpace(wrapped))
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	key, err := ecies.ImportECDSA(prv).Decrypt(ct, nil, nil)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return hexutil.Encode(key), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
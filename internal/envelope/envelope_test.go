@@ -0,0 +1,173 @@
+package envelope
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"vericred/internal/eth/ipfs"
+)
+
+type party struct {
+	key    *ecdsa.PrivateKey
+	wallet string
+}
+
+func newParty(t *testing.T) party {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return party{key: key, wallet: crypto.PubkeyToAddress(key.PublicKey).Hex()}
+}
+
+var testAttrs = []ipfs.Attribute{
+	{TraitType: "GPA", Value: "3.9"},
+	{TraitType: "Student ID", Value: "S-1024"},
+}
+
+func seal(t *testing.T, readers ...party) *Sealed {
+	t.Helper()
+	recipients := map[string]*ecdsa.PublicKey{}
+	for _, p := range readers {
+		recipients[p.wallet] = &p.key.PublicKey
+	}
+	s, err := Seal(testAttrs, recipients)
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	return s
+}
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	holder, issuer := newParty(t), newParty(t)
+	s := seal(t, holder, issuer)
+	if len(s.WrappedKeys) != 2 {
+		t.Fatalf("WrappedKeys has %d entries, want 2", len(s.WrappedKeys))
+	}
+	for _, p := range []party{holder, issuer} {
+		wrapped, ok := s.WrappedKeys[strings.ToLower(p.wallet)]
+		if !ok {
+			t.Fatalf("no wrapped key for %s", p.wallet)
+		}
+		key, err := UnwrapKey(p.key, wrapped)
+		if err != nil {
+			t.Fatalf("UnwrapKey(%s): %v", p.wallet, err)
+		}
+		payload, err := Open(s.Nonce, s.Ciphertext, s.Commitment, key)
+		if err != nil {
+			t.Fatalf("Open(%s): %v", p.wallet, err)
+		}
+		if !reflect.DeepEqual(payload.Attributes, testAttrs) {
+			t.Errorf("Open(%s) attributes = %v, want %v", p.wallet, payload.Attributes, testAttrs)
+		}
+		if b, err := hexutil.Decode(payload.Salt); err != nil || len(b) != saltBytes {
+			t.Errorf("salt = %q, want %d random bytes", payload.Salt, saltBytes)
+		}
+	}
+}
+
+func TestSealIsRandomized(t *testing.T) {
+	holder := newParty(t)
+	a, b := seal(t, holder), seal(t, holder)
+	if a.Commitment == b.Commitment || a.Ciphertext == b.Ciphertext {
+		t.Error("sealing the same attributes twice gave the same commitment or ciphertext")
+	}
+}
+
+func TestUnwrapWithOtherKey(t *testing.T) {
+	holder, issuer, outsider := newParty(t), newParty(t), newParty(t)
+	s := seal(t, holder, issuer)
+	holderWrapped := s.WrappedKeys[strings.ToLower(holder.wallet)]
+	for name, p := range map[string]party{"other party": issuer, "outsider": outsider} {
+		if _, err := UnwrapKey(p.key, holderWrapped); !errors.Is(err, ErrDecrypt) {
+			t.Errorf("UnwrapKey with %s's key error = %v, want ErrDecrypt", name, err)
+		}
+	}
+	if _, err := UnwrapKey(holder.key, "not hex"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("UnwrapKey(garbage) error = %v, want ErrDecrypt", err)
+	}
+}
+
+func TestOpenRejects(t *testing.T) {
+	holder := newParty(t)
+	s := seal(t, holder)
+	key, err := UnwrapKey(holder.key, s.WrappedKeys[strings.ToLower(holder.wallet)])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Flip the last byte of a hex string
+	flip := func(h string) string {
+		b := hexutil.MustDecode(h)
+		b[len(b)-1] ^= 0x01
+		return hexutil.Encode(b)
+	}
+	other := seal(t, holder)
+	otherKey, err := UnwrapKey(holder.key, other.WrappedKeys[strings.ToLower(holder.wallet)])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name                                   string
+		nonce, ciphertext, commitment, content string
+		want                                   error
+	}{
+		{"tampered ciphertext", s.Nonce, flip(s.Ciphertext), s.Commitment, key, ErrDecrypt},
+		{"truncated ciphertext", s.Nonce, s.Ciphertext[:len(s.Ciphertext)-2], s.Commitment, key, ErrDecrypt},
+		{"tampered nonce", flip(s.Nonce), s.Ciphertext, s.Commitment, key, ErrDecrypt},
+		{"short nonce", "0x00", s.Ciphertext, s.Commitment, key, ErrDecrypt},
+		{"another envelope's key", s.Nonce, s.Ciphertext, s.Commitment, otherKey, ErrDecrypt},
+		{"another envelope's commitment", s.Nonce, s.Ciphertext, other.Commitment, key, ErrCommitment},
+		{"short key", s.Nonce, s.Ciphertext, s.Commitment, key[:len(key)-2], ErrInvalidKey},
+		{"non-hex key", s.Nonce, s.Ciphertext, s.Commitment, "content-key", ErrInvalidKey},
+		{"non-hex ciphertext", s.Nonce, "zz", s.Commitment, key, ErrDecrypt},
+	}
+	for _, tt := range tests {
+		if _, err := Open(tt.nonce, tt.ciphertext, tt.commitment, tt.content); !errors.Is(err, tt.want) {
+			t.Errorf("%s: Open error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+	// The untouched envelope still opens, so the failures above are the edits
+	if _, err := Open(s.Nonce, s.Ciphertext, s.Commitment, " "+key+" "); err != nil {
+		t.Errorf("Open with the right key: %v", err)
+	}
+}
+
+func TestSealRejectsForeignKey(t *testing.T) {
+	holder, issuer := newParty(t), newParty(t)
+	_, err := Seal(testAttrs, map[string]*ecdsa.PublicKey{holder.wallet: &issuer.key.PublicKey})
+	if err == nil {
+		t.Error("Seal accepted a public key that doesn't belong to the wallet")
+	}
+	if _, err := Seal(nil, map[string]*ecdsa.PublicKey{holder.wallet: &holder.key.PublicKey}); err == nil {
+		t.Error("Seal accepted an empty attribute list")
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	p := newParty(t)
+	for name, s := range map[string]string{
+		"uncompressed": PublicKeyHex(&p.key.PublicKey),
+		"compressed":   hexutil.Encode(crypto.CompressPubkey(&p.key.PublicKey)),
+	} {
+		pub, err := ParsePublicKey(s)
+		if err != nil {
+			t.Fatalf("%s: ParsePublicKey: %v", name, err)
+		}
+		if got := crypto.PubkeyToAddress(*pub).Hex(); got != p.wallet {
+			t.Errorf("%s: key belongs to %s, want %s", name, got, p.wallet)
+		}
+	}
+	for _, s := range []string{"", "0x", "0x04", "not hex", "0x02" + strings.Repeat("ff", 32)} {
+		if _, err := ParsePublicKey(s); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("ParsePublicKey(%q) error = %v, want ErrInvalidKey", s, err)
+		}
+	}
+}
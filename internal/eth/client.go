@@ -1 +1,368 @@
-package eth
\ No newline at end of file
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"vericred/internal/eth/build"
+)
+
+var (
+	// ErrConfig means the RPC endpoint or contract address is unusable.
+	ErrConfig = errors.New("eth: invalid configuration")
+	// ErrUnavailable means the RPC node could not be reached or kept failing
+	// after the configured retries.
+	ErrUnavailable = errors.New("eth: rpc unavailable")
+	// ErrReverted means the contract rejected the call.
+	ErrReverted = errors.New("eth: call reverted")
+	// ErrTokenNotFound means the token was never minted or was burned.
+	ErrTokenNotFound = errors.New("eth: token does not exist")
+)
+
+// Config says which node and contract the client talks to.
+type Config struct {
+	RPCURL          string
+	ContractAddress string
+	// CallTimeout bounds each attempt of a read.
+	CallTimeout time.Duration
+	// ReadRetries is how many times a read is retried after a transient
+	// failure; RetryBackoff is the first wait, doubled on every retry.
+	ReadRetries  int
+	RetryBackoff time.Duration
+	// GasHeadroom is the percentage added to a transaction's estimated gas.
+	GasHeadroom uint64
+	// MaxFeePerGas caps the fee cap of every transaction, in wei; nil means
+	// no cap.
+	MaxFeePerGas *big.Int
+	// A transaction still unmined after StuckAfter is re-sent with higher
+	// fees, at most MaxBumps times; after that it is only re-broadcast.
+	StuckAfter time.Duration
+	MaxBumps   int
+	// Signer signs transactions; without one the client is read-only.
+	Signer Signer
+}
+
+// ConfigFromEnv reads ETH_RPC_URL, CONTRACT_ADDRESS, ETH_CALL_TIMEOUT
+// (default 10s), ETH_READ_RETRIES (default 3) and ETH_RETRY_BACKOFF
+// (default 500ms). The endpoint and address default to the Sepolia
+// deployment. Transactions use ETH_GAS_HEADROOM (percent, default 20),
+// ETH_MAX_FEE_GWEI (default 200, 0 for no cap), ETH_TX_STUCK_AFTER (default
+// 3m) and ETH_TX_MAX_BUMPS (default 5).
+func ConfigFromEnv() Config {
+	cfg := Config{
+		RPCURL:          C.rpcURL,
+		ContractAddress: C.cAddress,
+		CallTimeout:     envDuration("ETH_CALL_TIMEOUT", 10*time.Second),
+		ReadRetries:     3,
+		RetryBackoff:    envDuration("ETH_RETRY_BACKOFF", 500*time.Millisecond),
+		GasHeadroom:     20,
+		MaxFeePerGas:    new(big.Int).Mul(big.NewInt(200), big.NewInt(params.GWei)),
+		StuckAfter:      envDuration("ETH_TX_STUCK_AFTER", 3*time.Minute),
+		MaxBumps:        5,
+	}
+	if v := strings.TrimSpace(os.Getenv("ETH_RPC_URL")); v != "" {
+		cfg.RPCURL = v
+	}
+	if v := strings.TrimSpace(os.Getenv("CONTRACT_ADDRESS")); v != "" {
+		cfg.ContractAddress = v
+	}
+	if v := strings.TrimSpace(os.Getenv("ETH_READ_RETRIES")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.ReadRetries = n
+		} else {
+			log.Printf("ignoring ETH_READ_RETRIES %q", v)
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("ETH_GAS_HEADROOM")); v != "" {
+		if n, err := strconv.ParseUint(v, 10, 64); err == nil && n <= 200 {
+			cfg.GasHeadroom = n
+		} else {
+			log.Printf("ignoring ETH_GAS_HEADROOM %q", v)
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("ETH_MAX_FEE_GWEI")); v != "" {
+		if n, err := strconv.ParseUint(v, 10, 64); err == nil {
+			cfg.MaxFeePerGas = nil
+			if n > 0 {
+				cfg.MaxFeePerGas = new(big.Int).Mul(new(big.Int).SetUint64(n), big.NewInt(params.GWei))
+			}
+		} else {
+			log.Printf("ignoring ETH_MAX_FEE_GWEI %q", v)
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("ETH_TX_MAX_BUMPS")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.MaxBumps = n
+		} else {
+			log.Printf("ignoring ETH_TX_MAX_BUMPS %q", v)
+		}
+	}
+	return cfg
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Printf("ignoring %s %q", key, v)
+		return def
+	}
+	return d
+}
+
+// Client is a long-lived connection to the RPC node and the credential
+// contract, shared by handlers and jobs. Reads retry transient RPC failures;
+// transactions go through the transaction manager (see send), which owns
+// nonces and re-sends stuck transactions.
+type Client struct {
+	cfg      Config
+	rpc      *ethclient.Client
+	contract *build.Build
+	address  common.Address
+
+	mu      sync.Mutex
+	chainID *big.Int
+	signer  Signer
+	// newSigner builds the signer on first use when none was given, so a
+	// signer that is briefly unreachable doesn't leave the client read-only.
+	newSigner func() (Signer, error)
+	senders   map[common.Address]*senderState
+}
+
+// Dial connects to cfg.RPCURL and binds the contract at cfg.ContractAddress.
+func Dial(ctx context.Context, cfg Config) (*Client, error) {
+	if cfg.RPCURL == "" {
+		return nil, fmt.Errorf("%w: no RPC URL", ErrConfig)
+	}
+	if !common.IsHexAddress(cfg.ContractAddress) {
+		return nil, fmt.Errorf("%w: contract address %q", ErrConfig, cfg.ContractAddress)
+	}
+	if cfg.CallTimeout <= 0 {
+		cfg.CallTimeout = 10 * time.Second
+	}
+	if cfg.StuckAfter <= 0 {
+		cfg.StuckAfter = 3 * time.Minute
+	}
+	rc, err := ethclient.DialContext(ctx, cfg.RPCURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: dial: %v", ErrUnavailable, err)
+	}
+	address := common.HexToAddress(cfg.ContractAddress)
+	contract, err := build.NewBuild(address, rc)
+	if err != nil {
+		rc.Close()
+		return nil, fmt.Errorf("%w: bind contract: %v", ErrConfig, err)
+	}
+	return &Client{cfg: cfg, rpc: rc, contract: contract, address: address, signer: cfg.Signer}, nil
+}
+
+var (
+	defaultMu     sync.Mutex
+	defaultClient *Client
+)
+
+// Default returns the process-wide client built from ConfigFromEnv, signing
+// with SignerFromEnv. A failed dial is not remembered, so the next call tries
+// again.
+func Default(ctx context.Context) (*Client, error) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultClient != nil {
+		return defaultClient, nil
+	}
+	c, err := Dial(ctx, ConfigFromEnv())
+	if err != nil {
+		return nil, err
+	}
+	c.newSigner = SignerFromEnv
+	defaultClient = c
+	return c, nil
+}
+
+// Close releases the RPC connection.
+func (c *Client) Close() {
+	c.rpc.Close()
+}
+
+// Address is the contract's address.
+func (c *Client) Address() common.Address {
+	return c.address
+}
+
+// ChainID returns the chain the node serves, cached after the first lookup.
+func (c *Client) ChainID(ctx context.Context) (*big.Int, error) {
+	c.mu.Lock()
+	cached := c.chainID
+	c.mu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+	id, err := retry(ctx, c, "chainId", func(ctx context.Context) (*big.Int, error) {
+		return c.rpc.ChainID(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.chainID = id
+	c.mu.Unlock()
+	return id, nil
+}
+
+// Signer returns the transaction signer, building it on first use. It fails
+// with ErrNoSigner when none is configured.
+func (c *Client) Signer() (Signer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.signer != nil {
+		return c.signer, nil
+	}
+	if c.newSigner == nil {
+		return nil, ErrNoSigner
+	}
+	s, err := c.newSigner()
+	if err != nil {
+		return nil, err
+	}
+	c.signer = s
+	return s, nil
+}
+
+// LatestBlock returns the current head block number.
+func (c *Client) LatestBlock(ctx context.Context) (uint64, error) {
+	return retry(ctx, c, "blockNumber", c.rpc.BlockNumber)
+}
+
+// CurrentTokenID returns the contract's token counter.
+func (c *Client) CurrentTokenID(ctx context.Context) (*big.Int, error) {
+	return retry(ctx, c, "tokenIds", func(ctx context.Context) (*big.Int, error) {
+		return c.contract.TokenIds(&bind.CallOpts{Context: ctx})
+	})
+}
+
+// TokenURI returns the tokenURI stored on-chain for id.
+func (c *Client) TokenURI(ctx context.Context, id *big.Int) (string, error) {
+	return retry(ctx, c, "tokenURI("+id.String()+")", func(ctx context.Context) (string, error) {
+		return c.contract.TokenURI(&bind.CallOpts{Context: ctx}, id)
+	})
+}
+
+// OwnerOf returns the current owner of id; tokens that were never minted
+// (or were burned) fail with ErrTokenNotFound.
+func (c *Client) OwnerOf(ctx context.Context, id *big.Int) (common.Address, error) {
+	return retry(ctx, c, "ownerOf("+id.String()+")", func(ctx context.Context) (common.Address, error) {
+		return c.contract.OwnerOf(&bind.CallOpts{Context: ctx}, id)
+	})
+}
+
+// AllOrgs lists the organizations registered on the contract.
+func (c *Client) AllOrgs(ctx context.Context) ([]common.Address, error) {
+	return retry(ctx, c, "allOrgs", func(ctx context.Context) ([]common.Address, error) {
+		return c.contract.AllOrgs(&bind.CallOpts{Context: ctx})
+	})
+}
+
+// IsVerifiedOrg reports whether the contract lists org as a verified
+// organization (the contract's isVerfiedOrg).
+func (c *Client) IsVerifiedOrg(ctx context.Context, org common.Address) (bool, error) {
+	return retry(ctx, c, "isVerfiedOrg("+org.Hex()+")", func(ctx context.Context) (bool, error) {
+		return c.contract.IsVerfiedOrg(&bind.CallOpts{Context: ctx}, org)
+	})
+}
+
+// retry runs a read with a per-attempt timeout, retrying transient failures
+// with exponential backoff, and classifies the final error.
+func retry[T any](ctx context.Context, c *Client, method string, call func(context.Context) (T, error)) (T, error) {
+	var zero T
+	backoff := c.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		callCtx, cancel := context.WithTimeout(ctx, c.cfg.CallTimeout)
+		v, err := call(callCtx)
+		cancel()
+		if err == nil {
+			return v, nil
+		}
+		if ctx.Err() != nil {
+			return zero, fmt.Errorf("%s: %w", method, ctx.Err())
+		}
+		if !transient(err) {
+			return zero, classify(method, err)
+		}
+		if attempt >= c.cfg.ReadRetries {
+			return zero, fmt.Errorf("%s: %w after %d attempts: %v", method, ErrUnavailable, attempt+1, err)
+		}
+		select {
+		case <-ctx.Done():
+			return zero, fmt.Errorf("%s: %w", method, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// transient reports whether err is worth retrying: timeouts, dropped
+// connections, rate limiting and server-side errors, but never a revert.
+func transient(err error) bool {
+	if isRevert(err) {
+		return false
+	}
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == 429 || httpErr.StatusCode >= 500
+	}
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.As(err, &netErr):
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"timeout", "connection reset", "too many requests", "header not found", "bad gateway", "service unavailable"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func isRevert(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "execution reverted")
+}
+
+// classify wraps a permanent failure in the matching sentinel.
+func classify(method string, err error) error {
+	if !isRevert(err) {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"nonexistent token", "invalid token id", "erc721nonexistenttoken", "0x7e273289"} {
+		if strings.Contains(msg, s) {
+			return fmt.Errorf("%s: %w: %v", method, ErrTokenNotFound, err)
+		}
+	}
+	return fmt.Errorf("%s: %w: %v", method, ErrReverted, err)
+}
@@ -0,0 +1,86 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrNoMintEvent is returned when no mint Transfer exists for a token.
+var ErrNoMintEvent = errors.New("no mint event for token")
+
+// MintEvent is a Transfer event emitted by the contract with the zero address
+// as sender, i.e. a freshly minted credential token.
+type MintEvent struct {
+	TokenID     *big.Int
+	To          common.Address
+	BlockNumber uint64
+	TxHash      common.Hash
+}
+
+// MintEvents scans Transfer events from the zero address in the inclusive
+// block range [start, end] using the generated BuildFilterer.
+func (c *Client) MintEvents(ctx context.Context, start, end uint64) ([]MintEvent, error) {
+	return retry(ctx, c, fmt.Sprintf("transfers %d-%d", start, end), func(ctx context.Context) ([]MintEvent, error) {
+		opts := &bind.FilterOpts{Start: start, End: &end, Context: ctx}
+		it, err := c.contract.BuildFilterer.FilterTransfer(opts, []common.Address{{}}, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		defer it.Close()
+
+		var out []MintEvent
+		for it.Next() {
+			ev := it.Event
+			out = append(out, MintEvent{
+				TokenID:     ev.TokenId,
+				To:          ev.To,
+				BlockNumber: ev.Raw.BlockNumber,
+				TxHash:      ev.Raw.TxHash,
+			})
+		}
+		return out, it.Error()
+	})
+}
+
+// MintTxOf finds the transaction that minted id by scanning Transfer events
+// from the zero address for that token, starting at fromBlock.
+func (c *Client) MintTxOf(ctx context.Context, id *big.Int, fromBlock uint64) (common.Hash, error) {
+	hash, err := retry(ctx, c, "mint of "+id.String(), func(ctx context.Context) (common.Hash, error) {
+		opts := &bind.FilterOpts{Start: fromBlock, Context: ctx}
+		it, err := c.contract.BuildFilterer.FilterTransfer(opts, []common.Address{{}}, nil, []*big.Int{id})
+		if err != nil {
+			return common.Hash{}, err
+		}
+		defer it.Close()
+		if it.Next() {
+			return it.Event.Raw.TxHash, nil
+		}
+		return common.Hash{}, it.Error()
+	})
+	if err == nil && hash == (common.Hash{}) {
+		return hash, ErrNoMintEvent
+	}
+	return hash, err
+}
+
+// TxSender returns the account that sent the transaction with the given hash.
+func (c *Client) TxSender(ctx context.Context, txHash common.Hash) (common.Address, error) {
+	tx, err := retry(ctx, c, "transaction "+txHash.Hex(), func(ctx context.Context) (*types.Transaction, error) {
+		tx, _, err := c.rpc.TransactionByHash(ctx, txHash)
+		return tx, err
+	})
+	if err != nil {
+		return common.Address{}, err
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("sender of %s: %w", txHash.Hex(), err)
+	}
+	return from, nil
+}
@@ -0,0 +1,206 @@
+package ipfs
+
+import (
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// Multiformat codes used by the CIDs this package computes.
+const (
+	cidV1        = 0x01
+	codecRaw     = 0x55
+	hashSHA2_256 = 0x12
+	sha256Len    = 32
+)
+
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// ErrInvalidCID is returned for strings that don't parse as a CID.
+var ErrInvalidCID = errors.New("invalid CID")
+
+// ComputeCID returns the CIDv1 (raw codec, sha2-256, base32) of data. It is
+// the CID Kubo assigns with --cid-version=1 --raw-leaves to content that fits
+// in a single block, which covers credential metadata.
+func ComputeCID(data []byte) string {
+	sum := sha256.Sum256(data)
+	buf := binary.AppendUvarint(nil, cidV1)
+	buf = binary.AppendUvarint(buf, codecRaw)
+	buf = binary.AppendUvarint(buf, hashSHA2_256)
+	buf = binary.AppendUvarint(buf, sha256Len)
+	buf = append(buf, sum[:]...)
+	return "b" + strings.ToLower(b32.EncodeToString(buf))
+}
+
+// ValidCID reports whether s looks like a CID: a base58 CIDv0 ("Qm...") or a
+// base32 CIDv1 ("b...").
+func ValidCID(s string) bool {
+	switch {
+	case len(s) == 46 && strings.HasPrefix(s, "Qm"):
+		for _, r := range s {
+			if !strings.ContainsRune(base58Alphabet, r) {
+				return false
+			}
+		}
+		return true
+	case strings.HasPrefix(s, "b") && len(s) > 8:
+		raw, err := b32.DecodeString(strings.ToUpper(s[1:]))
+		if err != nil {
+			return false
+		}
+		v, n := binary.Uvarint(raw)
+		return n > 0 && v == cidV1
+	}
+	return false
+}
+
+// CanonicalURI is the form stored for content: ipfs://<cid>[/path].
+func CanonicalURI(cid string) string {
+	return "ipfs://" + strings.TrimPrefix(strings.TrimSpace(cid), "/")
+}
+
+// CanonicalLink rewrites gateway URLs (https://host/ipfs/<cid>/...) and
+// ipfs://ipfs/<cid> to ipfs://<cid>/...; anything else is returned trimmed.
+func CanonicalLink(link string) string {
+	link = strings.TrimSpace(link)
+	key := ContentKey(link)
+	if key == link {
+		return link
+	}
+	cid, _, _ := strings.Cut(key, "/")
+	if !ValidCID(cid) {
+		return link
+	}
+	return CanonicalURI(key)
+}
+
+// More multiformat codes, for checking content fetched under other CIDs.
+const (
+	codecDagPB = 0x70
+	// Files up to the default chunk size are a single dag-pb node.
+	unixfsChunkSize = 256 * 1024
+)
+
+// ErrCIDMismatch is returned when fetched content does not hash to the CID it
+// was requested under.
+var ErrCIDMismatch = errors.New("content does not match its CID")
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+type cidInfo struct {
+	version int
+	codec   uint64
+	hash    uint64
+	digest  []byte
+}
+
+func parseCID(s string) (cidInfo, error) {
+	var raw []byte
+	switch {
+	case len(s) == 46 && strings.HasPrefix(s, "Qm"):
+		mh, err := decodeBase58(s)
+		if err != nil || len(mh) != 2+sha256Len || mh[0] != hashSHA2_256 || mh[1] != sha256Len {
+			return cidInfo{}, ErrInvalidCID
+		}
+		return cidInfo{version: 0, codec: codecDagPB, hash: hashSHA2_256, digest: mh[2:]}, nil
+	case strings.HasPrefix(s, "b"):
+		b, err := b32.DecodeString(strings.ToUpper(s[1:]))
+		if err != nil {
+			return cidInfo{}, ErrInvalidCID
+		}
+		raw = b
+	case strings.HasPrefix(s, "z"):
+		b, err := decodeBase58(s[1:])
+		if err != nil {
+			return cidInfo{}, ErrInvalidCID
+		}
+		raw = b
+	default:
+		return cidInfo{}, ErrInvalidCID
+	}
+	var fields [4]uint64
+	for i := range fields {
+		v, n := binary.Uvarint(raw)
+		if n <= 0 {
+			return cidInfo{}, ErrInvalidCID
+		}
+		fields[i], raw = v, raw[n:]
+	}
+	if fields[0] != cidV1 || uint64(len(raw)) != fields[3] {
+		return cidInfo{}, ErrInvalidCID
+	}
+	return cidInfo{version: 1, codec: fields[1], hash: fields[2], digest: raw}, nil
+}
+
+// VerifyCID checks data against cid. It returns true when the CID was
+// recomputed and matches, false when this kind of CID can't be recomputed
+// from the bytes alone (other hash functions or codecs, multi-block files),
+// and ErrCIDMismatch when the content is not what the CID names.
+func VerifyCID(cid string, data []byte) (bool, error) {
+	info, err := parseCID(cid)
+	if err != nil {
+		return false, err
+	}
+	if info.hash != hashSHA2_256 {
+		return false, nil
+	}
+	var sum [sha256Len]byte
+	switch info.codec {
+	case codecRaw:
+		sum = sha256.Sum256(data)
+	case codecDagPB:
+		if len(data) > unixfsChunkSize {
+			return false, nil
+		}
+		sum = sha256.Sum256(unixfsFileNode(data))
+	default:
+		return false, nil
+	}
+	if string(sum[:]) != string(info.digest) {
+		return false, ErrCIDMismatch
+	}
+	return true, nil
+}
+
+// unixfsFileNode encodes data as the single dag-pb node IPFS builds for a
+// file that fits in one chunk: PBNode{Data: UnixFS{Type: File, Data,
+// filesize}}.
+func unixfsFileNode(data []byte) []byte {
+	var fsNode []byte
+	fsNode = append(fsNode, 0x08, 0x02)
+	if len(data) > 0 {
+		fsNode = append(fsNode, 0x12)
+		fsNode = binary.AppendUvarint(fsNode, uint64(len(data)))
+		fsNode = append(fsNode, data...)
+	}
+	fsNode = append(fsNode, 0x18)
+	fsNode = binary.AppendUvarint(fsNode, uint64(len(data)))
+
+	node := []byte{0x0a}
+	node = binary.AppendUvarint(node, uint64(len(fsNode)))
+	return append(node, fsNode...)
+}
+
+func decodeBase58(s string) ([]byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, r := range s {
+		i := strings.IndexRune(base58Alphabet, r)
+		if i < 0 {
+			return nil, ErrInvalidCID
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(i)))
+	}
+	out := n.Bytes()
+	for _, r := range s {
+		if r != '1' {
+			break
+		}
+		out = append([]byte{0}, out...)
+	}
+	return out, nil
+}
@@ -0,0 +1,133 @@
+package ipfs
+
+import (
+	"errors"
+	"testing"
+)
+
+// Known answers: the CIDv0 values are what `ipfs add` prints for these
+// files, the raw CIDv1 values what `ipfs add --cid-version=1 --raw-leaves`
+// prints.
+var cidVectors = []struct {
+	name    string
+	data    string
+	raw     string // CIDv1, raw codec
+	dagPB   string // CIDv0, single dag-pb node
+	dagPBv1 string // CIDv1, single dag-pb node
+}{
+	{
+		name:    "empty",
+		data:    "",
+		raw:     "bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku",
+		dagPB:   "QmbFMke1KXqnYyBBWxB74N4c5SBnJMVAiMNRcGu6x1AwQH",
+		dagPBv1: "bafybeif7ztnhq65lumvvtr4ekcwd2ifwgm3awq4zfr3srh462rwyinlb4y",
+	},
+	{
+		name:    "hello world",
+		data:    "hello world",
+		raw:     "bafkreifzjut3te2nhyekklss27nh3k72ysco7y32koao5eei66wof36n5e",
+		dagPB:   "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD",
+		dagPBv1: "bafybeihykld7uyxzogax6vgyvag42y7464eywpf55gxi5qpoisibh3c5wa",
+	},
+	{
+		name:    "hello world newline",
+		data:    "hello world\n",
+		raw:     "bafkreifjjcie6lypi6ny7amxnfftagclbuxndqonfipmb64f2km2devei4",
+		dagPB:   "QmT78zSuBmuS4z925WZfrqQ1qHaJ56DQaTfyMUF7F8ff5o",
+		dagPBv1: "bafybeicg2rebjoofv4kbyovkw7af3rpiitvnl6i7ckcywaq6xjcxnc2mby",
+	},
+}
+
+func TestComputeCID(t *testing.T) {
+	for _, v := range cidVectors {
+		if got := ComputeCID([]byte(v.data)); got != v.raw {
+			t.Errorf("%s: ComputeCID = %s, want %s", v.name, got, v.raw)
+		}
+	}
+}
+
+func TestVerifyCID(t *testing.T) {
+	for _, v := range cidVectors {
+		for _, cid := range []string{v.raw, v.dagPB, v.dagPBv1} {
+			ok, err := VerifyCID(cid, []byte(v.data))
+			if err != nil || !ok {
+				t.Errorf("%s: VerifyCID(%s) = %v, %v; want true, nil", v.name, cid, ok, err)
+			}
+			ok, err = VerifyCID(cid, []byte(v.data+"x"))
+			if !errors.Is(err, ErrCIDMismatch) || ok {
+				t.Errorf("%s: VerifyCID(%s) on other content = %v, %v; want false, ErrCIDMismatch", v.name, cid, ok, err)
+			}
+		}
+	}
+}
+
+func TestVerifyCIDUnverifiable(t *testing.T) {
+	// dag-cbor (0x71) can't be recomputed from the bytes alone
+	ok, err := VerifyCID("bafyreicecnx2gvntm6fbcrvnc336qze6st5u7qq7457igegamd3bzkx7ri", []byte("{}"))
+	if ok || err != nil {
+		t.Errorf("VerifyCID(dag-cbor) = %v, %v; want false, nil", ok, err)
+	}
+	// A dag-pb file over one chunk is a tree of blocks
+	big := make([]byte, unixfsChunkSize+1)
+	ok, err = VerifyCID(cidVectors[0].dagPB, big)
+	if ok || err != nil {
+		t.Errorf("VerifyCID(multi-block) = %v, %v; want false, nil", ok, err)
+	}
+}
+
+func TestVerifyCIDMalformed(t *testing.T) {
+	for _, cid := range []string{
+		"",
+		"not-a-cid",
+		"Qm0000000000000000000000000000000000000000000O", // 0 and O aren't base58
+		"bafkrei", // truncated
+		"bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzj", // digest shorter than its length
+		"b!!!!!!!!!",
+	} {
+		if _, err := VerifyCID(cid, nil); !errors.Is(err, ErrInvalidCID) {
+			t.Errorf("VerifyCID(%q) error = %v, want ErrInvalidCID", cid, err)
+		}
+	}
+}
+
+func TestValidCID(t *testing.T) {
+	for _, v := range cidVectors {
+		for _, cid := range []string{v.raw, v.dagPB, v.dagPBv1} {
+			if !ValidCID(cid) {
+				t.Errorf("ValidCID(%s) = false", cid)
+			}
+		}
+	}
+	for _, s := range []string{"", "Qm123", "bafy", "ipfs://" + cidVectors[0].raw, "QmbFMke1KXqnYyBBWxB74N4c5SBnJMVAiMNRcGu6x1AwQ0"} {
+		if ValidCID(s) {
+			t.Errorf("ValidCID(%q) = true", s)
+		}
+	}
+}
+
+func TestCanonicalLink(t *testing.T) {
+	cid := cidVectors[1].raw
+	v0 := cidVectors[1].dagPB
+	tests := []struct {
+		in, want string
+	}{
+		{"ipfs://" + cid, "ipfs://" + cid},
+		{"  ipfs://" + cid + "  ", "ipfs://" + cid},
+		{"ipfs://ipfs/" + cid, "ipfs://" + cid},
+		{"ipfs://" + cid + "/metadata.json", "ipfs://" + cid + "/metadata.json"},
+		{"https://ipfs.io/ipfs/" + cid, "ipfs://" + cid},
+		{"https://gateway.pinata.cloud/ipfs/" + v0 + "/", "ipfs://" + v0},
+		{"http://127.0.0.1:8080/ipfs/" + v0 + "/a/b.json", "ipfs://" + v0 + "/a/b.json"},
+		// Malformed links are left alone (trimmed)
+		{"https://ipfs.io/ipfs/not-a-cid", "https://ipfs.io/ipfs/not-a-cid"},
+		{"ipfs://not-a-cid", "ipfs://not-a-cid"},
+		{"https://ipfs.io/ipfs/", "https://ipfs.io/ipfs/"},
+		{"https://example.com/credential.json", "https://example.com/credential.json"},
+		{" ", ""},
+	}
+	for _, tt := range tests {
+		if got := CanonicalLink(tt.in); got != tt.want {
+			t.Errorf("CanonicalLink(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -3,13 +3,13 @@ package ipfs
 type Credentials struct {
 	Name		 	string 			`json:"name"`
 	Description 	string 			`json:"description"`
-	Image 			[]byte 			`json:"image"`
-	ExternalURL 	string  		`json:"external_url"`
+	Image 			string 			`json:"image,omitempty"`
+	ExternalURL 	string  		`json:"external_url,omitempty"`
 	Attributes  	[]Attribute 	`json:"attributes"`
-	AnimationURL 	string 			`json:"animation_url"`
-	YoutubeURL 	 	string 			`json:"youtube_url"`
-	BgColor 	 	string 			`json:"background_color"`
-	CustomFields 	[]CustomField 	`json:"custom_field"`
+	AnimationURL 	string 			`json:"animation_url,omitempty"`
+	YoutubeURL 	 	string 			`json:"youtube_url,omitempty"`
+	BgColor 	 	string 			`json:"background_color,omitempty"`
+	CustomFields 	[]CustomField 	`json:"custom_field,omitempty"`
 }
 
 type Attribute struct {
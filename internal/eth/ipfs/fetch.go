@@ -0,0 +1,132 @@
+package ipfs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"vericred/internal/models"
+)
+
+// Metadata is the part of pinned credential metadata the backend inspects.
+// Attribute values are normalised to strings whatever their JSON type.
+type Metadata struct {
+	Name         string        `json:"name"`
+	Description  string        `json:"description"`
+	Image        string        `json:"image"`
+	AnimationURL string        `json:"animation_url"`
+	Attributes   []Attribute   `json:"attributes"`
+	CustomFields []CustomField `json:"custom_field"`
+}
+
+// Trait returns the value of the first attribute whose trait_type matches one
+// of names, case-insensitively.
+func (m *Metadata) Trait(names ...string) (string, bool) {
+	for _, a := range m.Attributes {
+		for _, n := range names {
+			if strings.EqualFold(strings.TrimSpace(a.TraitType), n) {
+				return strings.TrimSpace(a.Value), true
+			}
+		}
+	}
+	return "", false
+}
+
+// ContentKey reduces a token URI to the part that identifies its content:
+// the CID (plus path) for ipfs:// and gateway URLs, the trimmed URI otherwise.
+func ContentKey(uri string) string {
+	u := strings.TrimSpace(uri)
+	if i := strings.Index(u, "/ipfs/"); i >= 0 {
+		return strings.TrimRight(u[i+len("/ipfs/"):], "/")
+	}
+	if strings.HasPrefix(u, "ipfs://") {
+		return strings.TrimRight(strings.TrimPrefix(strings.TrimPrefix(u, "ipfs://"), "ipfs/"), "/")
+	}
+	return u
+}
+
+func init() {
+	models.ContentURL = GatewayURL
+}
+
+// gatewayBase is the HTTP gateway ipfs:// URIs are linked through: the
+// first of the resolver's gateways.
+func gatewayBase() string {
+	return DefaultResolver().Gateways[0]
+}
+
+// GatewayURL turns an ipfs:// URI into an HTTP gateway URL; other links are
+// returned trimmed.
+func GatewayURL(link string) string {
+	link = strings.TrimSpace(link)
+	if rest, ok := strings.CutPrefix(link, "ipfs://"); ok {
+		return gatewayBase() + "/ipfs/" + strings.TrimPrefix(rest, "ipfs/")
+	}
+	return link
+}
+
+// FetchMetadata downloads and decodes the metadata document at link.
+func FetchMetadata(ctx context.Context, link string) (*Metadata, error) {
+	md, _, err := ResolveMetadata(ctx, link)
+	return md, err
+}
+
+// ResolveMetadata is FetchMetadata that also reports where the document came
+// from and whether it matched its CID.
+func ResolveMetadata(ctx context.Context, link string) (*Metadata, *Resolution, error) {
+	res, err := Resolve(ctx, link)
+	if err != nil {
+		return nil, nil, err
+	}
+	md, err := ParseMetadata(res.Data)
+	if err != nil {
+		return nil, res, err
+	}
+	return md, res, nil
+}
+
+// FetchDocument downloads the raw document at link (at most 1MB) through
+// the resolver, so IPFS content is checked against its CID.
+func FetchDocument(ctx context.Context, link string) ([]byte, error) {
+	res, err := Resolve(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+	return res.Data, nil
+}
+
+// ParseMetadata decodes a metadata document, normalising attribute values
+// to strings.
+func ParseMetadata(body []byte) (*Metadata, error) {
+	var raw struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		// Older documents carried image as base64 bytes; only URIs are kept
+		Image        any    `json:"image"`
+		AnimationURL string `json:"animation_url"`
+		Attributes   []struct {
+			TraitType string `json:"trait_type"`
+			Value     any    `json:"value"`
+		} `json:"attributes"`
+		CustomFields []CustomField `json:"custom_field"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("metadata is not valid JSON: %w", err)
+	}
+	md := &Metadata{Name: raw.Name, Description: raw.Description, AnimationURL: raw.AnimationURL, CustomFields: raw.CustomFields}
+	if s, ok := raw.Image.(string); ok {
+		if u, err := url.Parse(s); err == nil && u.Scheme != "" {
+			md.Image = s
+		}
+	}
+	for _, a := range raw.Attributes {
+		v := ""
+		if a.Value != nil {
+			v = fmt.Sprint(a.Value)
+		}
+		md.Attributes = append(md.Attributes, Attribute{TraitType: a.TraitType, Value: v})
+	}
+	return md, nil
+}
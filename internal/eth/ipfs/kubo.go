@@ -0,0 +1,139 @@
+package ipfs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// KuboStore adds and pins content through a self-hosted Kubo node's HTTP
+// RPC API (/api/v0).
+type KuboStore struct {
+	api    string
+	client *http.Client
+}
+
+// NewKuboStore talks to the RPC API at apiURL, e.g. http://127.0.0.1:5001.
+func NewKuboStore(apiURL string) *KuboStore {
+	return &KuboStore{
+		api:    strings.TrimRight(apiURL, "/"),
+		client: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func (s *KuboStore) Name() string { return StoreKubo }
+
+func (s *KuboStore) Put(ctx context.Context, name string, data []byte) (string, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write(data); err != nil {
+		return "", err
+	}
+	if err := mw.Close(); err != nil {
+		return "", err
+	}
+
+	// CIDv1 with raw leaves, matching ComputeCID for single-block content
+	endpoint := s.api + "/api/v0/add?cid-version=1&raw-leaves=true&pin=true"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("kubo add: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return "", fmt.Errorf("kubo add: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var out struct {
+		Hash string `json:"Hash"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("kubo add: %w", err)
+	}
+	return out.Hash, nil
+}
+
+func (s *KuboStore) Get(ctx context.Context, cid string) ([]byte, error) {
+	if !ValidCID(cid) {
+		return nil, ErrInvalidCID
+	}
+	// offline keeps the node from searching the network for content it
+	// doesn't hold; callers fall back to the gateways
+	endpoint := s.api + "/api/v0/cat?offline=true&arg=" + url.QueryEscape(cid)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("kubo cat: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, ErrNotFound
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, MaxAssetSize))
+}
+
+func (s *KuboStore) Pinned(ctx context.Context, cid string) (bool, error) {
+	if !ValidCID(cid) {
+		return false, ErrInvalidCID
+	}
+	status, msg, err := s.rpc(ctx, "/api/v0/pin/ls?type=recursive&arg="+url.QueryEscape(cid))
+	if err != nil {
+		return false, fmt.Errorf("kubo pin/ls: %w", err)
+	}
+	switch {
+	case status == http.StatusOK:
+		return true, nil
+	case strings.Contains(msg, "not pinned"):
+		return false, nil
+	}
+	return false, fmt.Errorf("kubo pin/ls: %d: %s", status, msg)
+}
+
+func (s *KuboStore) Unpin(ctx context.Context, cid string) error {
+	if !ValidCID(cid) {
+		return ErrInvalidCID
+	}
+	status, msg, err := s.rpc(ctx, "/api/v0/pin/rm?arg="+url.QueryEscape(cid))
+	if err != nil {
+		return fmt.Errorf("kubo pin/rm: %w", err)
+	}
+	if status != http.StatusOK && !strings.Contains(msg, "not pinned") {
+		return fmt.Errorf("kubo pin/rm: %d: %s", status, msg)
+	}
+	return nil
+}
+
+// rpc POSTs to an RPC endpoint and returns the status and the start of the
+// response body.
+func (s *KuboStore) rpc(ctx context.Context, path string) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.api+path, nil)
+	if err != nil {
+		return 0, "", err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+	return resp.StatusCode, strings.TrimSpace(string(msg)), nil
+}
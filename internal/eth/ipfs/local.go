@@ -0,0 +1,131 @@
+package ipfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// LocalStore keeps content as files named by CID in a directory. CIDs are
+// computed locally, so the same document gets the same CID it would on IPFS,
+// but nothing is published to the network.
+type LocalStore struct {
+	dir string
+}
+
+// NewLocalStore creates dir if needed and returns a store over it.
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create content dir: %w", err)
+	}
+	return &LocalStore{dir: dir}, nil
+}
+
+func (s *LocalStore) Name() string { return StoreLocal }
+
+func (s *LocalStore) Put(ctx context.Context, name string, data []byte) (string, error) {
+	cid := ComputeCID(data)
+	path := filepath.Join(s.dir, cid)
+	if _, err := os.Stat(path); err == nil {
+		return cid, nil
+	}
+	// Write then rename so readers never see a partial file
+	tmp, err := os.CreateTemp(s.dir, cid+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	return cid, nil
+}
+
+func (s *LocalStore) Get(ctx context.Context, cid string) ([]byte, error) {
+	if !ValidCID(cid) {
+		return nil, ErrInvalidCID
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, cid))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (s *LocalStore) Pinned(ctx context.Context, cid string) (bool, error) {
+	if !ValidCID(cid) {
+		return false, ErrInvalidCID
+	}
+	_, err := os.Stat(filepath.Join(s.dir, cid))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *LocalStore) Unpin(ctx context.Context, cid string) error {
+	if !ValidCID(cid) {
+		return ErrInvalidCID
+	}
+	err := os.Remove(filepath.Join(s.dir, cid))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// MemoryStore keeps content in memory; it is meant for development and
+// tests and loses everything on restart.
+type MemoryStore struct {
+	mu   sync.RWMutex
+	data map[string][]byte
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{data: map[string][]byte{}}
+}
+
+func (s *MemoryStore) Name() string { return StoreMemory }
+
+func (s *MemoryStore) Put(ctx context.Context, name string, data []byte) (string, error) {
+	cid := ComputeCID(data)
+	s.mu.Lock()
+	s.data[cid] = append([]byte(nil), data...)
+	s.mu.Unlock()
+	return cid, nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, cid string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.data[cid]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), data...), nil
+}
+
+func (s *MemoryStore) Pinned(ctx context.Context, cid string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.data[cid]
+	return ok, nil
+}
+
+func (s *MemoryStore) Unpin(ctx context.Context, cid string) error {
+	s.mu.Lock()
+	delete(s.data, cid)
+	s.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,72 @@
+package ipfs
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/zde37/pinata-go-sdk/pinata"
+)
+
+// PinataStore pins content with Pinata. Content is read back through the
+// gateways, so Get always reports ErrNotFound.
+type PinataStore struct {
+	client *pinata.Client
+}
+
+// NewPinataStore authenticates with a Pinata JWT.
+func NewPinataStore(jwt string) *PinataStore {
+	return &PinataStore{client: pinata.New(pinata.NewAuthWithJWT(jwt))}
+}
+
+func (s *PinataStore) Name() string { return StorePinata }
+
+func (s *PinataStore) Put(ctx context.Context, name string, data []byte) (string, error) {
+	// The SDK pins files from disk
+	tmp, err := os.CreateTemp(os.TempDir(), "vericred-*.json")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := tmp.Write(data); err != nil {
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	resp, err := s.client.PinFile(tmp.Name(), &pinata.PinOptions{
+		PinataMetadata: pinata.PinataMetadata{Name: name},
+		PinataOptions:  pinata.Options{CidVersion: 1},
+	})
+	if err != nil {
+		return "", fmt.Errorf("pinata: %w", err)
+	}
+	return resp.IpfsHash, nil
+}
+
+func (s *PinataStore) Get(ctx context.Context, cid string) ([]byte, error) {
+	return nil, ErrNotFound
+}
+
+func (s *PinataStore) Pinned(ctx context.Context, cid string) (bool, error) {
+	resp, err := s.client.ListFiles(&pinata.ListFilesOptions{Cid: cid, Status: "pinned", PageLimit: 1})
+	if err != nil {
+		return false, fmt.Errorf("pinata: %w", err)
+	}
+	return len(resp.Rows) > 0, nil
+}
+
+func (s *PinataStore) Unpin(ctx context.Context, cid string) error {
+	if err := s.client.DeleteFile(cid); err != nil {
+		// Pinata answers 400 for CIDs the account doesn't pin
+		if pinned, perr := s.Pinned(ctx, cid); perr == nil && !pinned {
+			return nil
+		}
+		return fmt.Errorf("pinata: %w", err)
+	}
+	return nil
+}
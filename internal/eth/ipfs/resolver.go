@@ -0,0 +1,347 @@
+package ipfs
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrLinkNotAllowed is returned for plain links the resolver won't fetch:
+// anything but https, and hosts that resolve to non-public addresses.
+var ErrLinkNotAllowed = errors.New("link not allowed")
+
+// Integrity states reported for resolved content.
+const (
+	// IntegrityVerified: the content was hashed and matches its CID.
+	IntegrityVerified = "verified"
+	// IntegrityUnverified: the link is an IPFS CID, but not one that can be
+	// recomputed from the bytes alone (a path inside a directory, another
+	// hash function, a multi-block file).
+	IntegrityUnverified = "unverified"
+	// IntegrityNotIPFS: the link is a plain URL with nothing to check.
+	IntegrityNotIPFS = "not_ipfs"
+)
+
+// maxDocumentSize caps documents the resolver will download, and
+// MaxAssetSize the images and media ResolveAsset will. Only documents are
+// cached.
+const (
+	maxDocumentSize = 1 << 20
+	MaxAssetSize    = 16 << 20
+)
+
+// Resolution is a fetched document and how it was obtained.
+type Resolution struct {
+	Data []byte `json:"-"`
+	// CID is the content identifier the link names, "" for plain URLs.
+	CID string `json:"cid,omitempty"`
+	// Source is the content store or gateway the bytes came from.
+	Source    string `json:"source"`
+	Integrity string `json:"integrity"`
+	Cached    bool   `json:"cached"`
+}
+
+// Verified reports whether the content was checked against its CID.
+func (r *Resolution) Verified() bool {
+	return r != nil && r.Integrity == IntegrityVerified
+}
+
+// Resolver fetches IPFS content from the content store or a list of HTTP
+// gateways, checks it against its CID and caches what it has resolved.
+// Content addressed by CID never changes, so cache entries don't expire.
+type Resolver struct {
+	Gateways []string
+	// Timeout bounds each gateway attempt.
+	Timeout time.Duration
+	// Client fetches from the configured gateways.
+	Client *http.Client
+
+	// external fetches plain (non-IPFS) links, see externalClient.
+	external *http.Client
+
+	mu       sync.Mutex
+	cache    map[string]*list.Element
+	order    *list.List
+	capacity int
+}
+
+type cacheEntry struct {
+	key string
+	res Resolution
+}
+
+// NewResolver returns a resolver over gateways keeping up to cacheEntries
+// documents in memory.
+func NewResolver(gateways []string, timeout time.Duration, cacheEntries int) *Resolver {
+	return &Resolver{
+		Gateways: gateways,
+		Timeout:  timeout,
+		Client:   &http.Client{},
+		external: externalClient(),
+		cache:    map[string]*list.Element{},
+		order:    list.New(),
+		capacity: cacheEntries,
+	}
+}
+
+var (
+	resolverOnce    sync.Once
+	defaultResolver *Resolver
+)
+
+// DefaultResolver is configured from IPFS_GATEWAYS (comma separated, tried
+// in order; IPFS_GATEWAY is read when it is unset), IPFS_GATEWAY_TIMEOUT
+// (default 8s per gateway) and IPFS_CACHE_ENTRIES (default 512, 0 disables
+// caching).
+func DefaultResolver() *Resolver {
+	resolverOnce.Do(func() {
+		timeout := 8 * time.Second
+		if v := strings.TrimSpace(os.Getenv("IPFS_GATEWAY_TIMEOUT")); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				log.Printf("ignoring IPFS_GATEWAY_TIMEOUT %q", v)
+			} else {
+				timeout = d
+			}
+		}
+		entries := 512
+		if v := strings.TrimSpace(os.Getenv("IPFS_CACHE_ENTRIES")); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				log.Printf("ignoring IPFS_CACHE_ENTRIES %q", v)
+			} else {
+				entries = n
+			}
+		}
+		defaultResolver = NewResolver(gatewaysFromEnv(), timeout, entries)
+	})
+	return defaultResolver
+}
+
+func gatewaysFromEnv() []string {
+	list := os.Getenv("IPFS_GATEWAYS")
+	if strings.TrimSpace(list) == "" {
+		list = os.Getenv("IPFS_GATEWAY")
+	}
+	var out []string
+	for _, g := range strings.Split(list, ",") {
+		if g = strings.TrimSpace(g); g != "" {
+			out = append(out, strings.TrimSuffix(strings.TrimRight(g, "/"), "/ipfs"))
+		}
+	}
+	if len(out) == 0 {
+		out = []string{"https://ipfs.io", "https://dweb.link"}
+	}
+	return out
+}
+
+// Resolve fetches link with the default resolver.
+func Resolve(ctx context.Context, link string) (*Resolution, error) {
+	return DefaultResolver().Resolve(ctx, link)
+}
+
+// Resolve returns the document at link. IPFS links are read from the content
+// store when it holds them and otherwise from each gateway in turn; content
+// that does not match its CID is discarded, and if no source has the right
+// bytes the error wraps ErrCIDMismatch. Other links must be https URLs on
+// public addresses and are fetched as-is.
+func (rv *Resolver) Resolve(ctx context.Context, link string) (*Resolution, error) {
+	return rv.resolve(ctx, link, maxDocumentSize)
+}
+
+// ResolveAsset fetches an image or other media asset with the default
+// resolver, allowing up to MaxAssetSize bytes.
+func ResolveAsset(ctx context.Context, link string) (*Resolution, error) {
+	return DefaultResolver().resolve(ctx, link, MaxAssetSize)
+}
+
+func (rv *Resolver) resolve(ctx context.Context, link string, limit int) (*Resolution, error) {
+	link = strings.TrimSpace(link)
+	key := ContentKey(link)
+	if key == link {
+		u, err := url.Parse(link)
+		if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+			return nil, fmt.Errorf("%w: %q is neither an IPFS link nor an https URL", ErrLinkNotAllowed, link)
+		}
+		data, err := rv.get(ctx, rv.external, link, limit)
+		if err != nil {
+			return nil, err
+		}
+		return &Resolution{Data: data, Source: link, Integrity: IntegrityNotIPFS}, nil
+	}
+	cid, path, _ := strings.Cut(key, "/")
+	if !ValidCID(cid) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidCID, cid)
+	}
+	if res, ok := rv.cached(key); ok {
+		return res, nil
+	}
+
+	var errs []error
+	accept := func(data []byte, source string) (*Resolution, bool) {
+		res := &Resolution{Data: data, CID: cid, Source: source, Integrity: IntegrityUnverified}
+		if path == "" {
+			ok, err := VerifyCID(cid, data)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", source, err))
+				return nil, false
+			}
+			if ok {
+				res.Integrity = IntegrityVerified
+			}
+		}
+		if len(data) <= maxDocumentSize {
+			rv.store(key, *res)
+		}
+		return res, true
+	}
+
+	if path == "" {
+		data, err := Store().Get(ctx, cid)
+		switch {
+		case err == nil:
+			if res, ok := accept(data, Store().Name()); ok {
+				return res, nil
+			}
+		case !errors.Is(err, ErrNotFound):
+			errs = append(errs, fmt.Errorf("%s store: %w", Store().Name(), err))
+		}
+	}
+	for _, gw := range rv.Gateways {
+		if ctx.Err() != nil {
+			break
+		}
+		data, err := rv.get(ctx, rv.Client, gw+"/ipfs/"+key, limit)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", gw, err))
+			continue
+		}
+		if res, ok := accept(data, gw); ok {
+			return res, nil
+		}
+	}
+	if len(errs) == 0 {
+		errs = append(errs, errors.New("no gateways configured"))
+	}
+	return nil, fmt.Errorf("resolve %s: %w", key, errors.Join(errs...))
+}
+
+// get downloads url with client within the per-gateway timeout, refusing
+// bodies over limit bytes.
+func (rv *Resolver) get(ctx context.Context, client *http.Client, url string, limit int) ([]byte, error) {
+	if rv.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rv.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid link: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, int64(limit)+1))
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	if len(body) > limit {
+		return nil, fmt.Errorf("document is larger than %d bytes", limit)
+	}
+	return body, nil
+}
+
+// externalClient returns the client for plain links, which come from users
+// and from token metadata. It ignores proxy settings, refuses redirects away
+// from https and checks every address it connects to after DNS resolution,
+// so neither a redirect nor a rebinding hostname reaches internal services.
+func externalClient() *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, Control: refuseInternal}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Scheme != "https" {
+				return fmt.Errorf("%w: redirect to %s", ErrLinkNotAllowed, req.URL.Redacted())
+			}
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			return nil
+		},
+	}
+}
+
+// cgnat is the shared address space (RFC 6598), private in all but name.
+var cgnat = netip.MustParsePrefix("100.64.0.0/10")
+
+// refuseInternal is a net.Dialer Control hook that only lets connections to
+// public unicast addresses through: no loopback, private, link-local (cloud
+// metadata endpoints live there), multicast or unspecified addresses.
+func refuseInternal(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() || cgnat.Contains(ip) {
+		return fmt.Errorf("%w: %s is not a public address", ErrLinkNotAllowed, ip)
+	}
+	return nil
+}
+
+func (rv *Resolver) cached(key string) (*Resolution, bool) {
+	rv.mu.Lock()
+	defer rv.mu.Unlock()
+	el, ok := rv.cache[key]
+	if !ok {
+		return nil, false
+	}
+	rv.order.MoveToFront(el)
+	res := el.Value.(*cacheEntry).res
+	res.Cached = true
+	return &res, true
+}
+
+func (rv *Resolver) store(key string, res Resolution) {
+	if rv.capacity <= 0 {
+		return
+	}
+	rv.mu.Lock()
+	defer rv.mu.Unlock()
+	if el, ok := rv.cache[key]; ok {
+		el.Value.(*cacheEntry).res = res
+		rv.order.MoveToFront(el)
+		return
+	}
+	rv.cache[key] = rv.order.PushFront(&cacheEntry{key: key, res: res})
+	for rv.order.Len() > rv.capacity {
+		oldest := rv.order.Back()
+		rv.order.Remove(oldest)
+		delete(rv.cache, oldest.Value.(*cacheEntry).key)
+	}
+}
@@ -0,0 +1,257 @@
+package ipfs
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// MetadataSchema is the JSON schema every pinned credential document must
+// satisfy: ERC-721 metadata plus the attributes verification relies on.
+//
+//go:embed metadata.schema.json
+var MetadataSchema []byte
+
+// schemaNode is the subset of JSON Schema (draft-07) the metadata schema
+// uses. Keywords outside it are ignored.
+type schemaNode struct {
+	Description          string                 `json:"description"`
+	Type                 string                 `json:"type"`
+	Required             []string               `json:"required"`
+	Properties           map[string]*schemaNode `json:"properties"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Items                *schemaNode            `json:"items"`
+	MinItems             *int                   `json:"minItems"`
+	MinLength            *int                   `json:"minLength"`
+	MaxLength            *int                   `json:"maxLength"`
+	Pattern              string                 `json:"pattern"`
+	Format               string                 `json:"format"`
+	Const                any                    `json:"const"`
+	Enum                 []any                  `json:"enum"`
+	Contains             *schemaNode            `json:"contains"`
+	AllOf                []*schemaNode          `json:"allOf"`
+
+	re *regexp.Regexp
+}
+
+var metadataSchema = mustCompileSchema(MetadataSchema)
+
+func mustCompileSchema(data []byte) *schemaNode {
+	var root schemaNode
+	if err := json.Unmarshal(data, &root); err != nil {
+		panic("ipfs: metadata schema: " + err.Error())
+	}
+	if err := root.compile(); err != nil {
+		panic("ipfs: metadata schema: " + err.Error())
+	}
+	return &root
+}
+
+func (n *schemaNode) compile() error {
+	if n == nil {
+		return nil
+	}
+	if n.Pattern != "" {
+		re, err := regexp.Compile(n.Pattern)
+		if err != nil {
+			return fmt.Errorf("pattern %q: %w", n.Pattern, err)
+		}
+		n.re = re
+	}
+	children := append([]*schemaNode{n.Items, n.Contains}, n.AllOf...)
+	for _, p := range n.Properties {
+		children = append(children, p)
+	}
+	for _, c := range children {
+		if err := c.compile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SchemaError lists the ways a document fails the metadata schema.
+type SchemaError struct {
+	Problems []string
+}
+
+func (e *SchemaError) Error() string {
+	return "metadata does not match schema: " + strings.Join(e.Problems, "; ")
+}
+
+// ValidateMetadata checks an encoded metadata document against
+// MetadataSchema and returns the problems found, if any.
+func ValidateMetadata(data []byte) []string {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return []string{"not valid JSON: " + err.Error()}
+	}
+	if dec.More() {
+		return []string{"not valid JSON: trailing data after document"}
+	}
+	return metadataSchema.validate(doc, "")
+}
+
+// PinMetadata encodes md, validates it against MetadataSchema and pins it,
+// returning the canonical ipfs:// URI and the document size. Invalid
+// metadata is never pinned and comes back as a *SchemaError.
+func PinMetadata(ctx context.Context, md *Credentials) (string, int, error) {
+	data, err := json.Marshal(md)
+	if err != nil {
+		return "", 0, fmt.Errorf("encode metadata: %w", err)
+	}
+	if problems := ValidateMetadata(data); len(problems) > 0 {
+		return "", 0, &SchemaError{Problems: problems}
+	}
+	link, err := PinJSONContext(ctx, "credential.json", data)
+	return link, len(data), err
+}
+
+func (n *schemaNode) validate(v any, path string) []string {
+	at := func(msg string, args ...any) []string {
+		where := path
+		if where == "" {
+			where = "document"
+		}
+		return []string{where + ": " + fmt.Sprintf(msg, args...)}
+	}
+	if n.Const != nil && !reflect.DeepEqual(normalizeJSON(v), n.Const) {
+		return at("must be %v", n.Const)
+	}
+	if n.Enum != nil && !slices.ContainsFunc(n.Enum, func(e any) bool { return reflect.DeepEqual(normalizeJSON(v), e) }) {
+		return at("must be one of %v", n.Enum)
+	}
+	if n.Type != "" && jsonType(v) != n.Type {
+		if !(n.Type == "number" && jsonType(v) == "integer") {
+			return at("must be of type %s", n.Type)
+		}
+	}
+
+	var out []string
+	switch x := v.(type) {
+	case string:
+		length := utf8.RuneCountInString(x)
+		if n.MinLength != nil && length < *n.MinLength {
+			if *n.MinLength == 1 {
+				out = append(out, at("must not be empty")...)
+			} else {
+				out = append(out, at("must be at least %d characters", *n.MinLength)...)
+			}
+		}
+		if n.MaxLength != nil && length > *n.MaxLength {
+			out = append(out, at("must be at most %d characters", *n.MaxLength)...)
+		}
+		if n.re != nil && !n.re.MatchString(x) {
+			out = append(out, at("does not match %s", n.Pattern)...)
+		}
+		if n.Format == "uri" && x != "" {
+			if u, err := url.Parse(x); err != nil || u.Scheme == "" {
+				out = append(out, at("must be an absolute URI")...)
+			}
+		}
+	case map[string]any:
+		for _, name := range n.Required {
+			if _, ok := x[name]; !ok {
+				out = append(out, at("%q is required", name)...)
+			}
+		}
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child, ok := n.Properties[k]
+			if !ok {
+				if n.AdditionalProperties != nil && !*n.AdditionalProperties {
+					out = append(out, at("unknown property %q", k)...)
+				}
+				continue
+			}
+			out = append(out, child.validate(x[k], joinPath(path, k))...)
+		}
+	case []any:
+		if n.MinItems != nil && len(x) < *n.MinItems {
+			out = append(out, at("must have at least %d items", *n.MinItems)...)
+		}
+		if n.Items != nil {
+			for i, item := range x {
+				out = append(out, n.Items.validate(item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+		if n.Contains != nil && !n.anyMatches(x) {
+			out = append(out, at("%s", n.describe())...)
+		}
+	}
+	for _, sub := range n.AllOf {
+		out = append(out, sub.validate(v, path)...)
+	}
+	return out
+}
+
+func (n *schemaNode) anyMatches(items []any) bool {
+	for _, item := range items {
+		if len(n.Contains.validate(item, "")) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *schemaNode) describe() string {
+	if n.Description != "" {
+		return n.Description
+	}
+	return "no item matches the required shape"
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func jsonType(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := x.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case float64:
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// normalizeJSON converts json.Number to float64 so decoded documents compare
+// equal to schema constants.
+func normalizeJSON(v any) any {
+	if n, ok := v.(json.Number); ok {
+		f, _ := n.Float64()
+		return f
+	}
+	return v
+}
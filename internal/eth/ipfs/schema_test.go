@@ -0,0 +1,145 @@
+package ipfs
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+var (
+	testWallet = "0x" + strings.Repeat("a1", 20)
+	testHash   = "0x" + strings.Repeat("cd", 32)
+)
+
+// metadataDoc returns a document that passes MetadataSchema with extra
+// top-level members spliced in and the attribute list replaced when attrs
+// isn't empty.
+func metadataDoc(extra, attrs string) string {
+	if attrs == "" {
+		attrs = `[
+			{"trait_type": "Recipient Wallet", "value": "` + testWallet + `"},
+			{"trait_type": "Issuer Wallet", "value": "` + testWallet + `"},
+			{"trait_type": "Credential Type", "value": "Degree"},
+			{"trait_type": "Issue Date", "value": "2024-06-30"},
+			{"trait_type": "Credential Hash", "value": "` + testHash + `"}
+		]`
+	}
+	return `{"name": "B.Sc. Physics", "description": "", ` + extra + `"attributes": ` + attrs + `}`
+}
+
+func TestValidateMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want []string // substrings of the expected problems, in order
+	}{
+		{"valid", metadataDoc("", ""), nil},
+		{"valid with optional fields", metadataDoc(`"image": "ipfs://`+cidVectors[0].raw+`", "background_color": "00ff7F", "custom_field": [{"dean_signature_hash": "`+testHash+`"}], `, ""), nil},
+		{"not json", `{"name": `, []string{"not valid JSON"}},
+		{"trailing data", metadataDoc("", "") + ` {}`, []string{"trailing data"}},
+		{"not an object", `[]`, []string{"document: must be of type object"}},
+		{"missing required", `{"name": "x"}`, []string{`"description" is required`, `"attributes" is required`}},
+		{"wrong type", metadataDoc(`"image": 7, `, ""), []string{"image: must be of type string"}},
+		{"empty name", strings.Replace(metadataDoc("", ""), `"B.Sc. Physics"`, `""`, 1), []string{"name: must not be empty"}},
+		{"name too long", strings.Replace(metadataDoc("", ""), `"B.Sc. Physics"`, `"`+strings.Repeat("é", 201)+`"`, 1), []string{"name: must be at most 200 characters"}},
+		{"relative uri", metadataDoc(`"external_url": "/credentials/1", `, ""), []string{"external_url: must be an absolute URI"}},
+		{"bad pattern", metadataDoc(`"background_color": "#00ff7f", `, ""), []string{"background_color: does not match"}},
+		{"unknown property", metadataDoc(`"owner": "x", `, ""), []string{`document: unknown property "owner"`}},
+		{"no attributes", metadataDoc("", `[]`), []string{
+			"attributes: must have at least 1 items",
+			`"Recipient Wallet"`, `"Issuer Wallet"`, `"Credential Type"`, `"Issue Date"`, `"Credential Hash"`,
+		}},
+		{"nested item type", metadataDoc("", `[7]`), []string{"attributes[0]: must be of type object"}},
+		{"nested item required", strings.Replace(metadataDoc("", ""), `{"trait_type": "Credential Type", "value": "Degree"}`, `{"trait_type": "Major"}`, 1), []string{
+			`attributes[2]: "value" is required`, `"Credential Type"`,
+		}},
+		{"nested item value type", strings.Replace(metadataDoc("", ""), `"value": "Degree"`, `"value": 3`, 1), []string{"attributes[2].value: must be of type string"}},
+		{"contains with bad value", strings.Replace(metadataDoc("", ""), `"2024-06-30"`, `"30/06/2024"`, 1), []string{`needs an "Issue Date" attribute in YYYY-MM-DD form`}},
+		{"nested custom field pattern", metadataDoc(`"custom_field": [{"dean_signature_hash": "0x12"}], `, ""), []string{"custom_field[0].dean_signature_hash: does not match"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ValidateMetadata([]byte(tt.doc))
+			if len(got) != len(tt.want) {
+				t.Fatalf("ValidateMetadata = %q, want %d problems %q", got, len(tt.want), tt.want)
+			}
+			for i, w := range tt.want {
+				if !strings.Contains(got[i], w) {
+					t.Errorf("problem %d = %q, want it to mention %q", i, got[i], w)
+				}
+			}
+		})
+	}
+}
+
+func TestSchemaKeywords(t *testing.T) {
+	schema := mustCompileSchema([]byte(`{
+		"type": "object",
+		"required": ["kind", "scores"],
+		"properties": {
+			"kind": { "enum": ["degree", "diploma", 1] },
+			"code": { "type": "string", "pattern": "^[A-Z]{3}[0-9]{3}$" },
+			"version": { "const": 2 },
+			"ratio": { "type": "number" },
+			"count": { "type": "integer" },
+			"scores": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"required": ["term"],
+					"properties": {
+						"term": { "type": "integer" },
+						"grades": { "type": "array", "items": { "enum": ["A", "B", "C"] } }
+					}
+				}
+			}
+		}
+	}`))
+	tests := []struct {
+		name string
+		doc  string
+		want []string
+	}{
+		{"passing", `{"kind": "degree", "code": "PHY101", "version": 2, "ratio": 0.5, "count": 3, "scores": [{"term": 1, "grades": ["A", "C"]}]}`, nil},
+		{"integer is a number", `{"kind": "diploma", "ratio": 1, "scores": []}`, nil},
+		{"numeric enum", `{"kind": 1, "scores": []}`, nil},
+		{"enum", `{"kind": "certificate", "scores": []}`, []string{"kind: must be one of [degree diploma 1]"}},
+		{"enum is typed", `{"kind": "1", "scores": []}`, []string{"kind: must be one of"}},
+		{"const", `{"kind": "degree", "version": 3, "scores": []}`, []string{"version: must be 2"}},
+		{"pattern", `{"kind": "degree", "code": "phy101", "scores": []}`, []string{"code: does not match ^[A-Z]{3}[0-9]{3}$"}},
+		{"type", `{"kind": "degree", "count": 1.5, "scores": {}}`, []string{"count: must be of type integer", "scores: must be of type array"}},
+		{"required", `{}`, []string{`document: "kind" is required`, `document: "scores" is required`}},
+		{"nested required", `{"kind": "degree", "scores": [{"term": 1}, {}]}`, []string{`scores[1]: "term" is required`}},
+		{"nested type", `{"kind": "degree", "scores": [{"term": "fall"}]}`, []string{"scores[0].term: must be of type integer"}},
+		{"nested array enum", `{"kind": "degree", "scores": [{"term": 1, "grades": ["A", "F"]}]}`, []string{"scores[0].grades[1]: must be one of [A B C]"}},
+		{"additional properties allowed", `{"kind": "degree", "scores": [], "extra": true}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := json.NewDecoder(strings.NewReader(tt.doc))
+			dec.UseNumber()
+			var doc any
+			if err := dec.Decode(&doc); err != nil {
+				t.Fatalf("test document: %v", err)
+			}
+			got := schema.validate(doc, "")
+			if len(got) != len(tt.want) {
+				t.Fatalf("validate = %q, want %q", got, tt.want)
+			}
+			for i, w := range tt.want {
+				if !strings.Contains(got[i], w) {
+					t.Errorf("problem %d = %q, want it to mention %q", i, got[i], w)
+				}
+			}
+		})
+	}
+}
+
+func TestMustCompileSchemaBadPattern(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("mustCompileSchema accepted an invalid pattern")
+		}
+	}()
+	mustCompileSchema([]byte(`{"properties": {"a": {"pattern": "("}}}`))
+}
@@ -0,0 +1,111 @@
+package ipfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ContentStore stores documents addressed by CID.
+type ContentStore interface {
+	// Put stores data under a display name and returns its CID.
+	Put(ctx context.Context, name string, data []byte) (string, error)
+	// Get returns the content for cid, or ErrNotFound when this store
+	// doesn't hold it.
+	Get(ctx context.Context, cid string) ([]byte, error)
+	// Pinned reports whether the store still holds cid.
+	Pinned(ctx context.Context, cid string) (bool, error)
+	// Unpin releases cid. Unpinning content the store doesn't hold is not
+	// an error.
+	Unpin(ctx context.Context, cid string) error
+	// Name identifies the backend in logs and responses.
+	Name() string
+}
+
+// ErrNotFound is returned by Get for content a store doesn't hold.
+var ErrNotFound = errors.New("content not found in store")
+
+// Content store backends selectable with CONTENT_STORE.
+const (
+	StorePinata = "pinata"
+	StoreKubo   = "kubo"
+	StoreLocal  = "local"
+	StoreMemory = "memory"
+)
+
+var (
+	storeOnce sync.Once
+	store     ContentStore
+)
+
+// Store returns the configured content store. CONTENT_STORE picks the
+// backend: "pinata" (PINATA_JWT), "kubo" (KUBO_API_URL, default
+// http://127.0.0.1:5001), "local" (CONTENT_STORE_DIR, default ./data/ipfs)
+// or "memory". Unset, it is pinata when PINATA_JWT is set; otherwise the
+// process exits, so a deployment missing its credentials doesn't quietly
+// keep metadata on local disk.
+func Store() ContentStore {
+	storeOnce.Do(func() {
+		s, err := storeFromEnv()
+		if err != nil {
+			log.Fatal("content store: ", err)
+		}
+		store = s
+		log.Printf("content store: %s", store.Name())
+	})
+	return store
+}
+
+func storeFromEnv() (ContentStore, error) {
+	kind := strings.ToLower(strings.TrimSpace(os.Getenv("CONTENT_STORE")))
+	jwt := strings.TrimSpace(os.Getenv("PINATA_JWT"))
+	if kind == "" {
+		if jwt == "" {
+			return nil, errors.New("CONTENT_STORE is not set and PINATA_JWT is empty (set CONTENT_STORE=local for development)")
+		}
+		kind = StorePinata
+	}
+	switch kind {
+	case StorePinata:
+		if jwt == "" {
+			return nil, errors.New("CONTENT_STORE=pinata needs PINATA_JWT")
+		}
+		return NewPinataStore(jwt), nil
+	case StoreKubo:
+		api := strings.TrimSpace(os.Getenv("KUBO_API_URL"))
+		if api == "" {
+			api = "http://127.0.0.1:5001"
+		}
+		return NewKuboStore(api), nil
+	case StoreLocal:
+		dir := strings.TrimSpace(os.Getenv("CONTENT_STORE_DIR"))
+		if dir == "" {
+			dir = filepath.Join("data", "ipfs")
+		}
+		return NewLocalStore(dir)
+	case StoreMemory:
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown CONTENT_STORE %q", kind)
+	}
+}
+
+// PinJSON stores a JSON document in the content store and returns its
+// canonical ipfs:// URI.
+func PinJSON(data []byte) (string, error) {
+	return PinJSONContext(context.Background(), "credential.json", data)
+}
+
+// PinJSONContext is PinJSON with a context and display name.
+func PinJSONContext(ctx context.Context, name string, data []byte) (string, error) {
+	cid, err := Store().Put(ctx, name, data)
+	if err != nil {
+		return "", err
+	}
+	return CanonicalURI(cid), nil
+}
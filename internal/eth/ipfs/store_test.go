@@ -0,0 +1,78 @@
+package ipfs
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestStoreFromEnv(t *testing.T) {
+	tests := []struct {
+		name, kind, jwt string
+		want            string // backend name; "" means an error
+	}{
+		{"unset without jwt", "", "", ""},
+		{"unset with jwt", "", "token", StorePinata},
+		{"pinata without jwt", "pinata", "", ""},
+		{"pinata", "Pinata", "token", StorePinata},
+		{"kubo", "kubo", "", StoreKubo},
+		{"local", " local ", "", StoreLocal},
+		{"memory", "memory", "", StoreMemory},
+		{"unknown", "s3", "token", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONTENT_STORE", tt.kind)
+			t.Setenv("PINATA_JWT", tt.jwt)
+			t.Setenv("CONTENT_STORE_DIR", t.TempDir())
+			s, err := storeFromEnv()
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("storeFromEnv() = %s, want an error", s.Name())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("storeFromEnv() error = %v", err)
+			}
+			if s.Name() != tt.want {
+				t.Errorf("storeFromEnv() = %s, want %s", s.Name(), tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := cidVectors[1]
+	cid, err := s.Put(ctx, "hello.txt", []byte(v.data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cid != v.raw {
+		t.Errorf("Put = %s, want %s", cid, v.raw)
+	}
+	data, err := s.Get(ctx, cid)
+	if err != nil || string(data) != v.data {
+		t.Errorf("Get = %q, %v; want %q", data, err, v.data)
+	}
+	if ok, err := s.Pinned(ctx, cid); !ok || err != nil {
+		t.Errorf("Pinned = %v, %v; want true", ok, err)
+	}
+	if err := s.Unpin(ctx, cid); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(ctx, cid); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Unpin error = %v, want ErrNotFound", err)
+	}
+	if err := s.Unpin(ctx, cid); err != nil {
+		t.Errorf("second Unpin error = %v", err)
+	}
+	if _, err := s.Get(ctx, "../etc/passwd"); !errors.Is(err, ErrInvalidCID) {
+		t.Errorf("Get(path) error = %v, want ErrInvalidCID", err)
+	}
+}
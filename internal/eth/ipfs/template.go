@@ -0,0 +1,396 @@
+package ipfs
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"vericred/internal/eip712"
+	"vericred/internal/models"
+)
+
+// Trait types every issued credential's metadata carries, whatever template
+// it was built from. The issuance checks in handlers compare against these.
+const (
+	TraitRecipientWallet = "Recipient Wallet"
+	TraitIssuerWallet    = "Issuer Wallet"
+	TraitDegreeName      = "Degree Name"
+	TraitCredentialType  = "Credential Type"
+	TraitMajor           = "Major"
+	TraitIssueDate       = "Issue Date"
+	TraitGraduationDate  = "Graduation Date"
+	TraitCredentialHash  = "Credential Hash"
+	// Only present when a transcript was issued with the credential.
+	TraitTranscriptHash = "Transcript Hash"
+	// Only present when attributes were encrypted instead of pinned.
+	TraitPrivateCommitment = "Private Data Commitment"
+	// The root of the selective disclosure digests, when the fields were
+	// salted before pinning.
+	TraitDisclosureCommitment = "Disclosure Commitment"
+)
+
+var standardTraits = []string{
+	TraitRecipientWallet, TraitIssuerWallet, TraitDegreeName, TraitCredentialType,
+	TraitMajor, TraitIssueDate, TraitGraduationDate, TraitCredentialHash,
+	TraitTranscriptHash, TraitPrivateCommitment, TraitDisclosureCommitment,
+}
+
+// IsStandardTrait reports whether name is one of the traits set from the
+// credential fields rather than from template values.
+func IsStandardTrait(name string) bool {
+	for _, s := range standardTraits {
+		if strings.EqualFold(s, strings.TrimSpace(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Issuance is the input for building metadata from a template.
+type Issuance struct {
+	StudentWallet  string            `json:"student_wallet"`
+	IssuerWallet   string            `json:"-"`
+	DegreeName     string            `json:"degree_name"`
+	Type           string            `json:"type"`
+	Major          string            `json:"major"`
+	IssuedDate     string            `json:"issued_date"`
+	GraduationDate string            `json:"graduation_date"`
+	Description    string            `json:"description"`
+	DeanSig        string            `json:"dean_sig"`
+	Values         map[string]string `json:"values"`
+	// ipfs:// URIs of uploaded assets for the image and animation_url
+	// metadata fields
+	Image        string `json:"image"`
+	AnimationURL string `json:"animation_url"`
+}
+
+// DeanSignatureHash is the keccak256 of a dean signature as carried in
+// metadata custom fields, or "" when sig is not hex.
+func DeanSignatureHash(sig string) string {
+	b, err := hexutil.Decode(strings.TrimSpace(sig))
+	if err != nil {
+		return ""
+	}
+	return crypto.Keccak256Hash(b).Hex()
+}
+
+// ValidateTemplate lists problems with a template definition.
+func ValidateTemplate(t models.CredentialTemplate) []string {
+	var out []string
+	if strings.TrimSpace(t.Name) == "" {
+		out = append(out, "name is required")
+	}
+	if strings.TrimSpace(t.CredentialType) == "" {
+		out = append(out, "credential_type is required")
+	}
+	seen := map[string]bool{}
+	for i, a := range t.Attributes {
+		name := strings.TrimSpace(a.TraitType)
+		if name == "" {
+			out = append(out, fmt.Sprintf("attributes[%d]: trait_type is required", i))
+			continue
+		}
+		key := strings.ToLower(name)
+		if seen[key] {
+			out = append(out, fmt.Sprintf("attributes[%d]: duplicate trait_type %q", i, name))
+		}
+		seen[key] = true
+		for _, s := range standardTraits {
+			if strings.EqualFold(s, name) {
+				out = append(out, fmt.Sprintf("attributes[%d]: %q is set from the credential fields and cannot be redefined", i, name))
+			}
+		}
+		switch a.Type {
+		case models.AttributeTypeString, models.AttributeTypeNumber, models.AttributeTypeDate,
+			models.AttributeTypeBool, models.AttributeTypeWallet:
+		case models.AttributeTypeEnum:
+			if len(a.Enum) == 0 {
+				out = append(out, fmt.Sprintf("attributes[%d]: enum %q needs at least one allowed value", i, name))
+			}
+		default:
+			out = append(out, fmt.Sprintf("attributes[%d]: unknown type %q", i, a.Type))
+		}
+		if a.Default != "" {
+			if err := CheckValue(a, a.Default); err != nil {
+				out = append(out, fmt.Sprintf("attributes[%d]: default: %v", i, err))
+			}
+		}
+	}
+	return out
+}
+
+// CheckValue validates v against the spec's value type.
+func CheckValue(spec models.AttributeSpec, v string) error {
+	v = strings.TrimSpace(v)
+	switch spec.Type {
+	case models.AttributeTypeNumber:
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return fmt.Errorf("%q is not a number", v)
+		}
+	case models.AttributeTypeDate:
+		if _, err := time.Parse("2006-01-02", v); err != nil {
+			return fmt.Errorf("%q is not a YYYY-MM-DD date", v)
+		}
+	case models.AttributeTypeBool:
+		if _, err := strconv.ParseBool(v); err != nil {
+			return fmt.Errorf("%q is not a boolean", v)
+		}
+	case models.AttributeTypeWallet:
+		if !common.IsHexAddress(v) {
+			return fmt.Errorf("%q is not a wallet address", v)
+		}
+	case models.AttributeTypeEnum:
+		for _, e := range spec.Enum {
+			if e == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("%q is not one of %s", v, strings.Join(spec.Enum, ", "))
+	}
+	return nil
+}
+
+// templateAttributes validates values against the template's attribute specs
+// and returns them in spec order, filling in defaults when asked to.
+func templateAttributes(t models.CredentialTemplate, applyDefaults bool, lookup func(string) (string, bool)) ([]Attribute, []string) {
+	var attrs []Attribute
+	var out []string
+	for _, spec := range t.Attributes {
+		v, ok := lookup(spec.TraitType)
+		v = strings.TrimSpace(v)
+		if (!ok || v == "") && applyDefaults {
+			v = spec.Default
+		}
+		if v == "" {
+			if spec.Required {
+				out = append(out, fmt.Sprintf("attribute %q is required", spec.TraitType))
+			}
+			continue
+		}
+		if err := CheckValue(spec, v); err != nil {
+			out = append(out, fmt.Sprintf("attribute %q: %v", spec.TraitType, err))
+			continue
+		}
+		attrs = append(attrs, Attribute{TraitType: spec.TraitType, Value: v})
+	}
+	return attrs, out
+}
+
+// BuildFromTemplate validates in against t and produces the metadata to pin.
+// The returned problems are empty when the metadata is valid.
+func BuildFromTemplate(t models.CredentialTemplate, in Issuance) (*Credentials, []string) {
+	var out []string
+	if !common.IsHexAddress(in.StudentWallet) {
+		out = append(out, "student_wallet must be a wallet address")
+	}
+	credType := strings.TrimSpace(in.Type)
+	if credType == "" {
+		credType = t.CredentialType
+	} else if !strings.EqualFold(credType, t.CredentialType) {
+		out = append(out, fmt.Sprintf("type %q does not match template credential_type %q", credType, t.CredentialType))
+	}
+	degree := strings.TrimSpace(in.DegreeName)
+	if degree == "" {
+		degree = t.DegreeName
+	} else if t.DegreeName != "" && !strings.EqualFold(degree, t.DegreeName) {
+		out = append(out, fmt.Sprintf("degree_name %q does not match template degree_name %q", degree, t.DegreeName))
+	}
+	if degree == "" {
+		out = append(out, "degree_name is required")
+	}
+	if _, err := time.Parse("2006-01-02", strings.TrimSpace(in.IssuedDate)); err != nil {
+		out = append(out, "issued_date must be YYYY-MM-DD")
+	}
+	description := strings.TrimSpace(in.Description)
+	if description == "" {
+		description = t.DefaultDescription
+	}
+
+	values := map[string]string{}
+	for k, v := range in.Values {
+		values[strings.ToLower(strings.TrimSpace(k))] = v
+	}
+	extra, problems := templateAttributes(t, true, func(name string) (string, bool) {
+		v, ok := values[strings.ToLower(name)]
+		return v, ok
+	})
+	out = append(out, problems...)
+	for k := range values {
+		if !hasSpec(t, k) {
+			out = append(out, fmt.Sprintf("attribute %q is not defined by the template", k))
+		}
+	}
+	image, problem := assetLink("image", in.Image)
+	if problem != "" {
+		out = append(out, problem)
+	}
+	animation, problem := assetLink("animation_url", in.AnimationURL)
+	if problem != "" {
+		out = append(out, problem)
+	}
+	if len(out) > 0 {
+		return nil, out
+	}
+
+	attrs := []Attribute{
+		{TraitType: TraitRecipientWallet, Value: strings.TrimSpace(in.StudentWallet)},
+		{TraitType: TraitIssuerWallet, Value: in.IssuerWallet},
+		{TraitType: TraitDegreeName, Value: degree},
+		{TraitType: TraitCredentialType, Value: credType},
+	}
+	if m := strings.TrimSpace(in.Major); m != "" {
+		attrs = append(attrs, Attribute{TraitType: TraitMajor, Value: m})
+	}
+	attrs = append(attrs, Attribute{TraitType: TraitIssueDate, Value: strings.TrimSpace(in.IssuedDate)})
+	if g := strings.TrimSpace(in.GraduationDate); g != "" {
+		attrs = append(attrs, Attribute{TraitType: TraitGraduationDate, Value: g})
+	}
+	attrs = append(attrs, extra...)
+
+	md := &Credentials{
+		Name:         degree,
+		Description:  description,
+		Image:        image,
+		AnimationURL: animation,
+		Attributes:   attrs,
+	}
+	if h := DeanSignatureHash(in.DeanSig); h != "" {
+		md.CustomFields = []CustomField{{DeanSignatureHash: h}}
+	}
+	if _, err := AttachCanonicalHash(md); err != nil {
+		return nil, []string{err.Error()}
+	}
+	return md, nil
+}
+
+// assetLink canonicalizes an optional asset reference, which must name an
+// IPFS CID so the metadata never points at mutable hosting.
+func assetLink(field, link string) (string, string) {
+	link = strings.TrimSpace(link)
+	if link == "" {
+		return "", ""
+	}
+	link = CanonicalLink(link)
+	cid, _, _ := strings.Cut(strings.TrimPrefix(link, "ipfs://"), "/")
+	if !strings.HasPrefix(link, "ipfs://") || !ValidCID(cid) {
+		return "", field + " must be an ipfs:// URI from an asset upload"
+	}
+	return link, ""
+}
+
+// BuildMetadata produces metadata for an issuance without a template. Values
+// become free-form string attributes in key order.
+func BuildMetadata(in Issuance) (*Credentials, []string) {
+	if strings.TrimSpace(in.Type) == "" {
+		return nil, []string{"type is required"}
+	}
+	t := models.CredentialTemplate{CredentialType: strings.TrimSpace(in.Type)}
+	keys := make([]string, 0, len(in.Values))
+	for k := range in.Values {
+		keys = append(keys, strings.TrimSpace(k))
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		t.Attributes = append(t.Attributes, models.AttributeSpec{TraitType: k, Type: models.AttributeTypeString})
+	}
+	return BuildFromTemplate(t, in)
+}
+
+// CanonicalFields reads a credential's canonical fields from metadata
+// attributes. Recipient, issuer, type and issue date must be present.
+func CanonicalFields(attrs []Attribute, name string) (eip712.CredentialFields, error) {
+	md := Metadata{Name: name, Attributes: attrs}
+	var f eip712.CredentialFields
+	var missing []string
+	get := func(required bool, names ...string) string {
+		v, ok := md.Trait(names...)
+		if !ok && required {
+			missing = append(missing, names[0])
+		}
+		return v
+	}
+	f.Recipient = get(true, TraitRecipientWallet)
+	f.Issuer = get(true, TraitIssuerWallet)
+	f.CredentialType = get(true, TraitCredentialType)
+	f.Major = get(false, TraitMajor)
+	f.GraduationDate = get(false, TraitGraduationDate)
+	issued := get(true, TraitIssueDate, "Issued Date")
+	f.DegreeName = get(false, TraitDegreeName, "Degree")
+	if f.DegreeName == "" {
+		f.DegreeName = strings.TrimSpace(name)
+	}
+	if len(missing) > 0 {
+		return f, fmt.Errorf("metadata needs %s to be hashed", strings.Join(missing, ", "))
+	}
+	for _, layout := range []string{"2006-01-02", time.RFC3339, "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, issued); err == nil {
+			f.IssuedDate = t.UTC().Format("2006-01-02")
+			return f, nil
+		}
+	}
+	return f, fmt.Errorf("%s %q is not a date", TraitIssueDate, issued)
+}
+
+// AttachCanonicalHash computes md's canonical credential hash and records it
+// as the Credential Hash attribute, replacing any existing one.
+func AttachCanonicalHash(md *Credentials) (string, error) {
+	f, err := CanonicalFields(md.Attributes, md.Name)
+	if err != nil {
+		return "", err
+	}
+	hash, err := eip712.CredentialHash(f)
+	if err != nil {
+		return "", err
+	}
+	SetTrait(md, TraitCredentialHash, hash)
+	return hash, nil
+}
+
+// SetTrait sets a trait on md, replacing any existing attribute of that type.
+func SetTrait(md *Credentials, traitType, value string) {
+	attrs := md.Attributes[:0]
+	for _, a := range md.Attributes {
+		if !strings.EqualFold(strings.TrimSpace(a.TraitType), traitType) {
+			attrs = append(attrs, a)
+		}
+	}
+	md.Attributes = append(attrs, Attribute{TraitType: traitType, Value: value})
+}
+
+// TemplateMismatches checks already-pinned metadata against t's attribute
+// specs. Traits sealed into the private data envelope are not in the
+// metadata; they were checked against the template before sealing and are
+// skipped.
+func TemplateMismatches(md *Metadata, t models.CredentialTemplate, sealed ...string) []string {
+	if len(sealed) > 0 {
+		var specs models.AttributeSpecs
+		for _, spec := range t.Attributes {
+			if !slices.ContainsFunc(sealed, func(name string) bool {
+				return strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(spec.TraitType))
+			}) {
+				specs = append(specs, spec)
+			}
+		}
+		t.Attributes = specs
+	}
+	_, out := templateAttributes(t, false, func(name string) (string, bool) {
+		return md.Trait(name)
+	})
+	return out
+}
+
+func hasSpec(t models.CredentialTemplate, traitType string) bool {
+	for _, s := range t.Attributes {
+		if strings.EqualFold(strings.TrimSpace(s.TraitType), traitType) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrMintReverted is returned by WaitMint when the mint transaction was mined
+// but failed.
+var ErrMintReverted = errors.New("mint transaction reverted")
+
+// SubmitMint sends mintDoc(to, tokenURI) from the server wallet, which must
+// be a verified org on the contract, and returns the transaction hash without
+// waiting for it to be mined.
+func (c *Client) SubmitMint(ctx context.Context, to, tokenURI string) (common.Hash, error) {
+	sent, err := c.send(ctx, TxKindMint, to, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return c.contract.MintDoc(opts, common.HexToAddress(to), tokenURI)
+	})
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("mintDoc: %w", err)
+	}
+	return common.HexToHash(sent.Hash), nil
+}
+
+// WaitMint waits for a submitted mint to be mined and returns the token ID
+// from its Transfer event along with the hash that was mined, which is not
+// txHash when the mint was re-sent with higher fees.
+func (c *Client) WaitMint(ctx context.Context, txHash common.Hash) (*big.Int, common.Hash, error) {
+	receipt, err := c.WaitTx(ctx, txHash)
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, receipt.TxHash, fmt.Errorf("%w: %s", ErrMintReverted, receipt.TxHash.Hex())
+	}
+	for _, l := range receipt.Logs {
+		if ev, err := c.contract.BuildFilterer.ParseTransfer(*l); err == nil && ev.From == (common.Address{}) {
+			return ev.TokenId, receipt.TxHash, nil
+		}
+	}
+	return nil, receipt.TxHash, fmt.Errorf("no mint Transfer event in %s", receipt.TxHash.Hex())
+}
@@ -0,0 +1,185 @@
+package eth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/external"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrNoSigner means no transaction signer is configured, so the client can
+// read from the chain but not send transactions.
+var ErrNoSigner = errors.New("eth: no transaction signer configured")
+
+// Signer signs transactions for the server wallet. The private key stays
+// wherever the implementation keeps it; the client only sees signed
+// transactions.
+type Signer interface {
+	// Address is the account transactions are sent from.
+	Address() common.Address
+	// SignTx signs tx for chainID.
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// TransactOpts returns bind options that send from s's address and sign
+// with s. Requests to sign for any other address fail with
+// bind.ErrNotAuthorized.
+func TransactOpts(ctx context.Context, s Signer, chainID *big.Int) *bind.TransactOpts {
+	from := s.Address()
+	return &bind.TransactOpts{
+		From:    from,
+		Context: ctx,
+		Signer: func(addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if addr != from {
+				return nil, bind.ErrNotAuthorized
+			}
+			return s.SignTx(ctx, tx, chainID)
+		},
+	}
+}
+
+// KeySigner signs with a private key held in memory. Use it in tests and
+// local development; production should use a keystore file or an external
+// signer.
+type KeySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// NewKeySigner wraps key.
+func NewKeySigner(key *ecdsa.PrivateKey) *KeySigner {
+	return &KeySigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+func (s *KeySigner) Address() common.Address {
+	return s.address
+}
+
+func (s *KeySigner) SignTx(_ context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
+
+// NewKeystoreSigner decrypts a go-ethereum keystore (V3 JSON) file with
+// passphrase and signs with the key it holds.
+func NewKeystoreSigner(path, passphrase string) (*KeySigner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: keystore: %v", ErrConfig, err)
+	}
+	key, err := keystore.DecryptKey(data, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("%w: keystore %s: %v", ErrConfig, path, err)
+	}
+	return NewKeySigner(key.PrivateKey), nil
+}
+
+// ExternalSigner asks a Clef-compatible signer (account_signTransaction over
+// JSON-RPC) to sign, so the key never enters this process. Clef's own rules
+// or its operator decide whether each request is approved.
+type ExternalSigner struct {
+	api     *external.ExternalSigner
+	account accounts.Account
+}
+
+// NewExternalSigner connects to the signer at endpoint (an HTTP URL or IPC
+// path). address picks the account to send from; the zero address takes the
+// first account the signer lists.
+func NewExternalSigner(endpoint string, address common.Address) (*ExternalSigner, error) {
+	api, err := external.NewExternalSigner(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("%w: external signer %s: %v", ErrUnavailable, endpoint, err)
+	}
+	if address == (common.Address{}) {
+		accts := api.Accounts()
+		if len(accts) == 0 {
+			return nil, fmt.Errorf("%w: external signer %s lists no accounts", ErrConfig, endpoint)
+		}
+		address = accts[0].Address
+	}
+	return &ExternalSigner{api: api, account: accounts.Account{Address: address}}, nil
+}
+
+func (s *ExternalSigner) Address() common.Address {
+	return s.account.Address
+}
+
+func (s *ExternalSigner) SignTx(_ context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	signed, err := s.api.SignTx(s.account, tx, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("external signer: %w", err)
+	}
+	return signed, nil
+}
+
+// SignerFromEnv builds the signer named by ETH_SIGNER:
+//
+//   - keystore: ETH_KEYSTORE_FILE, unlocked with ETH_KEYSTORE_PASSWORD or
+//     the contents of ETH_KEYSTORE_PASSWORD_FILE
+//   - external: the Clef-compatible signer at ETH_SIGNER_URL, sending from
+//     ETH_SIGNER_ADDRESS (default: its first account)
+//   - key: the hex key in ETH_PRIVATE_KEY, for local development only
+//
+// Without ETH_SIGNER the first of those settings present decides. With none
+// of them it returns ErrNoSigner.
+func SignerFromEnv() (Signer, error) {
+	kind := strings.ToLower(strings.TrimSpace(os.Getenv("ETH_SIGNER")))
+	if kind == "" {
+		switch {
+		case os.Getenv("ETH_KEYSTORE_FILE") != "":
+			kind = "keystore"
+		case os.Getenv("ETH_SIGNER_URL") != "":
+			kind = "external"
+		case os.Getenv("ETH_PRIVATE_KEY") != "":
+			kind = "key"
+		default:
+			return nil, ErrNoSigner
+		}
+	}
+	switch kind {
+	case "keystore":
+		path := strings.TrimSpace(os.Getenv("ETH_KEYSTORE_FILE"))
+		if path == "" {
+			return nil, fmt.Errorf("%w: ETH_KEYSTORE_FILE is not set", ErrConfig)
+		}
+		passphrase := os.Getenv("ETH_KEYSTORE_PASSWORD")
+		if f := strings.TrimSpace(os.Getenv("ETH_KEYSTORE_PASSWORD_FILE")); f != "" {
+			data, err := os.ReadFile(f)
+			if err != nil {
+				return nil, fmt.Errorf("%w: ETH_KEYSTORE_PASSWORD_FILE: %v", ErrConfig, err)
+			}
+			passphrase = strings.TrimRight(string(data), "\r\n")
+		}
+		return NewKeystoreSigner(path, passphrase)
+	case "external":
+		endpoint := strings.TrimSpace(os.Getenv("ETH_SIGNER_URL"))
+		if endpoint == "" {
+			return nil, fmt.Errorf("%w: ETH_SIGNER_URL is not set", ErrConfig)
+		}
+		var address common.Address
+		if v := strings.TrimSpace(os.Getenv("ETH_SIGNER_ADDRESS")); v != "" {
+			if !common.IsHexAddress(v) {
+				return nil, fmt.Errorf("%w: ETH_SIGNER_ADDRESS %q", ErrConfig, v)
+			}
+			address = common.HexToAddress(v)
+		}
+		return NewExternalSigner(endpoint, address)
+	case "key":
+		key, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(os.Getenv("ETH_PRIVATE_KEY")), "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid ETH_PRIVATE_KEY", ErrConfig)
+		}
+		return NewKeySigner(key), nil
+	}
+	return nil, fmt.Errorf("%w: unknown ETH_SIGNER %q (keystore, external or key)", ErrConfig, kind)
+}
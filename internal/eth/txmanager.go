@@ -0,0 +1,573 @@
+package eth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"gorm.io/gorm"
+
+	"vericred/internal/db"
+	"vericred/internal/models"
+)
+
+// Kinds of transaction recorded on models.ChainTransaction.
+const (
+	TxKindDeploy = "deploy"
+	TxKindNewOrg = "new_org"
+	TxKindMint   = "mint"
+)
+
+// ErrTxDropped means a transaction will never be mined: its nonce went to
+// another transaction, or the node refused it.
+var ErrTxDropped = errors.New("eth: transaction dropped")
+
+// txPollInterval is how often WaitTx checks a pending transaction.
+const txPollInterval = 3 * time.Second
+
+// senderState serializes sends from one address and remembers the next
+// nonce once it is known.
+type senderState struct {
+	mu    sync.Mutex
+	next  uint64
+	known bool
+}
+
+func (c *Client) sender(addr common.Address) *senderState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.senders == nil {
+		c.senders = map[common.Address]*senderState{}
+	}
+	st, ok := c.senders[addr]
+	if !ok {
+		st = &senderState{}
+		c.senders[addr] = st
+	}
+	return st
+}
+
+// send is the transaction manager. build packs the contract call with the
+// given options (which never send); send then gives it the signer's next
+// nonce, the estimated gas plus GasHeadroom and EIP-1559 fees, records it
+// as a pending ChainTransaction and broadcasts it. Sends from one address
+// are serialized so concurrent calls never share a nonce. ref is a free-form
+// note shown with the transaction, such as the recipient.
+func (c *Client) send(ctx context.Context, kind, ref string, build func(*bind.TransactOpts) (*types.Transaction, error)) (*models.ChainTransaction, error) {
+	signer, err := c.Signer()
+	if err != nil {
+		return nil, err
+	}
+	chainID, err := c.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	from := signer.Address()
+	st := c.sender(from)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	tip, feeCap, err := c.fees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// bind packs the call and estimates its gas; the pass-through signer
+	// leaves the draft unsigned so only the final transaction is signed
+	draft, err := build(&bind.TransactOpts{
+		From:      from,
+		Context:   ctx,
+		Nonce:     new(big.Int),
+		Value:     new(big.Int),
+		GasTipCap: tip,
+		GasFeeCap: feeCap,
+		NoSend:    true,
+		Signer: func(_ common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			return tx, nil
+		},
+	})
+	if err != nil {
+		if transient(err) {
+			return nil, fmt.Errorf("%s: %w: %v", kind, ErrUnavailable, err)
+		}
+		return nil, classify(kind, err)
+	}
+
+	row := &models.ChainTransaction{
+		Kind:      kind,
+		Reference: ref,
+		ChainID:   chainID.String(),
+		Sender:    from.Hex(),
+		Data:      hexutil.Encode(draft.Data()),
+		Value:     draft.Value().String(),
+		GasLimit:  draft.Gas() * (100 + c.cfg.GasHeadroom) / 100,
+		GasTipCap: tip.String(),
+		GasFeeCap: feeCap.String(),
+		Status:    models.ChainTxPending,
+		Attempts:  1,
+	}
+	if to := draft.To(); to != nil {
+		row.To = to.Hex()
+	}
+
+	// A nonce the node says is already used means our count is stale
+	// (another process or wallet sent from this address); resync once
+	for attempt := 0; ; attempt++ {
+		nonce, err := c.nextNonce(ctx, st, chainID, from)
+		if err != nil {
+			return nil, err
+		}
+		row.ID = 0
+		row.Nonce = nonce
+		row.Status = models.ChainTxPending
+		row.Error = ""
+		if kind == TxKindDeploy {
+			row.ContractAddress = crypto.CreateAddress(from, nonce).Hex()
+		}
+		signed, err := c.signRow(ctx, signer, chainID, row)
+		if err != nil {
+			return nil, fmt.Errorf("%s: sign: %w", kind, err)
+		}
+		row.Hash = signed.Hash().Hex()
+		row.Hashes = models.StringList{row.Hash}
+		row.LastSentAt = time.Now().UTC()
+		// Record before broadcasting so a crash in between leaves a row
+		// the monitor can pick up
+		if err := db.DB.Create(row).Error; err != nil {
+			return nil, fmt.Errorf("%s: record transaction: %w", kind, err)
+		}
+
+		err = c.broadcast(ctx, signed)
+		if err == nil {
+			st.next = nonce + 1
+			return row, nil
+		}
+		msg := err.Error()
+		if len(msg) > 500 {
+			msg = msg[:500]
+		}
+		// The node may have taken it before the connection failed; keep it
+		// pending and let CheckPending re-broadcast it if it never shows up
+		if transient(err) {
+			log.Printf("eth: broadcast %s tx %s: %v", kind, row.Hash, err)
+			db.DB.Model(row).Update("error", msg)
+			st.next = nonce + 1
+			return row, nil
+		}
+		db.DB.Model(row).Updates(map[string]any{"status": models.ChainTxDropped, "error": msg})
+		if st.resync(err, attempt) {
+			continue
+		}
+		// The nonce was not consumed, so the next send reuses it
+		return nil, fmt.Errorf("%s: %w", kind, err)
+	}
+}
+
+// nextNonce is the nonce for from's next transaction: the node's pending
+// nonce, or past our own pending transactions when the node has forgotten
+// them (they are re-broadcast by CheckPending).
+func (c *Client) nextNonce(ctx context.Context, st *senderState, chainID *big.Int, from common.Address) (uint64, error) {
+	if st.known {
+		return st.next, nil
+	}
+	n, err := retry(ctx, c, "pendingNonce", func(ctx context.Context) (uint64, error) {
+		return c.rpc.PendingNonceAt(ctx, from)
+	})
+	if err != nil {
+		return 0, err
+	}
+	var last sql.NullInt64
+	if err := db.DB.Model(&models.ChainTransaction{}).
+		Where("chain_id = ? AND sender = ? AND status = ?", chainID.String(), from.Hex(), models.ChainTxPending).
+		Select("max(nonce)").Scan(&last).Error; err != nil {
+		return 0, fmt.Errorf("load pending nonces: %w", err)
+	}
+	n = pastPending(n, last)
+	st.next, st.known = n, true
+	return n, nil
+}
+
+// resync reports whether a send that failed with err should be retried: when
+// the node says the nonce was already used on the first attempt, st forgets
+// its nonce so the retry asks the node again.
+func (st *senderState) resync(err error, attempt int) bool {
+	if attempt > 0 || !nonceTooLow(err) {
+		return false
+	}
+	st.known = false
+	return true
+}
+
+// pastPending is the node's pending nonce n, moved past lastPending, the
+// highest nonce among our own pending transactions, if the node is behind.
+func pastPending(n uint64, lastPending sql.NullInt64) uint64 {
+	if lastPending.Valid && uint64(lastPending.Int64)+1 > n {
+		return uint64(lastPending.Int64) + 1
+	}
+	return n
+}
+
+// fees returns the tip and fee cap for a transaction sent now: the node's
+// suggested tip, and a cap that leaves room for the base fee to double,
+// both limited to MaxFeePerGas.
+func (c *Client) fees(ctx context.Context) (*big.Int, *big.Int, error) {
+	head, err := retry(ctx, c, "header", func(ctx context.Context) (*types.Header, error) {
+		return c.rpc.HeaderByNumber(ctx, nil)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if head.BaseFee == nil {
+		return nil, nil, fmt.Errorf("%w: chain does not support EIP-1559 fees", ErrConfig)
+	}
+	tip, err := retry(ctx, c, "maxPriorityFeePerGas", c.rpc.SuggestGasTipCap)
+	if err != nil {
+		return nil, nil, err
+	}
+	feeCap := new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tip)
+	tip, feeCap = c.capFees(tip, feeCap)
+	return tip, feeCap, nil
+}
+
+// capFees limits feeCap to MaxFeePerGas and tip to feeCap.
+func (c *Client) capFees(tip, feeCap *big.Int) (*big.Int, *big.Int) {
+	if max := c.cfg.MaxFeePerGas; max != nil && max.Sign() > 0 && feeCap.Cmp(max) > 0 {
+		feeCap = new(big.Int).Set(max)
+	}
+	if tip.Cmp(feeCap) > 0 {
+		tip = new(big.Int).Set(feeCap)
+	}
+	return tip, feeCap
+}
+
+// signRow signs the transaction row describes.
+func (c *Client) signRow(ctx context.Context, signer Signer, chainID *big.Int, row *models.ChainTransaction) (*types.Transaction, error) {
+	var to *common.Address
+	if row.To != "" {
+		addr := common.HexToAddress(row.To)
+		to = &addr
+	}
+	data, err := hexutil.Decode(row.Data)
+	if err != nil {
+		return nil, fmt.Errorf("stored call data: %w", err)
+	}
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     row.Nonce,
+		GasTipCap: parseWei(row.GasTipCap),
+		GasFeeCap: parseWei(row.GasFeeCap),
+		Gas:       row.GasLimit,
+		To:        to,
+		Value:     parseWei(row.Value),
+		Data:      data,
+	})
+	return signer.SignTx(ctx, tx, chainID)
+}
+
+// broadcast sends a signed transaction once. A node that already has it
+// counts as success, so re-broadcasting is harmless.
+func (c *Client) broadcast(ctx context.Context, tx *types.Transaction) error {
+	callCtx, cancel := context.WithTimeout(ctx, c.cfg.CallTimeout)
+	defer cancel()
+	err := c.rpc.SendTransaction(callCtx, tx)
+	if err != nil && strings.Contains(strings.ToLower(err.Error()), "already known") {
+		return nil
+	}
+	return err
+}
+
+func nonceTooLow(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "nonce too low")
+}
+
+func parseWei(s string) *big.Int {
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return new(big.Int)
+	}
+	return v
+}
+
+// replacementFees picks the fees to re-send row with, given the fees
+// suggested now: at least a bump over row's fees, so nodes accept it as a
+// replacement ("bumped"). Past MaxBumps, or when the bump would exceed
+// MaxFeePerGas, row's own fees are kept and it is sent again as is
+// ("rebroadcast").
+func (c *Client) replacementFees(row *models.ChainTransaction, tip, feeCap *big.Int) (string, *big.Int, *big.Int) {
+	oldTip, oldCap := parseWei(row.GasTipCap), parseWei(row.GasFeeCap)
+	if min := bump(oldTip); tip.Cmp(min) < 0 {
+		tip = min
+	}
+	if min := bump(oldCap); feeCap.Cmp(min) < 0 {
+		feeCap = min
+	}
+	if feeCap.Cmp(tip) < 0 {
+		feeCap = new(big.Int).Set(tip)
+	}
+	capped := c.cfg.MaxFeePerGas != nil && c.cfg.MaxFeePerGas.Sign() > 0 && feeCap.Cmp(c.cfg.MaxFeePerGas) > 0
+	if row.Attempts-1 >= c.cfg.MaxBumps || capped {
+		return "rebroadcast", oldTip, oldCap
+	}
+	return "bumped", tip, feeCap
+}
+
+// bump raises v by 12.5%, over the 10% nodes require to replace a pending
+// transaction.
+func bump(v *big.Int) *big.Int {
+	out := new(big.Int).Div(v, big.NewInt(8))
+	out.Add(out, v)
+	return out.Add(out, big.NewInt(1))
+}
+
+// TxSummary describes one pass over pending transactions.
+type TxSummary struct {
+	Checked     int `json:"checked"`
+	Confirmed   int `json:"confirmed"`
+	Failed      int `json:"failed"`
+	Dropped     int `json:"dropped"`
+	Bumped      int `json:"bumped"`
+	Rebroadcast int `json:"rebroadcast"`
+	Errors      int `json:"errors"`
+}
+
+// CheckPending checks every pending transaction on this chain: it records
+// the outcome of those that were mined and re-sends those that have waited
+// longer than StuckAfter. Only transactions from the current signer can be
+// re-sent; others are still watched for their receipt.
+func (c *Client) CheckPending(ctx context.Context) (TxSummary, error) {
+	var sum TxSummary
+	chainID, err := c.ChainID(ctx)
+	if err != nil {
+		return sum, err
+	}
+	signer, err := c.Signer()
+	if err != nil && !errors.Is(err, ErrNoSigner) {
+		return sum, err
+	}
+	var rows []models.ChainTransaction
+	if err := db.DB.Where("chain_id = ? AND status = ?", chainID.String(), models.ChainTxPending).
+		Order("sender, nonce").Find(&rows).Error; err != nil {
+		return sum, fmt.Errorf("load pending transactions: %w", err)
+	}
+	for i := range rows {
+		if ctx.Err() != nil {
+			return sum, ctx.Err()
+		}
+		sum.Checked++
+		action, err := c.checkTx(ctx, signer, chainID, &rows[i])
+		if err != nil {
+			log.Printf("eth: check %s tx %s: %v", rows[i].Kind, rows[i].Hash, err)
+			sum.Errors++
+			continue
+		}
+		switch {
+		case action == "bumped":
+			sum.Bumped++
+		case action == "rebroadcast":
+			sum.Rebroadcast++
+		case rows[i].Status == models.ChainTxConfirmed:
+			sum.Confirmed++
+		case rows[i].Status == models.ChainTxFailed:
+			sum.Failed++
+		case rows[i].Status == models.ChainTxDropped:
+			sum.Dropped++
+		}
+	}
+	return sum, nil
+}
+
+// checkTx brings row up to date: it records the receipt of whichever of its
+// hashes was mined, marks it dropped when its nonce went to something else,
+// or re-sends it when it is stuck. It returns "bumped" or "rebroadcast" when
+// it sent the transaction again. signer may be nil.
+func (c *Client) checkTx(ctx context.Context, signer Signer, chainID *big.Int, row *models.ChainTransaction) (string, error) {
+	st := c.sender(common.HexToAddress(row.Sender))
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	// Another caller may have handled it while we waited for the lock
+	if err := db.DB.First(row, row.ID).Error; err != nil {
+		return "", err
+	}
+	if row.Status != models.ChainTxPending {
+		return "", nil
+	}
+
+	for i := len(row.Hashes) - 1; i >= 0; i-- {
+		hash := common.HexToHash(row.Hashes[i])
+		receipt, err := retry(ctx, c, "receipt", func(ctx context.Context) (*types.Receipt, error) {
+			return c.rpc.TransactionReceipt(ctx, hash)
+		})
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return "", c.finish(row, receipt)
+	}
+
+	sender := common.HexToAddress(row.Sender)
+	mined, err := retry(ctx, c, "nonce", func(ctx context.Context) (uint64, error) {
+		return c.rpc.NonceAt(ctx, sender, nil)
+	})
+	if err != nil {
+		return "", err
+	}
+	stuck := time.Since(row.LastSentAt) >= c.cfg.StuckAfter
+	if mined > row.Nonce {
+		// Give the receipt time to show up on a lagging node before
+		// deciding none of our hashes made it
+		if !stuck {
+			return "", nil
+		}
+		row.Status = models.ChainTxDropped
+		row.Error = fmt.Sprintf("nonce %d was used by another transaction", row.Nonce)
+		return "", db.DB.Model(row).Updates(map[string]any{"status": row.Status, "error": row.Error}).Error
+	}
+	if !stuck || signer == nil || signer.Address() != sender {
+		return "", nil
+	}
+	return c.resend(ctx, signer, chainID, row)
+}
+
+// resend replaces a stuck transaction with one paying at least 12.5% more,
+// or the current market rate if that is higher. Once MaxBumps is reached or
+// the fee cap is hit, the same transaction is broadcast again instead.
+func (c *Client) resend(ctx context.Context, signer Signer, chainID *big.Int, row *models.ChainTransaction) (string, error) {
+	tip, feeCap, err := c.fees(ctx)
+	if err != nil {
+		return "", err
+	}
+	action, tip, feeCap := c.replacementFees(row, tip, feeCap)
+	next := *row
+	next.GasTipCap, next.GasFeeCap = tip.String(), feeCap.String()
+	signed, err := c.signRow(ctx, signer, chainID, &next)
+	if err != nil {
+		return "", fmt.Errorf("sign: %w", err)
+	}
+
+	// Record the new hash before broadcasting: the node may accept it even
+	// when the call fails, and a mined hash we never saved would make the
+	// transaction look dropped
+	now := time.Now().UTC()
+	hash := signed.Hash().Hex()
+	updates := map[string]any{"last_sent_at": now, "error": ""}
+	if !slices.Contains(row.Hashes, hash) {
+		updates["hashes"] = append(append(models.StringList{}, row.Hashes...), hash)
+	}
+	if action == "bumped" {
+		updates["hash"] = hash
+		updates["gas_tip_cap"] = next.GasTipCap
+		updates["gas_fee_cap"] = next.GasFeeCap
+		updates["attempts"] = row.Attempts + 1
+	}
+	if err := db.DB.Model(row).Updates(updates).Error; err != nil {
+		return "", err
+	}
+	if err := db.DB.First(row, row.ID).Error; err != nil {
+		return "", err
+	}
+
+	if err := c.broadcast(ctx, signed); err != nil {
+		if nonceTooLow(err) {
+			// Mined in the meantime; the next check finds the receipt
+			return "", nil
+		}
+		msg := err.Error()
+		if len(msg) > 500 {
+			msg = msg[:500]
+		}
+		db.DB.Model(row).Update("error", msg)
+		return "", err
+	}
+	if action == "bumped" {
+		log.Printf("eth: %s tx nonce %d re-sent as %s (tip %s, fee cap %s wei)", row.Kind, row.Nonce, hash, next.GasTipCap, next.GasFeeCap)
+	}
+	return action, nil
+}
+
+// finish records the receipt of the mined hash.
+func (c *Client) finish(row *models.ChainTransaction, receipt *types.Receipt) error {
+	now := time.Now().UTC()
+	row.Status = models.ChainTxConfirmed
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		row.Status = models.ChainTxFailed
+	}
+	row.Hash = receipt.TxHash.Hex()
+	row.GasUsed = receipt.GasUsed
+	row.MinedAt = &now
+	if receipt.BlockNumber != nil {
+		row.BlockNumber = receipt.BlockNumber.Uint64()
+	}
+	if receipt.ContractAddress != (common.Address{}) {
+		row.ContractAddress = receipt.ContractAddress.Hex()
+	}
+	return db.DB.Model(row).Updates(map[string]any{
+		"status":           row.Status,
+		"hash":             row.Hash,
+		"gas_used":         row.GasUsed,
+		"block_number":     row.BlockNumber,
+		"contract_address": row.ContractAddress,
+		"mined_at":         row.MinedAt,
+	}).Error
+}
+
+// WaitTx waits until the transaction first sent as hash is final and
+// returns the receipt of the hash that was mined, which differs from hash
+// after a fee bump. It checks the transaction itself while waiting, so it
+// does not depend on the background monitor. A dropped transaction fails
+// with ErrTxDropped; a reverted one returns its receipt. Hashes the manager
+// never sent are waited on directly.
+func (c *Client) WaitTx(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	chainID, err := c.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// The same hash can't be sent on two chains, but rows from another
+	// network share the table; only this chain's can be checked here.
+	var row models.ChainTransaction
+	err = db.DB.Where("chain_id = ? AND hashes @> ?::jsonb", chainID.String(), `["`+hash.Hex()+`"]`).First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return bind.WaitMinedHash(ctx, c.rpc, hash)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load transaction %s: %w", hash.Hex(), err)
+	}
+	signer, _ := c.Signer()
+
+	ticker := time.NewTicker(txPollInterval)
+	defer ticker.Stop()
+	for {
+		if row.Status == models.ChainTxPending {
+			if _, err := c.checkTx(ctx, signer, chainID, &row); err != nil && ctx.Err() == nil {
+				log.Printf("eth: check %s tx %s: %v", row.Kind, row.Hash, err)
+			}
+		}
+		switch row.Status {
+		case models.ChainTxConfirmed, models.ChainTxFailed:
+			mined := common.HexToHash(row.Hash)
+			return retry(ctx, c, "receipt", func(ctx context.Context) (*types.Receipt, error) {
+				return c.rpc.TransactionReceipt(ctx, mined)
+			})
+		case models.ChainTxDropped:
+			return nil, fmt.Errorf("%w: %s: %s", ErrTxDropped, hash.Hex(), row.Error)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("wait for %s: %w", hash.Hex(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
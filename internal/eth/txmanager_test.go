@@ -0,0 +1,146 @@
+package eth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"vericred/internal/models"
+)
+
+func TestSenderResync(t *testing.T) {
+	tooLow := errors.New("nonce too low: address 0x00000000000000000000000000000000000000a1, tx: 5 state: 7")
+	tests := []struct {
+		name    string
+		err     error
+		attempt int
+		want    bool
+	}{
+		{"nonce too low", tooLow, 0, true},
+		{"capitalised", errors.New("Nonce too low"), 0, true},
+		{"wrapped", errors.Join(errors.New("mint"), tooLow), 0, true},
+		{"second attempt", tooLow, 1, false},
+		{"other error", errors.New("insufficient funds for gas * price + value"), 0, false},
+		{"replacement underpriced", errors.New("replacement transaction underpriced"), 0, false},
+	}
+	for _, tt := range tests {
+		st := &senderState{next: 5, known: true}
+		if got := st.resync(tt.err, tt.attempt); got != tt.want {
+			t.Errorf("%s: resync = %v, want %v", tt.name, got, tt.want)
+		}
+		if st.known == tt.want {
+			t.Errorf("%s: known = %v after resync, want %v", tt.name, st.known, !tt.want)
+		}
+	}
+}
+
+func TestNonceResyncAfterTooLow(t *testing.T) {
+	// A known nonce is used without asking the node (the client has no
+	// connection here)
+	c := &Client{}
+	from := common.HexToAddress("0xa1")
+	st := c.sender(from)
+	st.next, st.known = 5, true
+	n, err := c.nextNonce(context.Background(), st, big.NewInt(1), from)
+	if err != nil || n != 5 {
+		t.Fatalf("nextNonce = %d, %v; want 5", n, err)
+	}
+	// Another wallet used nonces 5 and 6: the node rejects 5 and the next
+	// attempt must ask it again instead of reusing the stale count
+	if !st.resync(errors.New("nonce too low: next nonce 7, tx nonce 5"), 0) || st.known {
+		t.Fatal("nonce too low did not invalidate the sender's nonce")
+	}
+	if c.sender(from) != st {
+		t.Error("sender state was not kept per address")
+	}
+}
+
+func TestPastPending(t *testing.T) {
+	tests := []struct {
+		name string
+		node uint64
+		last sql.NullInt64
+		want uint64
+	}{
+		{"no pending rows", 7, sql.NullInt64{}, 7},
+		{"rows behind node", 7, sql.NullInt64{Int64: 5, Valid: true}, 7},
+		{"last row just before node", 7, sql.NullInt64{Int64: 6, Valid: true}, 7},
+		{"node forgot our rows", 7, sql.NullInt64{Int64: 9, Valid: true}, 10},
+		{"fresh sender with a pending row", 0, sql.NullInt64{Int64: 0, Valid: true}, 1},
+	}
+	for _, tt := range tests {
+		if got := pastPending(tt.node, tt.last); got != tt.want {
+			t.Errorf("%s: pastPending = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBump(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"0", "1"},
+		{"7", "8"},
+		{"100", "113"},
+		{"1000000000", "1125000001"},     // 1 gwei
+		{"200000000000", "225000000001"}, // 200 gwei
+		{"115792089237316195423570985008687907853269984665640564039457584007913129639935", "130266100391980719851517358134773896334928732748845634544389782008902270844927"},
+	}
+	for _, tt := range tests {
+		in, _ := new(big.Int).SetString(tt.in, 10)
+		orig := new(big.Int).Set(in)
+		got := bump(in)
+		if got.String() != tt.want {
+			t.Errorf("bump(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+		if in.Cmp(orig) != 0 {
+			t.Errorf("bump(%s) modified its argument", tt.in)
+		}
+		// Nodes replace a pending transaction only for at least 10% more
+		if new(big.Int).Mul(got, big.NewInt(10)).Cmp(new(big.Int).Mul(orig, big.NewInt(11))) < 0 {
+			t.Errorf("bump(%s) = %s is under the 10%% replacement minimum", tt.in, got)
+		}
+	}
+}
+
+func TestReplacementFees(t *testing.T) {
+	gwei := func(n int64) *big.Int { return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e9)) }
+	c := &Client{cfg: Config{MaxFeePerGas: gwei(200), MaxBumps: 3}}
+	row := func(tip, feeCap *big.Int, attempts int) *models.ChainTransaction {
+		return &models.ChainTransaction{GasTipCap: tip.String(), GasFeeCap: feeCap.String(), Attempts: attempts}
+	}
+	tests := []struct {
+		name             string
+		row              *models.ChainTransaction
+		tip, feeCap      *big.Int // suggested now
+		action           string
+		wantTip, wantCap string
+	}{
+		{"bumps 12.5% over unchanged fees", row(gwei(2), gwei(40), 1), gwei(2), gwei(40), "bumped", "2250000001", "45000000001"},
+		{"bumps over lower suggestions", row(gwei(2), gwei(40), 2), gwei(1), gwei(20), "bumped", "2250000001", "45000000001"},
+		{"keeps higher suggestions", row(gwei(2), gwei(40), 1), gwei(5), gwei(90), "bumped", "5000000000", "90000000000"},
+		{"mixes bumped tip and suggested cap", row(gwei(2), gwei(40), 1), gwei(1), gwei(60), "bumped", "2250000001", "60000000000"},
+		{"fee cap at least the tip", row(gwei(2), gwei(2), 1), gwei(30), gwei(20), "bumped", "30000000000", "30000000000"},
+		{"last bump", row(gwei(2), gwei(40), 3), gwei(2), gwei(40), "bumped", "2250000001", "45000000001"},
+		{"out of bumps", row(gwei(2), gwei(40), 4), gwei(9), gwei(90), "rebroadcast", "2000000000", "40000000000"},
+		{"bump over the fee limit", row(gwei(2), gwei(180), 1), gwei(2), gwei(180), "rebroadcast", "2000000000", "180000000000"},
+		{"suggestion over the fee limit", row(gwei(2), gwei(40), 1), gwei(2), gwei(250), "rebroadcast", "2000000000", "40000000000"},
+	}
+	for _, tt := range tests {
+		action, tip, feeCap := c.replacementFees(tt.row, tt.tip, tt.feeCap)
+		if action != tt.action || tip.String() != tt.wantTip || feeCap.String() != tt.wantCap {
+			t.Errorf("%s: replacementFees = %s, %s, %s; want %s, %s, %s",
+				tt.name, action, tip, feeCap, tt.action, tt.wantTip, tt.wantCap)
+		}
+	}
+
+	// Without a fee limit the bump is never capped
+	unlimited := &Client{cfg: Config{MaxBumps: 3}}
+	if action, _, feeCap := unlimited.replacementFees(row(gwei(2), gwei(180), 1), gwei(2), gwei(180)); action != "bumped" || feeCap.String() != "202500000001" {
+		t.Errorf("unlimited: replacementFees = %s, %s; want bumped, 202500000001", action, feeCap)
+	}
+}
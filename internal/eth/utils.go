@@ -1 +1,20 @@
-package eth
\ No newline at end of file
+package eth
+
+import (
+	"os"
+	"strconv"
+)
+
+// ContractAddress returns the address of the deployed credential contract.
+func ContractAddress() string {
+	return ConfigFromEnv().ContractAddress
+}
+
+// ChainID returns the chain the credential contract lives on, from CHAIN_ID
+// (default Sepolia, 11155111).
+func ChainID() int64 {
+	if v, err := strconv.ParseInt(os.Getenv("CHAIN_ID"), 10, 64); err == nil && v > 0 {
+		return v
+	}
+	return 11155111
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"vericred/pkg"
+)
+
+// sessionWallet returns the wallet from an optional Bearer session token on a
+// public route, or "" when the request is anonymous or the token is invalid.
+func sessionWallet(r *http.Request) string {
+	parts := strings.Split(r.Header.Get("Authorization"), " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return ""
+	}
+	addr, err := pkg.VerifyToken(parts[1])
+	if err != nil {
+		return ""
+	}
+	return addr
+}
+
+// canReadCredential allows the holder, the issuer, or anyone presenting a
+// valid share token (?token=) for this credential.
+func canReadCredential(r *http.Request, credID, studentWallet, universityWallet string) bool {
+	if tok := r.URL.Query().Get("token"); tok != "" {
+		// Selective links only reveal their disclosures, never the record.
+		if claims, err := parseShareToken(tok); err == nil && claims.CredentialID == credID && !claims.Selective {
+			return true
+		}
+	}
+	addr := sessionWallet(r)
+	return addr != "" && (equalCaseInsensitive(addr, studentWallet) || equalCaseInsensitive(addr, universityWallet))
+}
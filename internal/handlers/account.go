@@ -6,6 +6,7 @@ import (
 	"log"
 	"net/http"
 	"vericred/internal/db"
+	"vericred/internal/envelope"
 	"vericred/internal/models"
 	"vericred/pkg"
 	"vericred/redisdb"
@@ -55,9 +56,17 @@ func LoginInMetamask(w http.ResponseWriter, r *http.Request) {
         return
     }
 
+    // The key behind the login signature is what private credential data
+    // gets encrypted to
+    publicKey := ""
+    if pub, err := pkg.RecoverPublicKey(nonce, signature); err == nil {
+        publicKey = envelope.PublicKeyHex(pub)
+    }
+
     acc := models.Accounts{
         MetamaskAddress: metamaskAddress,
         AccountType:     "unknown", // defer role until profile creation
+        PublicKey:       publicKey,
     }
 
     var existingAcc models.Accounts
@@ -73,6 +82,10 @@ func LoginInMetamask(w http.ResponseWriter, r *http.Request) {
     } else if result.Error != nil {
         http.Error(w, "Database error", http.StatusInternalServerError)
         return
+    } else if publicKey != "" && existingAcc.PublicKey != publicKey {
+        if err := db.DB.Model(&existingAcc).Update("public_key", publicKey).Error; err != nil {
+            log.Println("Failed to store public key:", err)
+        }
     }
 
     tokenStr, err := pkg.CreateToken(acc.MetamaskAddress)
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"vericred/internal/asset"
+	"vericred/internal/db"
+	"vericred/internal/eth/ipfs"
+	"vericred/internal/jobs"
+	"vericred/internal/models"
+)
+
+// POST /api/v1/org/assets (protected, verified org)
+// Multipart form with "file" and optional "kind" (image, the default, or
+// animation). Images are sniffed, size-checked and re-encoded (see
+// asset.Normalize) before they are pinned on their own; use the returned
+// uri as "image" or "animation_url" when uploading metadata.
+func UploadAsset(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	up, ok := receiveAsset(w, r, org, "")
+	if !ok {
+		return
+	}
+	writeJSONResp(w, http.StatusOK, map[string]any{
+		"uri":          up.link,
+		"cid":          strings.TrimPrefix(up.link, "ipfs://"),
+		"gateway_url":  ipfs.GatewayURL(up.link),
+		"name":         up.name,
+		"kind":         up.kind,
+		"content_type": up.ContentType,
+		"size":         len(up.Data),
+		"width":        up.Width,
+		"height":       up.Height,
+	})
+}
+
+// uploadedAsset is an asset receiveAsset pinned.
+type uploadedAsset struct {
+	*asset.Asset
+	link string
+	name string
+	kind string
+}
+
+// receiveAsset reads the multipart "file" upload, normalizes it and pins it
+// for org, writing the error response itself when it fails. kind forces the
+// asset kind; "" reads it from the "kind" field.
+func receiveAsset(w http.ResponseWriter, r *http.Request, org models.Organization, kind string) (*uploadedAsset, bool) {
+	limits := asset.DefaultLimits()
+	// Leave room for the multipart framing and the kind field
+	r.Body = http.MaxBytesReader(w, r.Body, limits.MaxBytes+1<<20)
+	if err := r.ParseMultipartForm(limits.MaxBytes + 1<<20); err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			http.Error(w, fmt.Sprintf("asset is larger than %d bytes", limits.MaxBytes), http.StatusRequestEntityTooLarge)
+			return nil, false
+		}
+		http.Error(w, "failed to parse form", http.StatusBadRequest)
+		return nil, false
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "file field 'file' is required", http.StatusBadRequest)
+		return nil, false
+	}
+	defer file.Close()
+	if kind == "" {
+		kind = strings.TrimSpace(r.FormValue("kind"))
+	}
+	if kind == "" {
+		kind = asset.KindImage
+	}
+	data, err := io.ReadAll(io.LimitReader(file, limits.MaxBytes+1))
+	if err != nil {
+		http.Error(w, "failed to read file", http.StatusBadRequest)
+		return nil, false
+	}
+
+	a, err := asset.Normalize(data, kind, limits)
+	switch {
+	case errors.Is(err, asset.ErrTooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return nil, false
+	case errors.Is(err, asset.ErrUnsupported):
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return nil, false
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return nil, false
+	}
+
+	name := assetName(header.Filename, a.Ext)
+	cid, err := ipfs.Store().Put(r.Context(), name, a.Data)
+	if err != nil {
+		fmt.Println("Asset upload failed:", err)
+		http.Error(w, "failed to upload to IPFS", http.StatusInternalServerError)
+		return nil, false
+	}
+	link := ipfs.CanonicalURI(cid)
+	if err := jobs.RecordAssetPin(link, a, name, org.MetamaskAddress, org.ID); err != nil {
+		fmt.Println("Failed to record pin:", err)
+	}
+	return &uploadedAsset{Asset: a, link: link, name: name, kind: kind}, true
+}
+
+// GET /api/v1/org/assets?limit=100 (protected, verified org)
+// Lists the org's uploaded assets, newest first.
+func ListAssets(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	limit := 100
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 && v <= 500 {
+		limit = v
+	}
+	var pins []models.Pin
+	if err := db.DB.Where("organization_id = ? AND kind = ? AND status <> ?", org.ID, models.PinKindAsset, models.PinStatusUnpinned).
+		Order("created_at DESC").Limit(limit).Find(&pins).Error; err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	out := make([]map[string]any, 0, len(pins))
+	for _, p := range pins {
+		link := ipfs.CanonicalURI(p.CID)
+		out = append(out, map[string]any{
+			"uri":          link,
+			"gateway_url":  ipfs.GatewayURL(link),
+			"name":         p.Name,
+			"content_type": p.ContentType,
+			"size":         p.Size,
+			"width":        p.Width,
+			"height":       p.Height,
+			"status":       p.Status,
+			"in_use":       p.CredentialID != nil,
+			"pinned_at":    p.PinnedAt,
+		})
+	}
+	writeJSONResp(w, http.StatusOK, map[string]any{"assets": out})
+}
+
+// assetName is the upload's file name reduced to safe characters, with the
+// extension of the normalized type.
+func assetName(filename, ext string) string {
+	base := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	var b strings.Builder
+	for _, r := range base {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			b.WriteRune(r)
+		case r == ' ' || r == '.':
+			b.WriteRune('-')
+		}
+		if b.Len() >= 100 {
+			break
+		}
+	}
+	if b.Len() == 0 {
+		return "asset" + ext
+	}
+	return b.String() + ext
+}
+
+// assetProblems checks that each link (by field name) names an asset the
+// org uploaded and that is still pinned, so metadata can't reference
+// someone else's files or content that was garbage-collected.
+func assetProblems(orgID uint, fields map[string]string) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var out []string
+	for _, field := range names {
+		link := fields[field]
+		if link == "" {
+			continue
+		}
+		cid, _, _ := strings.Cut(strings.TrimPrefix(link, "ipfs://"), "/")
+		var n int64
+		db.DB.Model(&models.Pin{}).
+			Where("cid = ? AND kind = ? AND organization_id = ? AND status = ?", cid, models.PinKindAsset, orgID, models.PinStatusPinned).
+			Count(&n)
+		if n == 0 {
+			out = append(out, fmt.Sprintf("%s %s is not a pinned asset of this organization; upload it to /api/v1/org/assets first", field, link))
+		}
+	}
+	return out
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"vericred/internal/asset"
+	"vericred/internal/branding"
+	"vericred/internal/certificate"
+	"vericred/internal/db"
+	"vericred/internal/eth/ipfs"
+	"vericred/internal/models"
+)
+
+// brandingResp is the org's own view of its profile, with gateway URLs for
+// the images.
+func brandingResp(b models.OrganizationBranding, org models.Organization) map[string]any {
+	out := branding.View(b, org)
+	out["logo"] = b.LogoURI
+	out["seal"] = b.SealURI
+	out["updated_at"] = b.UpdatedAt
+	return out
+}
+
+// issuerBranding is the branding block of verification responses, or nil
+// when the org can't be loaded.
+func issuerBranding(orgID uint) map[string]any {
+	var org models.Organization
+	if err := db.DB.First(&org, orgID).Error; err != nil {
+		return nil
+	}
+	b, err := branding.Load(orgID)
+	if err != nil {
+		return nil
+	}
+	return branding.View(b, org)
+}
+
+// GET /api/v1/org/branding (protected, verified org)
+func GetBranding(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	b, err := branding.Load(org.ID)
+	if err != nil {
+		http.Error(w, "failed to load branding", http.StatusInternalServerError)
+		return
+	}
+	writeJSONResp(w, http.StatusOK, brandingResp(b, org))
+}
+
+// PUT /api/v1/org/branding (protected, verified org)
+// Body: { display_name, logo, seal, primary_color, secondary_color, verification_footer }
+// logo and seal are ipfs:// URIs of image assets the org uploaded (or use
+// POST /api/v1/org/branding/{logo|seal}); colors are #RRGGBB. Empty fields
+// clear the setting.
+func PutBranding(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	var body models.OrganizationBranding
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	var problems []string
+	colors := map[string]*string{"primary_color": &body.PrimaryColor, "secondary_color": &body.SecondaryColor}
+	for _, field := range []string{"primary_color", "secondary_color"} {
+		c := colors[field]
+		*c = strings.TrimSpace(*c)
+		if *c == "" {
+			continue
+		}
+		if _, _, _, ok := certificate.ParseColor(*c); !ok || !strings.HasPrefix(*c, "#") {
+			problems = append(problems, field+" must be #RRGGBB")
+			continue
+		}
+		*c = strings.ToUpper(*c)
+	}
+	if len(strings.TrimSpace(body.DisplayName)) > 255 {
+		problems = append(problems, "display_name must be at most 255 characters")
+	}
+	if len(body.VerificationFooter) > 1000 {
+		problems = append(problems, "verification_footer must be at most 1000 characters")
+	}
+	images := map[string]*string{"logo": &body.LogoURI, "seal": &body.SealURI}
+	for _, field := range []string{"logo", "seal"} {
+		link := images[field]
+		if *link = strings.TrimSpace(*link); *link == "" {
+			continue
+		}
+		*link = ipfs.CanonicalLink(*link)
+		problems = append(problems, brandingImageProblems(org.ID, field, *link)...)
+	}
+	if len(problems) > 0 {
+		writeJSONResp(w, http.StatusUnprocessableEntity, map[string]any{"error": "invalid branding", "problems": problems})
+		return
+	}
+
+	b, err := branding.Load(org.ID)
+	if err != nil {
+		http.Error(w, "failed to load branding", http.StatusInternalServerError)
+		return
+	}
+	b.DisplayName = strings.TrimSpace(body.DisplayName)
+	b.LogoURI = body.LogoURI
+	b.SealURI = body.SealURI
+	b.PrimaryColor = body.PrimaryColor
+	b.SecondaryColor = body.SecondaryColor
+	b.VerificationFooter = strings.TrimSpace(body.VerificationFooter)
+	if err := db.DB.Save(&b).Error; err != nil {
+		http.Error(w, "failed to save branding", http.StatusInternalServerError)
+		return
+	}
+	writeJSONResp(w, http.StatusOK, brandingResp(b, org))
+}
+
+// POST /api/v1/org/branding/{slot} (protected, verified org)
+// Uploads the logo or seal as multipart "file" (see UploadAsset) and sets it
+// on the profile in one step.
+func UploadBrandingImage(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	slot := chi.URLParam(r, "slot")
+	if slot != "logo" && slot != "seal" {
+		http.Error(w, "slot must be logo or seal", http.StatusNotFound)
+		return
+	}
+	up, ok := receiveAsset(w, r, org, asset.KindImage)
+	if !ok {
+		return
+	}
+
+	b, err := branding.Load(org.ID)
+	if err != nil {
+		http.Error(w, "failed to load branding", http.StatusInternalServerError)
+		return
+	}
+	if slot == "logo" {
+		b.LogoURI = up.link
+	} else {
+		b.SealURI = up.link
+	}
+	if err := db.DB.Save(&b).Error; err != nil {
+		http.Error(w, "failed to save branding", http.StatusInternalServerError)
+		return
+	}
+	writeJSONResp(w, http.StatusOK, brandingResp(b, org))
+}
+
+// brandingImageProblems checks that link is one of the org's pinned image
+// assets; video can't serve as a logo or seal.
+func brandingImageProblems(orgID uint, field, link string) []string {
+	if problems := assetProblems(orgID, map[string]string{field: link}); len(problems) > 0 {
+		return problems
+	}
+	var pin models.Pin
+	cid, _, _ := strings.Cut(strings.TrimPrefix(link, "ipfs://"), "/")
+	if err := db.DB.Where("cid = ?", cid).First(&pin).Error; err != nil || !strings.HasPrefix(pin.ContentType, "image/") {
+		return []string{fmt.Sprintf("%s %s is not an image", field, link)}
+	}
+	return nil
+}
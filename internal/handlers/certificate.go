@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+
+	"vericred/internal/branding"
+	"vericred/internal/certificate"
+	"vericred/internal/db"
+	"vericred/internal/eip712"
+	"vericred/internal/models"
+)
+
+// certificateLinkTTL is how long the verification link printed on a
+// certificate stays valid. Paper outlives share links, so this defaults to
+// five years (CERTIFICATE_LINK_TTL_HOURS overrides).
+func certificateLinkTTL() time.Duration {
+	if v := os.Getenv("CERTIFICATE_LINK_TTL_HOURS"); v != "" {
+		if h, err := strconv.Atoi(v); err == nil && h > 0 {
+			return time.Duration(h) * time.Hour
+		}
+	}
+	return 5 * 365 * 24 * time.Hour
+}
+
+// certificateHash is the canonical hash printed on a certificate, recomputed
+// for credentials issued before hashes were stored.
+func certificateHash(cred models.Credential) string {
+	if cred.CanonicalHash != "" {
+		return cred.CanonicalHash
+	}
+	hash, _ := eip712.CredentialHash(eip712.FieldsOf(cred))
+	return hash
+}
+
+// orgCertificateTemplate returns the org's template, or the zero template
+// (rendered with defaults) when none has been saved.
+func orgCertificateTemplate(orgID uint) (models.CertificateTemplate, error) {
+	var tpl models.CertificateTemplate
+	err := db.DB.Where("organization_id = ?", orgID).First(&tpl).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.CertificateTemplate{OrganizationID: orgID}, nil
+	}
+	return tpl, err
+}
+
+// GET /api/v1/org/certificate-template (protected, verified org)
+func GetCertificateTemplate(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	tpl, err := orgCertificateTemplate(org.ID)
+	if err != nil {
+		http.Error(w, "failed to load template", http.StatusInternalServerError)
+		return
+	}
+	writeJSONResp(w, http.StatusOK, certificate.Defaults(tpl))
+}
+
+// PUT /api/v1/org/certificate-template (protected, verified org)
+// Body: { layout, title, preamble, body, accent_color, signatory_name, signatory_title, footer }
+func PutCertificateTemplate(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	var body models.CertificateTemplate
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if body.Layout != "" && body.Layout != certificate.LayoutClassic && body.Layout != certificate.LayoutModern {
+		http.Error(w, "layout must be classic or modern", http.StatusBadRequest)
+		return
+	}
+	if body.AccentColor != "" {
+		if _, _, _, ok := certificate.ParseColor(body.AccentColor); !ok {
+			http.Error(w, "accent_color must be #RRGGBB", http.StatusBadRequest)
+			return
+		}
+	}
+
+	tpl, err := orgCertificateTemplate(org.ID)
+	if err != nil {
+		http.Error(w, "failed to load template", http.StatusInternalServerError)
+		return
+	}
+	tpl.Layout = body.Layout
+	tpl.Title = body.Title
+	tpl.Preamble = body.Preamble
+	tpl.Body = body.Body
+	tpl.AccentColor = body.AccentColor
+	tpl.SignatoryName = body.SignatoryName
+	tpl.SignatoryTitle = body.SignatoryTitle
+	tpl.Footer = body.Footer
+	if tpl.Layout == "" {
+		tpl.Layout = certificate.LayoutClassic
+	}
+	if err := db.DB.Save(&tpl).Error; err != nil {
+		http.Error(w, "failed to save template", http.StatusInternalServerError)
+		return
+	}
+	writeJSONResp(w, http.StatusOK, certificate.Defaults(tpl))
+}
+
+// GET /api/v1/credentials/{id}/certificate.pdf
+// Holder or issuer session, or ?token= share token. The printed link lasts
+// certificateLinkTTL for sessions and as long as the presented token
+// otherwise.
+func CredentialCertificatePDF(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var cred models.Credential
+	if err := db.DB.Preload("User").Preload("Organization").Where("id = ?", id).First(&cred).Error; err != nil {
+		http.Error(w, "credential not found", http.StatusNotFound)
+		return
+	}
+	if !canReadCredential(r, cred.ID, cred.StudentWallet, cred.UniversityWallet) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	if cred.Status == models.CredentialStatusRevoked {
+		http.Error(w, "credential has been revoked", http.StatusGone)
+		return
+	}
+	if cred.Status == models.CredentialStatusSuperseded {
+		http.Error(w, "credential has been superseded by "+currentCredentialID(cred), http.StatusGone)
+		return
+	}
+
+	tpl, err := orgCertificateTemplate(cred.OrganizationID)
+	if err != nil {
+		http.Error(w, "failed to load template", http.StatusInternalServerError)
+		return
+	}
+	brand, err := branding.Load(cred.OrganizationID)
+	if err != nil {
+		http.Error(w, "failed to load branding", http.StatusInternalServerError)
+		return
+	}
+	// The template's own color and footer win over the branding profile
+	if tpl.AccentColor == "" {
+		tpl.AccentColor = brand.PrimaryColor
+	}
+	if tpl.Footer == "" {
+		tpl.Footer = brand.VerificationFooter
+	}
+	// Only the holder or issuer gets the long-lived link; a share-link
+	// viewer's copy expires when their own link does
+	expires := time.Now().Add(certificateLinkTTL())
+	if addr := sessionWallet(r); addr == "" || !(equalCaseInsensitive(addr, cred.StudentWallet) || equalCaseInsensitive(addr, cred.UniversityWallet)) {
+		claims, err := parseShareToken(r.URL.Query().Get("token"))
+		if err != nil {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		expires = claims.ExpiresAt.Time
+	}
+	token, err := signShareTokenUntil(shareClaims{CredentialID: cred.ID}, expires)
+	if errors.Is(err, errShareSecret) {
+		http.Error(w, "server misconfigured", http.StatusInternalServerError)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to sign share token", http.StatusInternalServerError)
+		return
+	}
+
+	studentName := strings.TrimSpace(cred.User.FirstName + " " + cred.User.LastName)
+	if studentName == "" {
+		studentName = cred.StudentWallet
+	}
+	data := certificate.Data{
+		CredentialID:   cred.ID,
+		StudentName:    studentName,
+		DegreeName:     cred.DegreeName,
+		Major:          cred.Major,
+		Type:           cred.Type,
+		IssuedDate:     cred.IssuedDate.Format("January 2, 2006"),
+		GraduationDate: cred.GraduationDate,
+		OrgName:        branding.Name(brand, cred.Organization),
+		CredentialHash: certificateHash(cred),
+		VerifyURL:      shareURL(cred.ID, token),
+	}
+	// Branding images are decoration; render without them if unavailable
+	if brand.LogoURI != "" {
+		if data.Logo, err = branding.LoadImage(r.Context(), brand.LogoURI); err != nil {
+			fmt.Println("certificate logo:", err)
+		}
+	}
+	if brand.SealURI != "" {
+		if data.Seal, err = branding.LoadImage(r.Context(), brand.SealURI); err != nil {
+			fmt.Println("certificate seal:", err)
+		}
+	}
+	out, err := certificate.Render(data, tpl)
+	if err != nil {
+		fmt.Println("certificate render failed:", err)
+		http.Error(w, "failed to render certificate", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="certificate-%s.pdf"`, cred.ID))
+	w.Header().Set("Content-Length", strconv.Itoa(len(out)))
+	_, _ = w.Write(out)
+}
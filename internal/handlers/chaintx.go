@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"vericred/internal/db"
+	"vericred/internal/jobs"
+	"vericred/internal/models"
+)
+
+// GET /api/admin/chain-transactions?status=pending|confirmed|failed|dropped&kind=deploy|new_org|mint&limit=100 (admin)
+// Lists transactions sent by the server wallet with counts per status.
+func ListChainTransactions(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 && v <= 500 {
+		limit = v
+	}
+	q := db.DB.Model(&models.ChainTransaction{})
+	switch status := r.URL.Query().Get("status"); status {
+	case "":
+	case models.ChainTxPending, models.ChainTxConfirmed, models.ChainTxFailed, models.ChainTxDropped:
+		q = q.Where("status = ?", status)
+	default:
+		http.Error(w, "status must be pending, confirmed, failed or dropped", http.StatusBadRequest)
+		return
+	}
+	if kind := r.URL.Query().Get("kind"); kind != "" {
+		q = q.Where("kind = ?", kind)
+	}
+	var txs []models.ChainTransaction
+	if err := q.Order("created_at DESC").Limit(limit).Find(&txs).Error; err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+
+	type statusCount struct {
+		Status string
+		Count  int64
+	}
+	var counts []statusCount
+	if err := db.DB.Model(&models.ChainTransaction{}).
+		Select("status, count(*) as count").
+		Group("status").
+		Scan(&counts).Error; err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	byStatus := map[string]int64{}
+	for _, c := range counts {
+		byStatus[c.Status] = c.Count
+	}
+	writeJSONResp(w, http.StatusOK, map[string]any{
+		"counts":       byStatus,
+		"transactions": txs,
+	})
+}
+
+// POST /api/admin/chain-transactions/run (admin)
+// Checks pending transactions synchronously, re-sending stuck ones, and
+// returns the summary.
+func RunTxMonitor(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
+	defer cancel()
+
+	sum, err := jobs.GetTxMonitor().RunOnce(ctx)
+	if errors.Is(err, jobs.ErrTxMonitorRunning) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		writeJSONResp(w, http.StatusBadGateway, map[string]any{"error": err.Error(), "summary": sum})
+		return
+	}
+	writeJSONResp(w, http.StatusOK, sum)
+}
@@ -6,19 +6,30 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 	"vericred/internal/db"
+	"vericred/internal/eip712"
+	"vericred/internal/eth/ipfs"
+	"vericred/internal/jobs"
 	"vericred/internal/middleware"
 	"vericred/internal/models"
 
 	"gorm.io/gorm"
 )
 
+// POST /credmint (protected)
+// The authenticated wallet must be a verified organization; it is the issuer.
 func MintCredentials(w http.ResponseWriter, r *http.Request) {
 
 	// fmt.Println("Inside the mint cred function")
 
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+
 	var body map[string]any
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		fmt.Println("Error in decoding: ", err)
@@ -36,15 +47,31 @@ func MintCredentials(w http.ResponseWriter, r *http.Request) {
 	}
 	cred.StudentWallet = studentWallet
 
-	universityWallet, ok := body["university_wallet"].(string)
-	if !ok || universityWallet == "" {
-		fmt.Println("Invalid or missing 'university_wallet'")
-		http.Error(w, "Invalid or missing 'university_wallet'", http.StatusBadRequest)
+	// university_wallet is optional; when sent it must be the session's wallet
+	if universityWallet, ok := body["university_wallet"].(string); ok && universityWallet != "" &&
+		!equalCaseInsensitive(universityWallet, org.MetamaskAddress) {
+		fmt.Println("university_wallet does not match authenticated organization")
+		http.Error(w, "forbidden: university_wallet does not match authenticated organization", http.StatusForbidden)
 		return
 	}
-	cred.UniversityWallet = universityWallet
+	cred.UniversityWallet = org.MetamaskAddress
+
+	// degree_id picks a catalog program; otherwise degree_name is resolved
+	// against the catalog by name or alias
+	var program *models.Program
+	if v, ok := body["degree_id"].(float64); ok {
+		var p models.Program
+		if err := db.DB.Where("id = ? AND organization_id = ?", uint(v), org.ID).First(&p).Error; err != nil {
+			http.Error(w, "Invalid 'degree_id': program not found in organization catalog", http.StatusBadRequest)
+			return
+		}
+		program = &p
+	}
 
 	degreeName, ok := body["degree_name"].(string)
+	if (!ok || degreeName == "") && program != nil {
+		degreeName, ok = program.Name, true
+	}
 	if !ok || degreeName == "" {
 		fmt.Println("Invalid or missing 'degree_name'")
 		http.Error(w, "Invalid or missing 'degree_name'", http.StatusBadRequest)
@@ -59,7 +86,22 @@ func MintCredentials(w http.ResponseWriter, r *http.Request) {
 		cred.Description = description
 	}
 
+	// template_id is required once the org has defined templates; the
+	// template fixes the type and supplies the default description
+	var templateID *uint
+	if v, ok := body["template_id"].(float64); ok {
+		id := uint(v)
+		templateID = &id
+	}
+	tpl, ok := resolveTemplate(w, org.ID, templateID)
+	if !ok {
+		return
+	}
+
 	credType, ok := body["type"].(string)
+	if (!ok || credType == "") && tpl != nil {
+		credType, ok = tpl.CredentialType, true
+	}
 	if !ok || credType == "" {
 		fmt.Println("Invalid or missing 'type'")
 		http.Error(w, "Invalid or missing 'type'", http.StatusBadRequest)
@@ -67,11 +109,46 @@ func MintCredentials(w http.ResponseWriter, r *http.Request) {
 	}
 	cred.Type = credType
 
+	if tpl != nil {
+		if !strings.EqualFold(cred.Type, tpl.CredentialType) {
+			http.Error(w, fmt.Sprintf("'type' must be %q for this template", tpl.CredentialType), http.StatusBadRequest)
+			return
+		}
+		if tpl.DegreeName != "" && !strings.EqualFold(cred.DegreeName, tpl.DegreeName) {
+			http.Error(w, fmt.Sprintf("'degree_name' must be %q for this template", tpl.DegreeName), http.StatusBadRequest)
+			return
+		}
+		if cred.Description == "" {
+			cred.Description = tpl.DefaultDescription
+		}
+		cred.TemplateID = &tpl.ID
+	}
+
 	major, ok := body["major"].(string)
 	if ok {
 		cred.Major = major
 	}
 
+	if program != nil && newProgramMatcher([]models.Program{*program}).Match(cred.DegreeName) == nil {
+		http.Error(w, fmt.Sprintf("'degree_name' %q does not match program %q", cred.DegreeName, program.Name), http.StatusBadRequest)
+		return
+	}
+	if program == nil {
+		matcher, err := orgProgramMatcher(org.ID)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		program = matcher.Match(cred.DegreeName)
+	}
+	if program != nil {
+		if !offersMajor(program, cred.Major) {
+			http.Error(w, fmt.Sprintf("'major' %q is not offered by program %q", cred.Major, program.Name), http.StatusBadRequest)
+			return
+		}
+		cred.DegreeID = &program.ID
+	}
+
 	// issued date: accept multiple formats (RFC3339, date-only, or datetime)
 	issuedDateStr, ok := body["issued_date"].(string)
 	if !ok || issuedDateStr == "" {
@@ -108,7 +185,7 @@ func MintCredentials(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid or missing 'ipfs_link'", http.StatusBadRequest)
 		return
 	}
-	cred.IPFSLink = strings.TrimSpace(ipfsLink)
+	cred.IPFSLink = ipfs.CanonicalLink(ipfsLink)
 
 	deanSig, ok := body["dean_sig"].(string)
 	if !ok || deanSig == "" {
@@ -118,18 +195,6 @@ func MintCredentials(w http.ResponseWriter, r *http.Request) {
 	}
 	cred.DeanSig = deanSig
 
-	var org models.Organization
-	if err := db.DB.Where("metamask_address = ?", cred.UniversityWallet).First(&org).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			fmt.Println("University not registered")
-			http.Error(w, "University not registered", http.StatusBadRequest)
-			return
-		}
-		fmt.Println("DB error finding university:", err)
-		http.Error(w, "Database error", http.StatusInternalServerError)
-		return
-	}
-
 	var user models.Users
 	if err := db.DB.Where("metamask_address = ?", cred.StudentWallet).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -142,6 +207,82 @@ func MintCredentials(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	approved, err := hasApprovedRequest(user.ID, org.ID)
+	if err != nil {
+		fmt.Println("DB error checking pending request:", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if !approved {
+		http.Error(w, "forbidden: student has no approved request with this organization", http.StatusForbidden)
+		return
+	}
+
+	signatory, err := issuingSignatory(org.ID, cred)
+	if isSignatureError(err) {
+		http.Error(w, "Invalid 'dean_sig': "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		fmt.Println("DB error checking signatory:", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	cred.SignatoryID = &signatory.ID
+
+	hash, err := eip712.CredentialHash(eip712.FieldsOf(cred))
+	if err != nil {
+		http.Error(w, "failed to hash credential", http.StatusInternalServerError)
+		return
+	}
+	cred.CanonicalHash = hash
+
+	md, err := ipfs.FetchMetadata(r.Context(), cred.IPFSLink)
+	if err != nil {
+		fmt.Println("Metadata fetch failed:", err)
+		http.Error(w, "Could not verify 'ipfs_link' content: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	env, err := envelopeForMetadata(org.ID, cred.StudentWallet, md, "")
+	if err != nil {
+		http.Error(w, "Could not link private data: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	sdSet, err := disclosureSetForMetadata(org.ID, cred.StudentWallet, md, "")
+	if err != nil {
+		http.Error(w, "Could not link disclosures: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	mismatches := metadataMismatches(md, cred)
+	if tpl != nil {
+		// Sealed and withheld attributes are left out of the metadata on purpose
+		omitted := slices.Concat(sealedTraits(env), withheldTraits(sdSet))
+		mismatches = append(mismatches, ipfs.TemplateMismatches(md, *tpl, omitted...)...)
+	}
+	if len(mismatches) > 0 {
+		writeJSONResp(w, http.StatusUnprocessableEntity, map[string]any{
+			"error":      "ipfs_link content does not match submitted fields or template",
+			"mismatches": mismatches,
+		})
+		return
+	}
+
+	tr, err := transcriptForMetadata(org.ID, cred.StudentWallet, md, "")
+	if errors.Is(err, errTranscriptUnmatched) {
+		http.Error(w, "Could not link transcript: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		fmt.Println("DB error finding transcript:", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := commitDisclosures(&cred, sdSet, studentName(user), md); err != nil {
+		http.Error(w, "failed to salt credential fields", http.StatusInternalServerError)
+		return
+	}
+
 	fmt.Println("UserID: ", user.ID)
 	fmt.Println("OrgID: ", org.ID)
 	cred.UserID = user.ID
@@ -157,13 +298,49 @@ func MintCredentials(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, res, http.StatusInternalServerError)
 		return
 	}
+	if tr != nil {
+		if err := tx.Model(tr).Update("credential_id", cred.ID).Error; err != nil {
+			tx.Rollback()
+			fmt.Println("Failed to link transcript:", err)
+			http.Error(w, "Failed to link transcript", http.StatusInternalServerError)
+			return
+		}
+	}
+	if env != nil {
+		if err := tx.Model(env).Update("credential_id", cred.ID).Error; err != nil {
+			tx.Rollback()
+			fmt.Println("Failed to link private data:", err)
+			http.Error(w, "Failed to link private data", http.StatusInternalServerError)
+			return
+		}
+	}
+	if sdSet != nil {
+		if err := tx.Model(sdSet).Update("credential_id", cred.ID).Error; err != nil {
+			tx.Rollback()
+			fmt.Println("Failed to link disclosures:", err)
+			http.Error(w, "Failed to link disclosures", http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := jobs.AttachPin(tx, cred.IPFSLink, cred.ID); err != nil {
+		tx.Rollback()
+		fmt.Println("Failed to attach pin:", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if err := jobs.RetainAssets(tx, cred.ID, md.Image, md.AnimationURL); err != nil {
+		tx.Rollback()
+		fmt.Println("Failed to attach pin:", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
 	if err := tx.Commit().Error; err != nil {
 		fmt.Println("Transaction commit failed:", err)
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
 
-	if err := db.DB.Preload("User").Preload("Organization").Where("id = ?", cred.ID).First(&cred).Error; err != nil {
+	if err := db.DB.Preload("User").Preload("Organization").Preload("Degree").Where("id = ?", cred.ID).First(&cred).Error; err != nil {
 		fmt.Println("Preload error:", err)
 		http.Error(w, "Error when preloading", http.StatusInternalServerError)
 		return
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/go-chi/chi/v5"
+
+	"vericred/internal/db"
+	"vericred/internal/disclosure"
+	"vericred/internal/eth/ipfs"
+	"vericred/internal/middleware"
+	"vericred/internal/models"
+)
+
+var errDisclosuresUnmatched = errors.New("no disclosure set matches the metadata commitment")
+
+// studentNameForWallet is the name of the user registered with wallet, or ""
+// when the student hasn't signed up yet.
+func studentNameForWallet(wallet string) string {
+	var u models.Users
+	if err := db.DB.Where("LOWER(metamask_address) = LOWER(?)", wallet).First(&u).Error; err != nil {
+		return ""
+	}
+	return studentName(u)
+}
+
+// disclosureSetForMetadata finds the disclosures salted when md was pinned,
+// for linking to the credential being recorded. It returns nil when md
+// carries no disclosure commitment.
+func disclosureSetForMetadata(orgID uint, studentWallet string, md *ipfs.Metadata, allowLinked string) (*models.DisclosureSet, error) {
+	root, ok := md.Trait(ipfs.TraitDisclosureCommitment)
+	if !ok || root == "" {
+		return nil, nil
+	}
+	var set models.DisclosureSet
+	err := db.DB.Where("organization_id = ? AND root = ? AND LOWER(student_wallet) = LOWER(?)", orgID, root, studentWallet).
+		First(&set).Error
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errDisclosuresUnmatched, root)
+	}
+	if set.CredentialID != nil && *set.CredentialID != allowLinked {
+		return nil, fmt.Errorf("%w: %s is already linked", errDisclosuresUnmatched, root)
+	}
+	return &set, nil
+}
+
+// withheldTraits names the attributes set keeps out of the pinned metadata;
+// nil when there is no set.
+func withheldTraits(set *models.DisclosureSet) []string {
+	if set == nil {
+		return nil
+	}
+	return set.Withheld
+}
+
+// commitDisclosures gives cred the disclosures salted when its metadata was
+// pinned. Metadata pinned without them (older uploads) is salted now, which
+// leaves the digests unanchored.
+func commitDisclosures(cred *models.Credential, set *models.DisclosureSet, studentName string, md *ipfs.Metadata) error {
+	if set != nil {
+		cred.SDDisclosures = set.Disclosures
+		cred.SDDigests = set.Digests
+		return nil
+	}
+	return disclosure.Commit(cred, studentName, disclosure.MetadataTraits(md))
+}
+
+// disclosureAnchor reports the root of cred's digests and whether md, checked
+// against its CID, carries it as its Disclosure Commitment.
+func disclosureAnchor(cred models.Credential, md *ipfs.Metadata, res *ipfs.Resolution) (string, bool) {
+	root := disclosure.Root(cred.SDDigests)
+	if md == nil || !res.Verified() || root == "" {
+		return root, false
+	}
+	pinned, _ := md.Trait(ipfs.TraitDisclosureCommitment)
+	return root, pinned == root
+}
+
+// ensureDisclosures salts the fields of a credential issued before selective
+// disclosure existed. Credentials issued since are committed at issuance.
+func ensureDisclosures(ctx context.Context, cred *models.Credential) error {
+	if len(cred.SDDisclosures) > 0 {
+		return nil
+	}
+	var student models.Users
+	if err := db.DB.First(&student, cred.UserID).Error; err != nil {
+		return fmt.Errorf("load student: %w", err)
+	}
+	// Extra traits are best-effort: without the document only the columns
+	// become disclosable.
+	md, _ := ipfs.FetchMetadata(ctx, cred.IPFSLink)
+	if err := disclosure.Commit(cred, studentName(student), disclosure.MetadataTraits(md)); err != nil {
+		return err
+	}
+	return db.DB.Model(cred).UpdateColumns(map[string]any{
+		"sd_disclosures": cred.SDDisclosures,
+		"sd_digests":     cred.SDDigests,
+	}).Error
+}
+
+// GET /api/v1/credentials/{id}/disclosures (protected, holder)
+// Lists the fields the holder can choose to disclose in a share link.
+func ListCredentialDisclosures(w http.ResponseWriter, r *http.Request) {
+	addr, ok := r.Context().Value(middleware.MetamaskAddressKey).(string)
+	if !ok || addr == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var cred models.Credential
+	if err := db.DB.Where("id = ?", chi.URLParam(r, "id")).First(&cred).Error; err != nil {
+		http.Error(w, "credential not found", http.StatusNotFound)
+		return
+	}
+	if !equalCaseInsensitive(cred.StudentWallet, addr) {
+		http.Error(w, "forbidden: not owner of credential", http.StatusForbidden)
+		return
+	}
+	if err := ensureDisclosures(r.Context(), &cred); err != nil {
+		fmt.Println("disclosure backfill failed:", err)
+		http.Error(w, "failed to prepare disclosures", http.StatusInternalServerError)
+		return
+	}
+	writeJSONResp(w, http.StatusOK, map[string]any{
+		"credential_id": cred.ID,
+		"fields":        disclosedFields(cred),
+	})
+}
+
+// disclosedFields maps each of cred's disclosable fields to its value. It
+// includes the attributes withheld from the pinned metadata.
+func disclosedFields(cred models.Credential) map[string]string {
+	fields := map[string]string{}
+	for _, enc := range cred.SDDisclosures {
+		if d, err := disclosure.Decode(enc); err == nil {
+			fields[d.Name] = d.Value
+		}
+	}
+	return fields
+}
+
+// selectiveCredentialInfo answers a share link that discloses only some
+// fields. Nothing that would lead back to the full record is returned: no
+// credential id, issuer wallet, IPFS document or token id. Follow-up checks
+// go through /api/v1/verify with the same share token.
+func selectiveCredentialInfo(w http.ResponseWriter, r *http.Request, cred models.Credential, claims *shareClaims) {
+	var org models.Organization
+	if err := db.DB.First(&org, cred.OrganizationID).Error; err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+
+	disclosed := disclosure.Verify(claims.Disclosures, cred.SDDigests)
+	allVerified := true
+	shown := map[string]bool{}
+	for _, d := range disclosed {
+		allVerified = allVerified && d.Verified
+		shown[d.Name] = true
+	}
+	hidden := []string{}
+	for _, n := range disclosure.Names(cred.SDDisclosures) {
+		if !shown[n] {
+			hidden = append(hidden, n)
+		}
+	}
+	// Transcripts and private data are never disclosed selectively
+	var withTranscript int64
+	if err := db.DB.Model(&models.Transcript{}).Where("credential_id = ?", cred.ID).Count(&withTranscript).Error; err == nil && withTranscript > 0 {
+		hidden = append(hidden, "transcript")
+	}
+	var withPrivate int64
+	if err := db.DB.Model(&models.PrivateEnvelope{}).Where("credential_id = ?", cred.ID).Count(&withPrivate).Error; err == nil && withPrivate > 0 {
+		hidden = append(hidden, "private")
+	}
+	sort.Strings(hidden)
+
+	md, res, fetchErr := ipfs.ResolveMetadata(r.Context(), cred.IPFSLink)
+	integrity := credentialIntegrity(r.Context(), cred, md, res, fetchErr)
+	content, _ := integrity["content"].(map[string]any)
+	root, anchored := disclosureAnchor(cred, md, res)
+
+	writeJSONResp(w, http.StatusOK, map[string]any{
+		"credential": map[string]any{
+			"issuer":    org.OrgName,
+			"selective": true,
+		},
+		"disclosed":         disclosed,
+		"disclosures_valid": allVerified,
+		"hidden":            hidden,
+		"sd_digests":        cred.SDDigests,
+		"sd_root":           root,
+		"sd_anchored":       anchored,
+		"integrity": map[string]any{
+			"consistent": integrity["consistent"],
+			"content":    map[string]any{"status": content["status"], "verified": content["verified"]},
+		},
+		"status":      selectiveStatus(cred),
+		"signatory":   signatoryReport(cred),
+		"branding":    issuerBranding(cred.OrganizationID),
+		"valid_until": claims.ExpiresAt.Time,
+	})
+}
+
+// selectiveStatus is credentialStatusPayload without the ids and reasons,
+// which name this and related credentials.
+func selectiveStatus(cred models.Credential) map[string]any {
+	full := credentialStatusPayload(cred)
+	return map[string]any{
+		"status":     full["status"],
+		"revoked":    full["revoked"],
+		"superseded": full["superseded"],
+	}
+}
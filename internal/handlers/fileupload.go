@@ -138,6 +138,14 @@ func BulkUploadHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Programs are resolved against the org's catalog by name or alias
+	matcher, err := orgProgramMatcher(org.ID)
+	if err != nil {
+		http.Error(w, "database error loading program catalog", http.StatusInternalServerError)
+		fmt.Println("Error:", "database error loading program catalog")
+		return
+	}
+
 	// 4) Begin transaction
 	tx := db.DB.Begin()
 	if tx.Error != nil {
@@ -154,6 +162,8 @@ func BulkUploadHandler(w http.ResponseWriter, r *http.Request) {
 	// 5) Read and insert rows
 	var count int
 	var duplicates int
+	var linked int
+	unmatched := map[string]int{}
 	for {
 		rec, err := reader.Read()
 		if errors.Is(err, io.EOF) {
@@ -231,6 +241,12 @@ func BulkUploadHandler(w http.ResponseWriter, r *http.Request) {
 			GraduationDate: graduationDate,
 			UniversityID:   org.ID,
 		}
+		if p := matcher.Match(program); p != nil {
+			row.ProgramID = &p.ID
+			linked++
+		} else if program != "" {
+			unmatched[program]++
+		}
 
 		if err := tx.Create(&row).Error; err != nil {
 			tx.Rollback()
@@ -252,6 +268,8 @@ func BulkUploadHandler(w http.ResponseWriter, r *http.Request) {
 		"message":             fmt.Sprintf("Successfully imported %d records. Skipped %d duplicates.", count, duplicates),
 		"inserted":            count,
 		"duplicates_skipped":  duplicates,
+		"linked_to_catalog":   linked,
+		"unmatched_programs":  unmatched,
 		"file":                header.Filename,
 	})
 }
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"vericred/internal/eip712"
+	"vericred/internal/eth"
+	"vericred/internal/eth/ipfs"
+	"vericred/internal/models"
+)
+
+// credentialIntegrity recomputes cred's canonical hash and checks that the
+// database row, the metadata pinned at IPFSLink (md, nil when it could not be
+// fetched) and the token's on-chain tokenURI all describe the same credential.
+// res is how the metadata was resolved, reported as "content". When the node
+// can't be reached, consistent is null and chain_unavailable is set.
+func credentialIntegrity(ctx context.Context, cred models.Credential, md *ipfs.Metadata, res *ipfs.Resolution, fetchErr error) map[string]any {
+	checks := map[string]verifyCheck{}
+	fields := eip712.FieldsOf(cred)
+	hash, err := eip712.CredentialHash(fields)
+	if err != nil {
+		return map[string]any{"consistent": false, "error": "failed to hash credential"}
+	}
+
+	switch {
+	case cred.CanonicalHash == "":
+		checks["database"] = verifyCheck{OK: false, Detail: "no canonical hash recorded (issued before hashing)"}
+	case !eip712.CredentialHashMatches(fields, cred.CanonicalHash):
+		checks["database"] = verifyCheck{OK: false, Detail: fmt.Sprintf("stored hash %s does not match the row's fields", cred.CanonicalHash)}
+	default:
+		checks["database"] = verifyCheck{OK: true}
+	}
+
+	switch {
+	case md == nil:
+		checks["ipfs"] = verifyCheck{OK: false, Detail: fmt.Sprintf("metadata unavailable: %v", fetchErr)}
+	default:
+		got, ok := md.Trait(ipfs.TraitCredentialHash)
+		switch {
+		case !ok:
+			checks["ipfs"] = verifyCheck{OK: false, Detail: "metadata has no Credential Hash attribute"}
+		case !eip712.CredentialHashMatches(fields, got):
+			checks["ipfs"] = verifyCheck{OK: false, Detail: fmt.Sprintf("metadata hash %s does not match the database fields", got)}
+		default:
+			checks["ipfs"] = verifyCheck{OK: true}
+		}
+	}
+
+	tokenID, ok := new(big.Int).SetString(cred.TokenID, 10)
+	chainUnavailable := false
+	switch {
+	case cred.TokenID == "" || !ok:
+		checks["chain"] = verifyCheck{OK: false, Detail: "token id not recorded (not minted or not yet reconciled)"}
+	default:
+		var uri string
+		chain, err := eth.Default(ctx)
+		if err == nil {
+			uri, err = chain.TokenURI(ctx, tokenID)
+		}
+		switch {
+		case errors.Is(err, eth.ErrUnavailable):
+			// Unknown rather than inconsistent; callers decide how to report it
+			chainUnavailable = true
+			checks["chain"] = verifyCheck{OK: false, Detail: "blockchain node unavailable"}
+		case err != nil:
+			checks["chain"] = verifyCheck{OK: false, Detail: fmt.Sprintf("tokenURI lookup failed: %v", err)}
+		case ipfs.ContentKey(uri) != ipfs.ContentKey(cred.IPFSLink):
+			checks["chain"] = verifyCheck{OK: false, Detail: fmt.Sprintf("token %s points at %s, not the credential's metadata", cred.TokenID, uri)}
+		default:
+			checks["chain"] = verifyCheck{OK: true, Detail: "token " + cred.TokenID}
+		}
+	}
+
+	consistent := true
+	for _, c := range checks {
+		consistent = consistent && c.OK
+	}
+	out := map[string]any{
+		"canonical_hash": hash,
+		"consistent":     consistent,
+		"checks":         checks,
+		"content":        contentIntegrity(res, fetchErr),
+	}
+	if chainUnavailable {
+		out["consistent"] = nil
+		out["chain_unavailable"] = true
+	}
+	return out
+}
+
+// contentIntegrity reports whether a resolved document matched its CID:
+// status is one of the ipfs.Integrity* values, "mismatch" when every source
+// served other content, or "unavailable".
+func contentIntegrity(res *ipfs.Resolution, fetchErr error) map[string]any {
+	if res == nil {
+		status := "unavailable"
+		if errors.Is(fetchErr, ipfs.ErrCIDMismatch) {
+			status = "mismatch"
+		}
+		out := map[string]any{"status": status, "verified": false}
+		if fetchErr != nil {
+			out["detail"] = fetchErr.Error()
+		}
+		return out
+	}
+	return map[string]any{
+		"status":   res.Integrity,
+		"verified": res.Verified(),
+		"cid":      res.CID,
+		"source":   res.Source,
+		"cached":   res.Cached,
+	}
+}
@@ -0,0 +1,595 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+
+	"vericred/internal/db"
+	"vericred/internal/jobs"
+	"vericred/internal/models"
+)
+
+type issuanceItemReq struct {
+	StudentWallet  string            `json:"student_wallet"`
+	DegreeName     string            `json:"degree_name"`
+	Type           string            `json:"type"`
+	Major          string            `json:"major"`
+	IssuedDate     string            `json:"issued_date"`
+	GraduationDate string            `json:"graduation_date"`
+	Description    string            `json:"description"`
+	DegreeID       *uint             `json:"degree_id"`
+	TemplateID     *uint             `json:"template_id"`
+	Values         map[string]string `json:"values"`
+	DeanSig        string            `json:"dean_sig"`
+}
+
+type createIssuanceJobReq struct {
+	TemplateID *uint             `json:"template_id"`
+	Items      []issuanceItemReq `json:"items"`
+}
+
+type rowProblems struct {
+	Row      int      `json:"row"`
+	Problems []string `json:"problems"`
+}
+
+// maxIssuanceItems caps a single job (ISSUANCE_MAX_ITEMS, default 1000).
+func maxIssuanceItems() int {
+	if v, err := strconv.Atoi(os.Getenv("ISSUANCE_MAX_ITEMS")); err == nil && v > 0 {
+		return v
+	}
+	return 1000
+}
+
+// parseIssuedDate accepts RFC3339, YYYY-MM-DD or "YYYY-MM-DD HH:MM:SS".
+func parseIssuedDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC3339, "2006-01-02", "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid issued_date %q; expected RFC3339 or YYYY-MM-DD", s)
+}
+
+var issuanceCSVColumns = map[string]bool{
+	"student_wallet": true, "degree_name": true, "type": true, "major": true,
+	"issued_date": true, "graduation_date": true, "description": true,
+	"degree_id": true, "template_id": true, "dean_sig": true,
+}
+
+// parseIssuanceCSV reads items from CSV with a header row. Known columns fill
+// the item fields; any other column becomes a template attribute value.
+func parseIssuanceCSV(rd io.Reader) ([]issuanceItemReq, error) {
+	reader := csv.NewReader(rd)
+	reader.TrimLeadingSpace = true
+	headers, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read CSV header: %w", err)
+	}
+	for i := range headers {
+		headers[i] = strings.TrimSpace(headers[i])
+	}
+
+	var items []issuanceItemReq
+	for {
+		rec, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return items, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV row %d: %w", len(items)+1, err)
+		}
+		var it issuanceItemReq
+		for i, h := range headers {
+			v := strings.TrimSpace(rec[i])
+			if v == "" {
+				continue
+			}
+			switch strings.ToLower(h) {
+			case "student_wallet":
+				it.StudentWallet = v
+			case "degree_name":
+				it.DegreeName = v
+			case "type":
+				it.Type = v
+			case "major":
+				it.Major = v
+			case "issued_date":
+				it.IssuedDate = v
+			case "graduation_date":
+				it.GraduationDate = v
+			case "description":
+				it.Description = v
+			case "dean_sig":
+				it.DeanSig = v
+			case "degree_id", "template_id":
+				n, err := strconv.ParseUint(v, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("row %d: invalid %s %q", len(items)+1, h, v)
+				}
+				id := uint(n)
+				if strings.EqualFold(h, "degree_id") {
+					it.DegreeID = &id
+				} else {
+					it.TemplateID = &id
+				}
+			default:
+				if it.Values == nil {
+					it.Values = map[string]string{}
+				}
+				it.Values[h] = v
+			}
+		}
+		items = append(items, it)
+	}
+}
+
+// issuanceValidator checks batch items against the same rules as /credmint,
+// caching catalog lookups across rows.
+type issuanceValidator struct {
+	org             models.Organization
+	requireTemplate bool
+	templates       map[uint]*models.CredentialTemplate
+	programs        *programMatcher
+	seen            map[string]int
+}
+
+func newIssuanceValidator(org models.Organization) (*issuanceValidator, error) {
+	required, err := orgRequiresTemplate(org.ID)
+	if err != nil {
+		return nil, err
+	}
+	matcher, err := orgProgramMatcher(org.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &issuanceValidator{
+		org:             org,
+		requireTemplate: required,
+		templates:       map[uint]*models.CredentialTemplate{},
+		programs:        matcher,
+		seen:            map[string]int{},
+	}, nil
+}
+
+func (v *issuanceValidator) template(id uint) (*models.CredentialTemplate, error) {
+	if t, ok := v.templates[id]; ok {
+		return t, nil
+	}
+	t, err := orgTemplate(v.org.ID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		v.templates[id] = nil
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	v.templates[id] = &t
+	return &t, nil
+}
+
+// validate turns a request row into a job item, or lists why it cannot be
+// issued.
+func (v *issuanceValidator) validate(row int, in issuanceItemReq) (models.IssuanceJobItem, []string, error) {
+	var problems []string
+	item := models.IssuanceJobItem{
+		Row:            row,
+		Status:         models.IssuanceItemPending,
+		StudentWallet:  strings.TrimSpace(in.StudentWallet),
+		DegreeName:     strings.TrimSpace(in.DegreeName),
+		Type:           strings.TrimSpace(in.Type),
+		Major:          strings.TrimSpace(in.Major),
+		GraduationDate: strings.TrimSpace(in.GraduationDate),
+		Description:    strings.TrimSpace(in.Description),
+		DeanSig:        strings.TrimSpace(in.DeanSig),
+		Values:         in.Values,
+	}
+
+	if !common.IsHexAddress(item.StudentWallet) {
+		problems = append(problems, "student_wallet must be a wallet address")
+	}
+	if item.DeanSig == "" {
+		problems = append(problems, "dean_sig is required")
+	}
+	issued, err := parseIssuedDate(in.IssuedDate)
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	item.IssuedDate = issued
+
+	var tpl *models.CredentialTemplate
+	switch {
+	case in.TemplateID != nil:
+		t, err := v.template(*in.TemplateID)
+		if err != nil {
+			return item, nil, err
+		}
+		if t == nil || !t.Active {
+			problems = append(problems, fmt.Sprintf("template %d not found or inactive", *in.TemplateID))
+		}
+		tpl = t
+	case v.requireTemplate:
+		problems = append(problems, "template_id is required: organization issues from credential templates")
+	}
+	if tpl != nil {
+		item.TemplateID = &tpl.ID
+		if item.Type == "" {
+			item.Type = tpl.CredentialType
+		}
+		if item.DegreeName == "" {
+			item.DegreeName = tpl.DegreeName
+		}
+		if item.Description == "" {
+			item.Description = tpl.DefaultDescription
+		}
+	}
+
+	var program *models.Program
+	if in.DegreeID != nil {
+		var p models.Program
+		if err := db.DB.Where("id = ? AND organization_id = ?", *in.DegreeID, v.org.ID).First(&p).Error; err != nil {
+			problems = append(problems, fmt.Sprintf("degree_id %d not found in organization catalog", *in.DegreeID))
+		} else {
+			program = &p
+			if item.DegreeName == "" {
+				item.DegreeName = p.Name
+			}
+		}
+	} else {
+		program = v.programs.Match(item.DegreeName)
+	}
+	if program != nil {
+		if !offersMajor(program, item.Major) {
+			problems = append(problems, fmt.Sprintf("major %q is not offered by program %q", item.Major, program.Name))
+		}
+		item.DegreeID = &program.ID
+	}
+
+	if item.DegreeName == "" {
+		problems = append(problems, "degree_name is required")
+	}
+	if item.Type == "" {
+		problems = append(problems, "type is required")
+	}
+
+	if common.IsHexAddress(item.StudentWallet) {
+		var user models.Users
+		err := db.DB.Where("LOWER(metamask_address) = LOWER(?)", item.StudentWallet).First(&user).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			problems = append(problems, "student not registered")
+		case err != nil:
+			return item, nil, err
+		default:
+			item.StudentWallet = user.MetamaskAddress
+			item.UserID = user.ID
+			approved, err := hasApprovedRequest(user.ID, v.org.ID)
+			if err != nil {
+				return item, nil, err
+			}
+			if !approved {
+				problems = append(problems, "student has no approved request with this organization")
+			}
+		}
+	}
+
+	key := strings.ToLower(item.StudentWallet + "|" + item.DegreeName + "|" + item.Type)
+	if prev, dup := v.seen[key]; dup {
+		problems = append(problems, fmt.Sprintf("duplicate of row %d", prev))
+	} else {
+		v.seen[key] = row
+	}
+
+	if len(problems) == 0 {
+		signatory, err := issuingSignatory(v.org.ID, models.Credential{
+			StudentWallet:    item.StudentWallet,
+			UniversityWallet: v.org.MetamaskAddress,
+			DegreeName:       item.DegreeName,
+			Type:             item.Type,
+			Major:            item.Major,
+			IssuedDate:       item.IssuedDate,
+			GraduationDate:   item.GraduationDate,
+			DeanSig:          item.DeanSig,
+		})
+		switch {
+		case isSignatureError(err):
+			problems = append(problems, "dean_sig: "+err.Error())
+		case err != nil:
+			return item, nil, err
+		default:
+			item.SignatoryID = &signatory.ID
+		}
+	}
+	if len(problems) == 0 {
+		_, mdProblems := jobs.BuildItemMetadata(v.org, tpl, item)
+		problems = append(problems, mdProblems...)
+	}
+	return item, problems, nil
+}
+
+// POST /api/v1/issuance/jobs (protected, verified org)
+// JSON body { template_id?, items: [{ student_wallet, degree_name, type, major,
+// issued_date, graduation_date, description, degree_id?, template_id?, values,
+// dean_sig }] }, or CSV (text/csv body or multipart field "file") with those
+// column names; extra columns become template values. Every row is validated
+// up front and the job is only created when all rows pass.
+func CreateIssuanceJob(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+
+	var req createIssuanceJobReq
+	ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch ct {
+	case "text/csv":
+		items, err := parseIssuanceCSV(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.Items = items
+	case "multipart/form-data":
+		if err := r.ParseMultipartForm(10 << 20); err != nil {
+			http.Error(w, "failed to parse form", http.StatusBadRequest)
+			return
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "CSV file field 'file' is required", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		items, err := parseIssuanceCSV(file)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.Items = items
+		if v := r.FormValue("template_id"); v != "" {
+			n, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				http.Error(w, "invalid template_id", http.StatusBadRequest)
+				return
+			}
+			id := uint(n)
+			req.TemplateID = &id
+		}
+	default:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if len(req.Items) == 0 {
+		http.Error(w, "no items to issue", http.StatusBadRequest)
+		return
+	}
+	if max := maxIssuanceItems(); len(req.Items) > max {
+		http.Error(w, fmt.Sprintf("too many items: %d (max %d)", len(req.Items), max), http.StatusBadRequest)
+		return
+	}
+
+	v, err := newIssuanceValidator(org)
+	if err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	items := make([]models.IssuanceJobItem, 0, len(req.Items))
+	var invalid []rowProblems
+	for i, in := range req.Items {
+		if in.TemplateID == nil {
+			in.TemplateID = req.TemplateID
+		}
+		item, problems, err := v.validate(i+1, in)
+		if err != nil {
+			fmt.Println("Issuance validation error:", err)
+			http.Error(w, "database error", http.StatusInternalServerError)
+			return
+		}
+		if len(problems) > 0 {
+			invalid = append(invalid, rowProblems{Row: i + 1, Problems: problems})
+			continue
+		}
+		items = append(items, item)
+	}
+	if len(invalid) > 0 {
+		writeJSONResp(w, http.StatusUnprocessableEntity, map[string]any{
+			"error": "some rows cannot be issued",
+			"rows":  invalid,
+		})
+		return
+	}
+
+	job := models.IssuanceJob{
+		OrganizationID: org.ID,
+		CreatedBy:      org.MetamaskAddress,
+		Status:         models.IssuanceJobQueued,
+		Total:          len(items),
+	}
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&job).Error; err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].JobID = job.ID
+		}
+		return tx.CreateInBatches(&items, 200).Error
+	})
+	if err != nil {
+		fmt.Println("Failed to create issuance job:", err)
+		http.Error(w, "failed to create issuance job", http.StatusInternalServerError)
+		return
+	}
+	jobs.GetIssuer().Notify()
+
+	writeJSONResp(w, http.StatusAccepted, job)
+}
+
+// orgIssuanceJob loads job {id} owned by the organization.
+func orgIssuanceJob(w http.ResponseWriter, r *http.Request, orgID uint) (models.IssuanceJob, bool) {
+	var job models.IssuanceJob
+	if err := db.DB.Where("id = ? AND organization_id = ?", chi.URLParam(r, "id"), orgID).First(&job).Error; err != nil {
+		http.Error(w, "issuance job not found", http.StatusNotFound)
+		return job, false
+	}
+	return job, true
+}
+
+// GET /api/v1/issuance/jobs (protected, verified org)
+func ListIssuanceJobs(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	var list []models.IssuanceJob
+	if err := db.DB.Where("organization_id = ?", org.ID).Order("created_at DESC").Limit(100).Find(&list).Error; err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	writeJSONResp(w, http.StatusOK, list)
+}
+
+// GET /api/v1/issuance/jobs/{id} (protected, verified org)
+// Returns the job with its item counts per status.
+func GetIssuanceJob(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	job, ok := orgIssuanceJob(w, r, org.ID)
+	if !ok {
+		return
+	}
+	type count struct {
+		Status string
+		N      int
+	}
+	var counts []count
+	if err := db.DB.Model(&models.IssuanceJobItem{}).Select("status, COUNT(*) AS n").
+		Where("job_id = ?", job.ID).Group("status").Scan(&counts).Error; err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	byStatus := map[string]int{}
+	for _, c := range counts {
+		byStatus[c.Status] = c.N
+	}
+	writeJSONResp(w, http.StatusOK, map[string]any{
+		"job":       job,
+		"by_status": byStatus,
+		"progress":  float64(job.Completed+job.Failed) / float64(max(job.Total, 1)),
+	})
+}
+
+// GET /api/v1/issuance/jobs/{id}/items?status= (protected, verified org)
+func ListIssuanceJobItems(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	job, ok := orgIssuanceJob(w, r, org.ID)
+	if !ok {
+		return
+	}
+	q := db.DB.Where("job_id = ?", job.ID)
+	if s := r.URL.Query().Get("status"); s != "" {
+		q = q.Where("status = ?", s)
+	}
+	var items []models.IssuanceJobItem
+	if err := q.Order("row").Find(&items).Error; err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	writeJSONResp(w, http.StatusOK, items)
+}
+
+// POST /api/v1/issuance/jobs/{id}/retry (protected, verified org)
+// Requeues failed items from the last step they completed, and resumes a
+// cancelled job's remaining items.
+func RetryIssuanceJob(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	job, ok := orgIssuanceJob(w, r, org.ID)
+	if !ok {
+		return
+	}
+
+	var retried int64
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		failed := tx.Model(&models.IssuanceJobItem{}).Where("job_id = ? AND status = ?", job.ID, models.IssuanceItemFailed)
+		steps := []struct {
+			where  string
+			status string
+		}{
+			{"mint_tx_hash <> ''", models.IssuanceItemSubmitted},
+			{"mint_tx_hash = '' AND ipfs_link <> ''", models.IssuanceItemPinned},
+			{"mint_tx_hash = '' AND ipfs_link = ''", models.IssuanceItemPending},
+		}
+		for _, s := range steps {
+			res := failed.Session(&gorm.Session{}).Where(s.where).Update("status", s.status)
+			if res.Error != nil {
+				return res.Error
+			}
+			retried += res.RowsAffected
+		}
+		var unfinished int64
+		if err := tx.Model(&models.IssuanceJobItem{}).Where("job_id = ? AND status IN ?", job.ID, []string{
+			models.IssuanceItemPending, models.IssuanceItemPinned, models.IssuanceItemSubmitted,
+		}).Count(&unfinished).Error; err != nil {
+			return err
+		}
+		if unfinished == 0 {
+			return nil
+		}
+		retried = unfinished
+		return tx.Model(&job).Updates(map[string]any{"status": models.IssuanceJobQueued, "finished_at": nil, "failed": 0}).Error
+	})
+	if err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	if retried > 0 {
+		jobs.GetIssuer().Notify()
+	}
+	writeJSONResp(w, http.StatusOK, map[string]any{"job_id": job.ID, "requeued": retried})
+}
+
+// POST /api/v1/issuance/jobs/{id}/cancel (protected, verified org)
+// Stops the job before its next item; items already minted are kept.
+func CancelIssuanceJob(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	job, ok := orgIssuanceJob(w, r, org.ID)
+	if !ok {
+		return
+	}
+	if job.Status != models.IssuanceJobQueued && job.Status != models.IssuanceJobRunning {
+		http.Error(w, "job is not queued or running", http.StatusConflict)
+		return
+	}
+	if err := db.DB.Model(&job).Updates(map[string]any{"status": models.IssuanceJobCancelled, "finished_at": time.Now().UTC()}).Error; err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	writeJSONResp(w, http.StatusOK, job)
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"vericred/internal/db"
+	"vericred/internal/eip712"
+	"vericred/internal/eth/ipfs"
+	"vericred/internal/middleware"
+	"vericred/internal/models"
+)
+
+// requireIssuer resolves the authenticated wallet to a verified organization.
+// On failure it writes the HTTP error and returns false.
+func requireIssuer(w http.ResponseWriter, r *http.Request) (models.Organization, bool) {
+	var org models.Organization
+	addr, ok := r.Context().Value(middleware.MetamaskAddressKey).(string)
+	if !ok || addr == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return org, false
+	}
+	if err := db.DB.Where("LOWER(metamask_address) = LOWER(?)", addr).First(&org).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "forbidden: wallet is not a registered organization", http.StatusForbidden)
+			return org, false
+		}
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return org, false
+	}
+	if !org.IsVerified {
+		http.Error(w, "forbidden: organization is not verified", http.StatusForbidden)
+		return org, false
+	}
+	return org, true
+}
+
+// hasApprovedRequest reports whether the student has an approved pending
+// request with the organization, which is required before issuance.
+func hasApprovedRequest(userID, orgID uint) (bool, error) {
+	var n int64
+	err := db.DB.Model(&models.PendingRequest{}).
+		Where("requester_id = ? AND organization_id = ? AND is_approved = ?", userID, orgID, true).
+		Count(&n).Error
+	return n > 0, err
+}
+
+// metadataMismatches lists the submitted fields the pinned metadata md
+// contradicts. Issuer and recipient wallets and the degree name must be
+// present in the metadata; other fields are compared when the metadata
+// carries them.
+func metadataMismatches(md *ipfs.Metadata, cred models.Credential) []string {
+	var out []string
+	check := func(field, want string, required bool, traits ...string) {
+		got, ok := md.Trait(traits...)
+		if !ok {
+			if required {
+				out = append(out, fmt.Sprintf("%s missing from metadata (expected attribute %q)", field, traits[0]))
+			}
+			return
+		}
+		if !strings.EqualFold(strings.TrimSpace(got), strings.TrimSpace(want)) {
+			out = append(out, fmt.Sprintf("%s does not match metadata (%q != %q)", field, want, got))
+		}
+	}
+
+	check("student_wallet", cred.StudentWallet, true, ipfs.TraitRecipientWallet)
+	check("university_wallet", cred.UniversityWallet, true, ipfs.TraitIssuerWallet)
+	check("type", cred.Type, false, ipfs.TraitCredentialType)
+	check("major", cred.Major, false, ipfs.TraitMajor)
+	check("graduation_date", cred.GraduationDate, false, ipfs.TraitGraduationDate)
+
+	degree, ok := md.Trait(ipfs.TraitDegreeName, "Degree")
+	if !ok {
+		degree = md.Name
+	}
+	if !strings.EqualFold(strings.TrimSpace(degree), strings.TrimSpace(cred.DegreeName)) {
+		out = append(out, fmt.Sprintf("degree_name does not match metadata (%q != %q)", cred.DegreeName, degree))
+	}
+
+	if issued, ok := md.Trait(ipfs.TraitIssueDate, "Issued Date"); ok {
+		if !sameDay(issued, cred.IssuedDate) {
+			out = append(out, fmt.Sprintf("issued_date does not match metadata (%q != %q)", cred.IssuedDate.Format("2006-01-02"), issued))
+		}
+	}
+	// The canonical hash binds the metadata to exactly these fields. Metadata
+	// pinned before the hash left out the domain carries the older form.
+	if got, ok := md.Trait(ipfs.TraitCredentialHash); !ok {
+		out = append(out, fmt.Sprintf("canonical_hash missing from metadata (expected attribute %q)", ipfs.TraitCredentialHash))
+	} else if !eip712.CredentialHashMatches(eip712.FieldsOf(cred), got) {
+		out = append(out, fmt.Sprintf("canonical_hash does not match metadata (%q != %q)", cred.CanonicalHash, got))
+	}
+
+	// A dean signature hash in the metadata must be the submitted dean_sig's
+	for _, f := range md.CustomFields {
+		if f.DeanSignatureHash != "" && !strings.EqualFold(f.DeanSignatureHash, ipfs.DeanSignatureHash(cred.DeanSig)) {
+			out = append(out, "dean_sig does not match metadata dean_signature_hash")
+		}
+	}
+	return out
+}
+
+// sameDay compares a metadata date string with t at day precision.
+func sameDay(s string, t time.Time) bool {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC3339, "2006-01-02", "2006-01-02 15:04:05"} {
+		if d, err := time.Parse(layout, s); err == nil {
+			return d.UTC().Format("2006-01-02") == t.UTC().Format("2006-01-02")
+		}
+	}
+	return false
+}
+
+// issuedCredential loads credential id and checks that org issued it.
+// On failure it writes the HTTP error and returns false.
+func issuedCredential(w http.ResponseWriter, org models.Organization, id string) (models.Credential, bool) {
+	var cred models.Credential
+	if err := db.DB.Preload("Organization").Where("id = ?", id).First(&cred).Error; err != nil {
+		http.Error(w, "credential not found", http.StatusNotFound)
+		return cred, false
+	}
+	if cred.OrganizationID != org.ID {
+		http.Error(w, "forbidden: credential was issued by another organization", http.StatusForbidden)
+		return cred, false
+	}
+	return cred, true
+}
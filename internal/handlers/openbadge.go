@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+
+	"vericred/internal/db"
+	"vericred/internal/models"
+	"vericred/internal/vc"
+)
+
+// renderOpenBadge builds the badge for cred, attaching the stored proof if any.
+func renderOpenBadge(cred models.Credential) vc.OpenBadge {
+	badge := vc.OpenBadgeFromCredential(cred)
+	if cred.OBProof != "" {
+		var p vc.Proof
+		if err := json.Unmarshal([]byte(cred.OBProof), &p); err == nil {
+			badge.Proof = &p
+		}
+	}
+	return badge
+}
+
+// GET /api/v1/credentials/{id}/openbadge
+// Holder or issuer session, or ?token= share token.
+func ExportOpenBadge(w http.ResponseWriter, r *http.Request) {
+	var cred models.Credential
+	if err := db.DB.Preload("Organization").Where("id = ?", chi.URLParam(r, "id")).First(&cred).Error; err != nil {
+		http.Error(w, "credential not found", http.StatusNotFound)
+		return
+	}
+	if !canReadCredential(r, cred.ID, cred.StudentWallet, cred.UniversityWallet) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	if cred.Source == models.CredentialSourceOpenBadges && cred.SourceDocument != "" {
+		// Imported badges are returned exactly as received so their proof stays valid
+		w.Header().Set("Content-Type", "application/vc+ld+json")
+		_, _ = io.WriteString(w, cred.SourceDocument)
+		return
+	}
+	w.Header().Set("Content-Type", "application/vc+ld+json")
+	_ = json.NewEncoder(w).Encode(renderOpenBadge(cred))
+}
+
+// GET /api/v1/credentials/{id}/openbadge/signing-payload (protected, issuing org)
+func OpenBadgeSigningPayload(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	cred, ok := issuedCredential(w, org, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+	writeJSONResp(w, http.StatusOK, vc.OpenBadgeTypedData(vc.OpenBadgeFromCredential(cred)))
+}
+
+// POST /api/v1/credentials/{id}/openbadge/proof (protected, issuing org)
+// Body: { signature }
+func AttachOpenBadgeProof(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	cred, ok := issuedCredential(w, org, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Signature string `json:"signature"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || strings.TrimSpace(body.Signature) == "" {
+		http.Error(w, "signature is required", http.StatusBadRequest)
+		return
+	}
+
+	badge := vc.OpenBadgeFromCredential(cred)
+	badge.Proof = vc.NewOpenBadgeProof(badge, strings.TrimSpace(body.Signature), time.Now())
+	if _, err := vc.VerifyOpenBadgeSignature(badge); err != nil {
+		http.Error(w, "invalid signature: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	proof, err := json.Marshal(badge.Proof)
+	if err != nil {
+		http.Error(w, "failed to encode proof", http.StatusInternalServerError)
+		return
+	}
+	if err := db.DB.Model(&models.Credential{}).Where("id = ?", cred.ID).Update("ob_proof", string(proof)).Error; err != nil {
+		http.Error(w, "failed to store proof", http.StatusInternalServerError)
+		return
+	}
+	writeJSONResp(w, http.StatusOK, badge)
+}
+
+// POST /api/v1/openbadges/import?student_wallet=0x... (protected, verified org)
+// Body: an Open Badges 3.0 OpenBadgeCredential. The badge issuer must be the
+// authenticated organization, identified by did:ethr or by its website host.
+// student_wallet is only needed when the subject is not a did:ethr.
+func ImportOpenBadge(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+	var badge vc.OpenBadge
+	if err := json.Unmarshal(raw, &badge); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	if errs := vc.ValidateOpenBadge(badge, time.Now()); len(errs) > 0 {
+		writeJSONResp(w, http.StatusUnprocessableEntity, map[string]any{"error": "invalid Open Badge", "details": errs})
+		return
+	}
+
+	if !badgeIssuedBy(badge, org) {
+		http.Error(w, "forbidden: badge issuer does not match the authenticated organization", http.StatusForbidden)
+		return
+	}
+
+	proofVerified := false
+	if err := vc.CheckOpenBadgeProof(badge); err != nil {
+		if !errors.Is(err, vc.ErrUnsupportedProof) {
+			http.Error(w, "invalid badge proof: "+err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+	} else {
+		proofVerified = badge.Proof != nil
+	}
+
+	studentWallet := r.URL.Query().Get("student_wallet")
+	if addr, err := vc.AddressFromDID(badge.CredentialSubject.ID); err == nil {
+		studentWallet = addr.Hex()
+	}
+	if studentWallet == "" {
+		http.Error(w, "credentialSubject.id is not a did:ethr; pass ?student_wallet=", http.StatusBadRequest)
+		return
+	}
+	var user models.Users
+	if err := db.DB.Where("LOWER(metamask_address) = LOWER(?)", studentWallet).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "student not registered", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+
+	var existing models.Credential
+	err = db.DB.Where("source = ? AND external_id = ? AND organization_id = ?", models.CredentialSourceOpenBadges, badge.ID, org.ID).First(&existing).Error
+	if err == nil {
+		writeJSONResp(w, http.StatusConflict, map[string]any{"error": "badge already imported", "credential": existing})
+		return
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+
+	issued, _ := badge.IssuedAt()
+	a := badge.CredentialSubject.Achievement
+	credType := a.AchievementType
+	if credType == "" {
+		credType = "Badge"
+	}
+	cred := models.Credential{
+		StudentWallet:    user.MetamaskAddress,
+		UniversityWallet: org.MetamaskAddress,
+		DegreeName:       a.Name,
+		Description:      a.Description,
+		Type:             credType,
+		Major:            a.FieldOfStudy,
+		IssuedDate:       issued.UTC(),
+		IPFSLink:         "",
+		DeanSig:          "",
+		Source:           models.CredentialSourceOpenBadges,
+		ExternalID:       badge.ID,
+		SourceDocument:   string(raw),
+		UserID:           user.ID,
+		OrganizationID:   org.ID,
+	}
+	if err := db.DB.Create(&cred).Error; err != nil {
+		fmt.Println("Failed to import badge:", err)
+		http.Error(w, "failed to store credential", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSONResp(w, http.StatusCreated, map[string]any{
+		"credential":     cred,
+		"proof_verified": proofVerified,
+	})
+}
+
+// badgeIssuedBy matches the badge issuer to org by wallet DID or website host.
+func badgeIssuedBy(b vc.OpenBadge, org models.Organization) bool {
+	if addr, err := vc.AddressFromDID(b.Issuer.ID); err == nil {
+		return equalCaseInsensitive(addr.Hex(), org.MetamaskAddress)
+	}
+	host := b.IssuerHost()
+	if host == "" || org.OrgUrl == "" {
+		return false
+	}
+	orgURL := org.OrgUrl
+	if !strings.Contains(orgURL, "://") {
+		orgURL = "https://" + orgURL
+	}
+	u, err := url.Parse(orgURL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(strings.TrimPrefix(u.Host, "www."), host)
+}
@@ -0,0 +1,388 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"vericred/internal/db"
+	"vericred/internal/models"
+)
+
+const (
+	defaultCredentialPage = 50
+	maxCredentialPage     = 200
+	exportBatchSize       = 500
+)
+
+var errInvalidCursor = errors.New("invalid cursor for this sort")
+
+// uuidPattern matches the canonical textual form of a credential id.
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// credentialSortColumns maps the accepted sort keys to their columns. Every
+// sort is tie-broken on id so the keyset cursor is stable.
+var credentialSortColumns = map[string]string{
+	"issued_date": "credentials.issued_date",
+	"created_at":  "credentials.created_at",
+	"degree_name": "credentials.degree_name",
+}
+
+// credentialQuery is a parsed org credential search.
+type credentialQuery struct {
+	orgID      uint
+	credType   string
+	degree     string
+	degreeID   uint
+	major      string
+	issuedFrom *time.Time
+	issuedTo   *time.Time
+	student    string
+	status     string
+	sortKey    string
+	desc       bool
+	limit      int
+	cursor     *credentialCursor
+}
+
+// credentialCursor is the opaque keyset position handed back to clients:
+// the sort value and id of the last row on the previous page.
+type credentialCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+func encodeCredentialCursor(c credentialCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCredentialCursor(s string) (*credentialCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var c credentialCursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	if !uuidPattern.MatchString(c.ID) {
+		return nil, errors.New("cursor id is not a credential id")
+	}
+	return &c, nil
+}
+
+// parseCredentialQuery reads the search filters shared by the listing and
+// the CSV export.
+func parseCredentialQuery(r *http.Request, orgID uint) (credentialQuery, error) {
+	v := r.URL.Query()
+	q := credentialQuery{
+		orgID:    orgID,
+		credType: strings.TrimSpace(v.Get("type")),
+		degree:   strings.TrimSpace(v.Get("degree")),
+		major:    strings.TrimSpace(v.Get("major")),
+		student:  strings.TrimSpace(v.Get("student")),
+		status:   strings.TrimSpace(v.Get("status")),
+		limit:    defaultCredentialPage,
+	}
+	if s := v.Get("degree_id"); s != "" {
+		id, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return q, errors.New("degree_id must be a number")
+		}
+		q.degreeID = uint(id)
+	}
+	switch q.status {
+	case "", models.CredentialStatusActive, models.CredentialStatusRevoked, models.CredentialStatusSuperseded:
+	default:
+		return q, errors.New("status must be active, revoked or superseded")
+	}
+	if s := v.Get("issued_from"); s != "" {
+		t, err := parseIssuedDate(s)
+		if err != nil {
+			return q, errors.New("issued_from must be a date (YYYY-MM-DD or RFC3339)")
+		}
+		q.issuedFrom = &t
+	}
+	if s := v.Get("issued_to"); s != "" {
+		t, err := parseIssuedDate(s)
+		if err != nil {
+			return q, errors.New("issued_to must be a date (YYYY-MM-DD or RFC3339)")
+		}
+		// A bare date includes the whole day.
+		if len(strings.TrimSpace(s)) == len("2006-01-02") {
+			t = t.Add(24*time.Hour - time.Nanosecond)
+		}
+		q.issuedTo = &t
+	}
+	if q.issuedFrom != nil && q.issuedTo != nil && q.issuedTo.Before(*q.issuedFrom) {
+		return q, errors.New("issued_to is before issued_from")
+	}
+
+	sort := v.Get("sort")
+	if sort == "" {
+		sort = "-issued_date"
+	}
+	if strings.HasPrefix(sort, "-") {
+		q.desc = true
+		sort = sort[1:]
+	}
+	if _, ok := credentialSortColumns[sort]; !ok {
+		return q, errors.New("sort must be one of issued_date, created_at, degree_name (prefix - for descending)")
+	}
+	q.sortKey = sort
+
+	if s := v.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 || n > maxCredentialPage {
+			return q, fmt.Errorf("limit must be between 1 and %d", maxCredentialPage)
+		}
+		q.limit = n
+	}
+	if s := v.Get("cursor"); s != "" {
+		c, err := decodeCredentialCursor(s)
+		if err != nil || c.Sort != q.sortKey {
+			return q, errInvalidCursor
+		}
+		q.cursor = c
+	}
+	return q, nil
+}
+
+// filter applies the search filters to a credentials query.
+func (q credentialQuery) filter(tx *gorm.DB) *gorm.DB {
+	tx = tx.Model(&models.Credential{}).
+		Joins("LEFT JOIN users ON users.id = credentials.user_id").
+		Where("credentials.organization_id = ?", q.orgID)
+	if q.credType != "" {
+		tx = tx.Where("LOWER(credentials.type) = LOWER(?)", q.credType)
+	}
+	if q.degreeID != 0 {
+		tx = tx.Where("credentials.degree_id = ?", q.degreeID)
+	}
+	if q.degree != "" {
+		tx = tx.Where("credentials.degree_name ILIKE ?", "%"+escapeLike(q.degree)+"%")
+	}
+	if q.major != "" {
+		tx = tx.Where("credentials.major ILIKE ?", "%"+escapeLike(q.major)+"%")
+	}
+	if q.issuedFrom != nil {
+		tx = tx.Where("credentials.issued_date >= ?", *q.issuedFrom)
+	}
+	if q.issuedTo != nil {
+		tx = tx.Where("credentials.issued_date <= ?", *q.issuedTo)
+	}
+	if q.status != "" {
+		tx = tx.Where("credentials.status = ?", q.status)
+	}
+	if q.student != "" {
+		if strings.HasPrefix(q.student, "0x") && len(q.student) == 42 {
+			tx = tx.Where("LOWER(credentials.student_wallet) = LOWER(?)", q.student)
+		} else {
+			like := "%" + escapeLike(q.student) + "%"
+			tx = tx.Where("((users.first_name || ' ' || users.last_name) ILIKE ? OR users.email ILIKE ? OR credentials.student_wallet ILIKE ?)",
+				like, like, like)
+		}
+	}
+	return tx
+}
+
+// ordered applies the sort and the cursor position on top of the filters.
+func (q credentialQuery) ordered(tx *gorm.DB) (*gorm.DB, error) {
+	tx = q.filter(tx)
+	col := credentialSortColumns[q.sortKey]
+	dir, cmp := "ASC", ">"
+	if q.desc {
+		dir, cmp = "DESC", "<"
+	}
+	if q.cursor != nil {
+		val, err := q.cursorValue()
+		if err != nil {
+			return nil, err
+		}
+		tx = tx.Where(fmt.Sprintf("(%s, credentials.id) %s (?, ?)", col, cmp), val, q.cursor.ID)
+	}
+	return tx.Order(col + " " + dir).Order("credentials.id " + dir), nil
+}
+
+func (q credentialQuery) cursorValue() (any, error) {
+	if q.sortKey == "degree_name" {
+		return q.cursor.Value, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, q.cursor.Value)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	return t, nil
+}
+
+func (q credentialQuery) cursorAfter(c models.Credential) credentialCursor {
+	cur := credentialCursor{Sort: q.sortKey, ID: c.ID}
+	switch q.sortKey {
+	case "issued_date":
+		cur.Value = c.IssuedDate.UTC().Format(time.RFC3339Nano)
+	case "created_at":
+		cur.Value = c.CreatedAt.UTC().Format(time.RFC3339Nano)
+	default:
+		cur.Value = c.DegreeName
+	}
+	return cur
+}
+
+// page loads up to limit rows after the query's cursor and reports the
+// cursor for the next page, empty when there is none.
+func (q credentialQuery) page(limit int) ([]models.Credential, string, error) {
+	tx, err := q.ordered(db.DB)
+	if err != nil {
+		return nil, "", err
+	}
+	var creds []models.Credential
+	if err := tx.Select("credentials.*").Preload("User").Limit(limit + 1).Find(&creds).Error; err != nil {
+		return nil, "", err
+	}
+	next := ""
+	if len(creds) > limit {
+		creds = creds[:limit]
+		next = encodeCredentialCursor(q.cursorAfter(creds[limit-1]))
+	}
+	return creds, next, nil
+}
+
+// escapeLike escapes LIKE wildcards in user input.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func studentName(u models.Users) string {
+	return strings.TrimSpace(u.FirstName + " " + u.LastName)
+}
+
+// GET /api/v1/org/credentials (protected, verified org)
+// Query: type, degree, degree_id, major, issued_from, issued_to, student
+// (name, email or wallet), status, sort (issued_date|created_at|degree_name,
+// "-" prefix for descending; default -issued_date), limit, cursor.
+func ListOrgCredentials(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	q, err := parseCredentialQuery(r, org.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	creds, next, err := q.page(q.limit)
+	if err != nil {
+		if errors.Is(err, errInvalidCursor) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+
+	items := make([]map[string]any, 0, len(creds))
+	for _, c := range creds {
+		items = append(items, map[string]any{
+			"id":              c.ID,
+			"student_name":    studentName(c.User),
+			"student_email":   c.User.Email,
+			"student_wallet":  c.StudentWallet,
+			"degree_name":     c.DegreeName,
+			"degree_id":       c.DegreeID,
+			"type":            c.Type,
+			"major":           c.Major,
+			"issued_date":     c.IssuedDate,
+			"graduation_date": c.GraduationDate,
+			"status":          c.Status,
+			"token_id":        c.TokenID,
+			"source":          c.Source,
+			"created_at":      c.CreatedAt,
+		})
+	}
+	resp := map[string]any{
+		"items":       items,
+		"next_cursor": next,
+	}
+	if q.cursor == nil {
+		// Only the first page pays for the total.
+		if total, err := q.count(); err == nil {
+			resp["total"] = total
+		}
+	}
+	writeJSONResp(w, http.StatusOK, resp)
+}
+
+// count reports how many rows match the filters, ignoring the cursor.
+func (q credentialQuery) count() (int64, error) {
+	var n int64
+	err := q.filter(db.DB).Count(&n).Error
+	return n, err
+}
+
+// GET /api/v1/org/credentials/export.csv (protected, verified org)
+// Same filters and sort as the listing; streams every matching row.
+func ExportOrgCredentials(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	q, err := parseCredentialQuery(r, org.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="credentials-%s.csv"`, time.Now().UTC().Format("20060102")))
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{
+		"id", "student_name", "student_email", "student_wallet", "degree_name", "type", "major",
+		"issued_date", "graduation_date", "status", "token_id", "canonical_hash", "source", "created_at",
+	})
+	for {
+		creds, next, err := q.page(exportBatchSize)
+		if err != nil {
+			// Headers are gone; all we can do is stop the stream.
+			fmt.Println("credential export failed:", err)
+			break
+		}
+		for _, c := range creds {
+			row := []string{
+				c.ID, studentName(c.User), c.User.Email, c.StudentWallet, c.DegreeName, c.Type, c.Major,
+				c.IssuedDate.UTC().Format("2006-01-02"), c.GraduationDate, c.Status, c.TokenID,
+				c.CanonicalHash, c.Source, c.CreatedAt.UTC().Format(time.RFC3339),
+			}
+			for i := range row {
+				row[i] = csvSafe(row[i])
+			}
+			_ = cw.Write(row)
+		}
+		cw.Flush()
+		if next == "" {
+			break
+		}
+		q.cursor, _ = decodeCredentialCursor(next)
+	}
+	cw.Flush()
+}
+
+// csvSafe quotes a cell that a spreadsheet would otherwise evaluate as a
+// formula: names and degree titles come from users and CSV imports.
+func csvSafe(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"vericred/internal/db"
+	"vericred/internal/jobs"
+	"vericred/internal/models"
+)
+
+// GET /api/admin/pins?status=pinned|unpinned|missing&kind=metadata|asset&unattached=true&limit=100 (admin)
+// Lists recorded pins with totals per status.
+func ListPins(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 && v <= 500 {
+		limit = v
+	}
+	q := db.DB.Model(&models.Pin{})
+	switch status := r.URL.Query().Get("status"); status {
+	case "":
+	case models.PinStatusPinned, models.PinStatusUnpinned, models.PinStatusMissing:
+		q = q.Where("status = ?", status)
+	default:
+		http.Error(w, "status must be pinned, unpinned or missing", http.StatusBadRequest)
+		return
+	}
+	switch kind := r.URL.Query().Get("kind"); kind {
+	case "":
+	case models.PinKindMetadata, models.PinKindAsset:
+		q = q.Where("kind = ?", kind)
+	default:
+		http.Error(w, "kind must be metadata or asset", http.StatusBadRequest)
+		return
+	}
+	if r.URL.Query().Get("unattached") == "true" {
+		q = q.Where("credential_id IS NULL")
+	}
+	var pins []models.Pin
+	if err := q.Order("created_at DESC").Limit(limit).Find(&pins).Error; err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+
+	type statusTotal struct {
+		Status string
+		Count  int64
+		Bytes  int64
+	}
+	var totals []statusTotal
+	if err := db.DB.Model(&models.Pin{}).
+		Select("status, count(*) as count, coalesce(sum(size), 0) as bytes").
+		Group("status").
+		Scan(&totals).Error; err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	byStatus := map[string]map[string]int64{}
+	for _, t := range totals {
+		byStatus[t.Status] = map[string]int64{"count": t.Count, "bytes": t.Bytes}
+	}
+	writeJSONResp(w, http.StatusOK, map[string]any{
+		"totals": byStatus,
+		"pins":   pins,
+	})
+}
+
+// POST /api/admin/pins/run (admin)
+// Runs pin maintenance synchronously and returns its summary.
+func RunPinMaintenance(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
+	defer cancel()
+
+	sum, err := jobs.GetPinKeeper().RunOnce(ctx)
+	if errors.Is(err, jobs.ErrPinsRunning) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		writeJSONResp(w, http.StatusBadGateway, map[string]any{"error": err.Error(), "summary": sum})
+		return
+	}
+	writeJSONResp(w, http.StatusOK, sum)
+}
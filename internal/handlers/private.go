@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"vericred/internal/db"
+	"vericred/internal/envelope"
+	"vericred/internal/eth/ipfs"
+	"vericred/internal/middleware"
+	"vericred/internal/models"
+)
+
+var errEnvelopeUnmatched = errors.New("no private data envelope matches the metadata commitment")
+
+// accountPublicKey is the public key recovered from wallet's last login
+// signature.
+func accountPublicKey(wallet string) (*ecdsa.PublicKey, error) {
+	var acc models.Accounts
+	if err := db.DB.Where("LOWER(metamask_address) = LOWER(?)", wallet).First(&acc).Error; err != nil || acc.PublicKey == "" {
+		return nil, fmt.Errorf("no public key on file for %s; the wallet must sign in with MetaMask first", wallet)
+	}
+	return envelope.ParsePublicKey(acc.PublicKey)
+}
+
+// sealPrivateTraits moves the attributes named in private out of md,
+// encrypts them for the student and the issuing org and puts the commitment
+// in their place. Standard traits can't be made private: verification needs
+// them in the clear. The returned envelope is not saved yet.
+func sealPrivateTraits(org models.Organization, studentWallet string, md *ipfs.Credentials, private []string) (*models.PrivateEnvelope, []string) {
+	want := map[string]bool{}
+	var problems []string
+	for _, name := range private {
+		name = strings.TrimSpace(name)
+		if ipfs.IsStandardTrait(name) {
+			problems = append(problems, fmt.Sprintf("attribute %q cannot be private", name))
+			continue
+		}
+		want[strings.ToLower(name)] = true
+	}
+	var public, sealed []ipfs.Attribute
+	var traits []string
+	for _, a := range md.Attributes {
+		key := strings.ToLower(strings.TrimSpace(a.TraitType))
+		if want[key] {
+			sealed = append(sealed, a)
+			traits = append(traits, a.TraitType)
+			delete(want, key)
+			continue
+		}
+		public = append(public, a)
+	}
+	for name := range want {
+		problems = append(problems, fmt.Sprintf("private attribute %q is not in the metadata", name))
+	}
+	if len(problems) > 0 {
+		return nil, problems
+	}
+
+	readers := map[string]*ecdsa.PublicKey{}
+	for _, wallet := range []string{studentWallet, org.MetamaskAddress} {
+		pub, err := accountPublicKey(wallet)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		readers[wallet] = pub
+	}
+	if len(problems) > 0 {
+		return nil, problems
+	}
+	s, err := envelope.Seal(sealed, readers)
+	if err != nil {
+		return nil, []string{err.Error()}
+	}
+	md.Attributes = public
+	ipfs.SetTrait(md, ipfs.TraitPrivateCommitment, s.Commitment)
+	return &models.PrivateEnvelope{
+		OrganizationID: org.ID,
+		StudentWallet:  studentWallet,
+		Commitment:     s.Commitment,
+		Traits:         traits,
+		Nonce:          s.Nonce,
+		Ciphertext:     s.Ciphertext,
+		WrappedKeys:    s.WrappedKeys,
+	}, nil
+}
+
+// envelopeForMetadata finds the envelope whose commitment md carries, for
+// linking to the credential being recorded. It returns nil when md has no
+// private data.
+func envelopeForMetadata(orgID uint, studentWallet string, md *ipfs.Metadata, allowLinked string) (*models.PrivateEnvelope, error) {
+	commitment, ok := md.Trait(ipfs.TraitPrivateCommitment)
+	if !ok || commitment == "" {
+		return nil, nil
+	}
+	var env models.PrivateEnvelope
+	err := db.DB.Where("organization_id = ? AND commitment = ? AND LOWER(student_wallet) = LOWER(?)", orgID, commitment, studentWallet).
+		First(&env).Error
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errEnvelopeUnmatched, commitment)
+	}
+	if env.CredentialID != nil && *env.CredentialID != allowLinked {
+		return nil, fmt.Errorf("%w: %s is already linked", errEnvelopeUnmatched, commitment)
+	}
+	return &env, nil
+}
+
+// sealedTraits names the attributes env holds, which are missing from the
+// pinned metadata on purpose; nil when there is no envelope.
+func sealedTraits(env *models.PrivateEnvelope) []string {
+	if env == nil {
+		return nil
+	}
+	return env.Traits
+}
+
+// privateView is the private-data section of share-link responses, or nil
+// when the credential has none. With the content key the holder put in the
+// link, the attributes are decrypted and checked against the commitment;
+// without it only the commitment and trait names are shown.
+func privateView(cred models.Credential, contentKey string) map[string]any {
+	var env models.PrivateEnvelope
+	if err := db.DB.Where("credential_id = ?", cred.ID).First(&env).Error; err != nil {
+		return nil
+	}
+	out := map[string]any{
+		"commitment": env.Commitment,
+		"traits":     env.Traits,
+		"encrypted":  true,
+	}
+	if contentKey == "" {
+		return out
+	}
+	p, err := envelope.Open(env.Nonce, env.Ciphertext, env.Commitment, contentKey)
+	if err != nil {
+		out["error"] = err.Error()
+		return out
+	}
+	out["encrypted"] = false
+	out["attributes"] = p.Attributes
+	out["commitment_valid"] = true
+	return out
+}
+
+// GET /api/v1/credentials/{id}/private (protected, holder or issuer)
+// Returns the encrypted private attributes and the content key wrapped for
+// the caller's wallet. Decrypt the key with the wallet's private key, open
+// the ciphertext with it and check keccak256(plaintext) against commitment.
+func CredentialPrivateData(w http.ResponseWriter, r *http.Request) {
+	addr, _ := r.Context().Value(middleware.MetamaskAddressKey).(string)
+	var cred models.Credential
+	if err := db.DB.Where("id = ?", chi.URLParam(r, "id")).First(&cred).Error; err != nil {
+		http.Error(w, "credential not found", http.StatusNotFound)
+		return
+	}
+	if addr == "" || (!equalCaseInsensitive(addr, cred.StudentWallet) && !equalCaseInsensitive(addr, cred.UniversityWallet)) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	var env models.PrivateEnvelope
+	if err := db.DB.Where("credential_id = ?", cred.ID).First(&env).Error; err != nil {
+		http.Error(w, "credential has no private data", http.StatusNotFound)
+		return
+	}
+	wrapped, ok := env.WrappedKeys[strings.ToLower(addr)]
+	if !ok {
+		http.Error(w, "no key was wrapped for this wallet", http.StatusForbidden)
+		return
+	}
+	writeJSONResp(w, http.StatusOK, map[string]any{
+		"credential_id": cred.ID,
+		"commitment":    env.Commitment,
+		"traits":        env.Traits,
+		"cipher":        envelope.Cipher,
+		"nonce":         env.Nonce,
+		"ciphertext":    env.Ciphertext,
+		"key_wrap":      envelope.KeyWrap,
+		"wrapped_key":   wrapped,
+	})
+}
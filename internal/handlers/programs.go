@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-chi/chi/v5"
+
+	"vericred/internal/db"
+	"vericred/internal/models"
+)
+
+// programKey normalizes a program name for matching: case, spacing and
+// punctuation are ignored, so "B.Tech", "B Tech" and "btech" collide.
+func programKey(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// programMatcher resolves free-text program names to an organization's
+// catalog entries by name or alias.
+type programMatcher struct {
+	byKey map[string]*models.Program
+}
+
+func newProgramMatcher(programs []models.Program) *programMatcher {
+	m := &programMatcher{byKey: map[string]*models.Program{}}
+	for i := range programs {
+		p := &programs[i]
+		for _, name := range append([]string{p.Name}, p.Aliases...) {
+			if k := programKey(name); k != "" {
+				m.byKey[k] = p
+			}
+		}
+	}
+	return m
+}
+
+// orgProgramMatcher loads the organization's catalog into a matcher.
+func orgProgramMatcher(orgID uint) (*programMatcher, error) {
+	var programs []models.Program
+	if err := db.DB.Where("organization_id = ?", orgID).Find(&programs).Error; err != nil {
+		return nil, err
+	}
+	return newProgramMatcher(programs), nil
+}
+
+// Match returns the catalog entry for name. "B.Tech in Computer Science" falls
+// back to matching "B.Tech" when the full string is unknown.
+func (m *programMatcher) Match(name string) *models.Program {
+	if p, ok := m.byKey[programKey(name)]; ok {
+		return p
+	}
+	// Slice the lowercased string the index came from: lowercasing can
+	// change byte lengths, and programKey lowercases anyway
+	lower := strings.ToLower(name)
+	if i := strings.Index(lower, " in "); i > 0 {
+		if p, ok := m.byKey[programKey(lower[:i])]; ok {
+			return p
+		}
+	}
+	return nil
+}
+
+// offersMajor reports whether major is listed for p. Programs without listed
+// majors accept any.
+func offersMajor(p *models.Program, major string) bool {
+	if len(p.Majors) == 0 || strings.TrimSpace(major) == "" {
+		return true
+	}
+	for _, m := range p.Majors {
+		if programKey(m) == programKey(major) {
+			return true
+		}
+	}
+	return false
+}
+
+// programProblems validates a catalog entry, normalizing its level.
+func programProblems(p *models.Program) []string {
+	var out []string
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		out = append(out, "name is required")
+	}
+	p.Level = strings.ToLower(strings.TrimSpace(p.Level))
+	switch p.Level {
+	case models.ProgramLevelCertificate, models.ProgramLevelDiploma, models.ProgramLevelBachelor,
+		models.ProgramLevelMaster, models.ProgramLevelDoctorate:
+	default:
+		out = append(out, "level must be one of certificate, diploma, bachelor, master, doctorate")
+	}
+	if p.DurationMonths < 0 {
+		out = append(out, "duration_months cannot be negative")
+	}
+	return out
+}
+
+// aliasConflicts lists names or aliases of p already claimed by another
+// program of the same organization.
+func aliasConflicts(p models.Program) ([]string, error) {
+	var others []models.Program
+	if err := db.DB.Where("organization_id = ? AND id <> ?", p.OrganizationID, p.ID).Find(&others).Error; err != nil {
+		return nil, err
+	}
+	m := newProgramMatcher(others)
+	var out []string
+	for _, name := range append([]string{p.Name}, p.Aliases...) {
+		if other, ok := m.byKey[programKey(name)]; ok {
+			out = append(out, fmt.Sprintf("%q already resolves to program %q", name, other.Name))
+		}
+	}
+	return out, nil
+}
+
+// linkProgramRecords points the organization's unlinked credentials and
+// legacy records whose free-text program resolves to p at it.
+func linkProgramRecords(p models.Program) (credentials, legacy int64, err error) {
+	m := newProgramMatcher([]models.Program{p})
+
+	var creds []models.Credential
+	if err = db.DB.Select("id", "degree_name").
+		Where("organization_id = ? AND degree_id IS NULL", p.OrganizationID).
+		Find(&creds).Error; err != nil {
+		return
+	}
+	var credIDs []string
+	for _, c := range creds {
+		if m.Match(c.DegreeName) != nil {
+			credIDs = append(credIDs, c.ID)
+		}
+	}
+	if len(credIDs) > 0 {
+		res := db.DB.Model(&models.Credential{}).Where("id IN ?", credIDs).Update("degree_id", p.ID)
+		if err = res.Error; err != nil {
+			return
+		}
+		credentials = res.RowsAffected
+	}
+
+	var rows []models.LegacyCredential
+	if err = db.DB.Select("id", "program").
+		Where("university_id = ? AND program_id IS NULL", p.OrganizationID).
+		Find(&rows).Error; err != nil {
+		return
+	}
+	var rowIDs []string
+	for _, lc := range rows {
+		if m.Match(lc.Program) != nil {
+			rowIDs = append(rowIDs, lc.ID)
+		}
+	}
+	if len(rowIDs) > 0 {
+		res := db.DB.Model(&models.LegacyCredential{}).Where("id IN ?", rowIDs).Update("program_id", p.ID)
+		if err = res.Error; err != nil {
+			return
+		}
+		legacy = res.RowsAffected
+	}
+	return
+}
+
+func orgProgram(w http.ResponseWriter, r *http.Request, orgID uint) (models.Program, bool) {
+	var p models.Program
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid program id", http.StatusBadRequest)
+		return p, false
+	}
+	if err := db.DB.Where("id = ? AND organization_id = ?", id, orgID).First(&p).Error; err != nil {
+		http.Error(w, "program not found", http.StatusNotFound)
+		return p, false
+	}
+	return p, true
+}
+
+type programWithCounts struct {
+	models.Program
+	Credentials       int64 `json:"credentials"`
+	LegacyCredentials int64 `json:"legacy_credentials"`
+}
+
+// GET /api/v1/org/programs (protected, verified org)
+// Lists the catalog with the number of credentials and legacy records
+// linked to each program.
+func ListPrograms(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	var programs []models.Program
+	if err := db.DB.Where("organization_id = ?", org.ID).Order("name").Find(&programs).Error; err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+
+	type count struct {
+		ID uint
+		N  int64
+	}
+	var credCounts, legacyCounts []count
+	if err := db.DB.Model(&models.Credential{}).Select("degree_id AS id, COUNT(*) AS n").
+		Where("organization_id = ? AND degree_id IS NOT NULL", org.ID).Group("degree_id").
+		Scan(&credCounts).Error; err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	if err := db.DB.Model(&models.LegacyCredential{}).Select("program_id AS id, COUNT(*) AS n").
+		Where("university_id = ? AND program_id IS NOT NULL", org.ID).Group("program_id").
+		Scan(&legacyCounts).Error; err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	byCred := map[uint]int64{}
+	for _, c := range credCounts {
+		byCred[c.ID] = c.N
+	}
+	byLegacy := map[uint]int64{}
+	for _, c := range legacyCounts {
+		byLegacy[c.ID] = c.N
+	}
+
+	out := make([]programWithCounts, 0, len(programs))
+	for _, p := range programs {
+		out = append(out, programWithCounts{Program: p, Credentials: byCred[p.ID], LegacyCredentials: byLegacy[p.ID]})
+	}
+	writeJSONResp(w, http.StatusOK, out)
+}
+
+// GET /api/v1/org/programs/{id} (protected, verified org)
+func GetProgram(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	p, ok := orgProgram(w, r, org.ID)
+	if !ok {
+		return
+	}
+	writeJSONResp(w, http.StatusOK, p)
+}
+
+// POST /api/v1/org/programs (protected, verified org)
+// Body: { name, level, duration_months, majors, aliases }
+// Existing credentials and legacy records matching the new entry are linked.
+func CreateProgram(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	var p models.Program
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	p.ID = 0
+	p.OrganizationID = org.ID
+	saveProgram(w, p, http.StatusCreated)
+}
+
+// PUT /api/v1/org/programs/{id} (protected, verified org)
+// Same body as create.
+func UpdateProgram(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	p, ok := orgProgram(w, r, org.ID)
+	if !ok {
+		return
+	}
+	var body models.Program
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	p.Name = body.Name
+	p.Level = body.Level
+	p.DurationMonths = body.DurationMonths
+	p.Majors = body.Majors
+	p.Aliases = body.Aliases
+	saveProgram(w, p, http.StatusOK)
+}
+
+func saveProgram(w http.ResponseWriter, p models.Program, status int) {
+	problems := programProblems(&p)
+	conflicts, err := aliasConflicts(p)
+	if err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	problems = append(problems, conflicts...)
+	if len(problems) > 0 {
+		writeJSONResp(w, http.StatusBadRequest, map[string]any{"error": "invalid program", "problems": problems})
+		return
+	}
+	if err := db.DB.Save(&p).Error; err != nil {
+		fmt.Println("Failed to save program:", err)
+		http.Error(w, "failed to save program", http.StatusInternalServerError)
+		return
+	}
+	creds, legacy, err := linkProgramRecords(p)
+	if err != nil {
+		fmt.Println("Failed to link records to program:", err)
+	}
+	writeJSONResp(w, status, map[string]any{
+		"program":                   p,
+		"linked_credentials":        creds,
+		"linked_legacy_credentials": legacy,
+	})
+}
+
+// DELETE /api/v1/org/programs/{id} (protected, verified org)
+// Linked credentials and legacy records keep their free-text program.
+func DeleteProgram(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	p, ok := orgProgram(w, r, org.ID)
+	if !ok {
+		return
+	}
+	if err := db.DB.Delete(&p).Error; err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	writeJSONResp(w, http.StatusOK, map[string]any{"id": p.ID, "deleted": true})
+}
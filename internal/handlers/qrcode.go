@@ -1,13 +1,19 @@
 package handlers
 
 import (
+    "fmt"
+    "image"
     "net/http"
     "github.com/go-chi/chi/v5"
-    "github.com/skip2/go-qrcode"
     "strings"
+
+    "vericred/internal/branding"
+    "vericred/internal/db"
+    "vericred/internal/models"
 )
 
 // GET /api/credential/{id}/qrcode
+// Drawn in the issuer's primary color with its logo in the middle.
 func GetCredentialQRCode(w http.ResponseWriter, r *http.Request) {
     // Extract credential ID from URL path
     pathParts := strings.Split(r.URL.Path, "/")
@@ -17,11 +23,28 @@ func GetCredentialQRCode(w http.ResponseWriter, r *http.Request) {
     }
     credID := chi.URLParam(r, "id")
 
+    var cred models.Credential
+    if err := db.DB.Select("id", "organization_id").Where("id = ?", credID).First(&cred).Error; err != nil {
+        http.Error(w, "credential not found", http.StatusNotFound)
+        return
+    }
+    brand, err := branding.Load(cred.OrganizationID)
+    if err != nil {
+        http.Error(w, "failed to load branding", http.StatusInternalServerError)
+        return
+    }
+    var logo image.Image
+    if brand.LogoURI != "" {
+        if logo, err = branding.LoadImage(r.Context(), brand.LogoURI); err != nil {
+            fmt.Println("QR code logo:", err)
+        }
+    }
+
     // Data to encode in QR (could be a URL or credential ID)
     data := "https://yourdomain.com/credential/" + credID
 
     // Generate QR code as PNG
-    png, err := qrcode.Encode(data, qrcode.Medium, 256)
+    png, err := branding.QRCode(data, 256, brand, logo)
     if err != nil {
         http.Error(w, "Failed to generate QR code", http.StatusInternalServerError)
         return
@@ -30,4 +53,4 @@ func GetCredentialQRCode(w http.ResponseWriter, r *http.Request) {
     w.Header().Set("Content-Type", "image/png")
     w.WriteHeader(http.StatusOK)
     w.Write(png)
-}
\ No newline at end of file
+}
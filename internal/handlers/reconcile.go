@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"vericred/internal/db"
+	"vericred/internal/jobs"
+	"vericred/internal/models"
+)
+
+// GET /api/admin/reconciliation?status=open|resolved|all&kind=...&limit=100 (admin)
+// Returns the discrepancy report produced by the on-chain reconciler.
+func ReconciliationReport(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "open"
+	}
+	limit := 100
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 && v <= 500 {
+		limit = v
+	}
+
+	q := db.DB.Model(&models.CredentialDiscrepancy{})
+	switch status {
+	case "open":
+		q = q.Where("resolved_at IS NULL")
+	case "resolved":
+		q = q.Where("resolved_at IS NOT NULL")
+	case "all":
+	default:
+		http.Error(w, "status must be open, resolved or all", http.StatusBadRequest)
+		return
+	}
+	if kind := r.URL.Query().Get("kind"); kind != "" {
+		q = q.Where("kind = ?", kind)
+	}
+
+	var items []models.CredentialDiscrepancy
+	if err := q.Order("created_at DESC").Limit(limit).Find(&items).Error; err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+
+	type kindCount struct {
+		Kind  string
+		Count int64
+	}
+	var counts []kindCount
+	if err := db.DB.Model(&models.CredentialDiscrepancy{}).
+		Select("kind, count(*) as count").
+		Where("resolved_at IS NULL").
+		Group("kind").
+		Scan(&counts).Error; err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	open := map[string]int64{}
+	for _, c := range counts {
+		open[c.Kind] = c.Count
+	}
+
+	cursor, err := jobs.GetReconciler().Cursor()
+	if err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSONResp(w, http.StatusOK, map[string]any{
+		"last_scanned_block": cursor.LastBlock,
+		"last_scanned_at":    cursor.UpdatedAt,
+		"open_counts":        open,
+		"discrepancies":      items,
+	})
+}
+
+// POST /api/admin/reconciliation/run (admin)
+// Runs the reconciler synchronously and returns its summary.
+func RunReconciliation(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
+	defer cancel()
+
+	sum, err := jobs.GetReconciler().RunOnce(ctx)
+	if errors.Is(err, jobs.ErrReconcileRunning) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		writeJSONResp(w, http.StatusBadGateway, map[string]any{"error": err.Error(), "summary": sum})
+		return
+	}
+	writeJSONResp(w, http.StatusOK, sum)
+}
+
+// PATCH /api/admin/reconciliation/{id}/resolve (admin)
+// Marks a discrepancy as handled after manual review.
+func ResolveDiscrepancy(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	res := db.DB.Model(&models.CredentialDiscrepancy{}).
+		Where("id = ? AND resolved_at IS NULL", id).
+		Update("resolved_at", time.Now().UTC())
+	if res.Error != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	if res.RowsAffected == 0 {
+		http.Error(w, "open discrepancy not found", http.StatusNotFound)
+		return
+	}
+	writeJSONResp(w, http.StatusOK, map[string]any{"resolved": id})
+}
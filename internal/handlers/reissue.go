@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"vericred/internal/db"
+	"vericred/internal/eip712"
+	"vericred/internal/eth/ipfs"
+	"vericred/internal/jobs"
+	"vericred/internal/models"
+)
+
+// maxSupersessionHops bounds how far currentCredentialID follows a chain.
+const maxSupersessionHops = 32
+
+var errAlreadySuperseded = errors.New("credential was already superseded")
+
+// currentCredentialID follows the superseded-by chain from cred to the
+// credential that currently replaces it, or returns cred's own ID.
+func currentCredentialID(cred models.Credential) string {
+	id := cred.ID
+	next := cred.SupersededByID
+	for i := 0; next != nil && i < maxSupersessionHops; i++ {
+		id = *next
+		var c models.Credential
+		if err := db.DB.Select("id", "superseded_by_id").Where("id = ?", id).First(&c).Error; err != nil {
+			break
+		}
+		next = c.SupersededByID
+	}
+	return id
+}
+
+type reissueReq struct {
+	Reason         string  `json:"reason"`
+	IPFSLink       string  `json:"ipfs_link"`
+	DeanSig        string  `json:"dean_sig"`
+	DegreeName     *string `json:"degree_name"`
+	Type           *string `json:"type"`
+	Major          *string `json:"major"`
+	Description    *string `json:"description"`
+	IssuedDate     *string `json:"issued_date"`
+	GraduationDate *string `json:"graduation_date"`
+}
+
+// POST /api/v1/credentials/{id}/reissue (protected, issuing org)
+// Body: { reason, ipfs_link, dean_sig, degree_name?, type?, major?,
+// description?, issued_date?, graduation_date? }
+// Creates the corrected credential, which supersedes {id}. Omitted fields
+// are copied from the old credential; ipfs_link is the new metadata and is
+// checked against the resulting fields like /credmint.
+func ReissueCredential(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	old, ok := issuedCredential(w, org, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+
+	var body reissueReq
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	body.Reason = strings.TrimSpace(body.Reason)
+	if body.Reason == "" {
+		http.Error(w, "reason is required", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(body.IPFSLink) == "" || strings.TrimSpace(body.DeanSig) == "" {
+		http.Error(w, "ipfs_link and dean_sig are required", http.StatusBadRequest)
+		return
+	}
+	switch old.Status {
+	case models.CredentialStatusRevoked:
+		http.Error(w, "revoked credentials cannot be reissued", http.StatusConflict)
+		return
+	case models.CredentialStatusSuperseded:
+		http.Error(w, fmt.Sprintf("credential was already superseded by %s", currentCredentialID(old)), http.StatusConflict)
+		return
+	}
+
+	next := models.Credential{
+		DegreeID:         old.DegreeID,
+		StudentWallet:    old.StudentWallet,
+		UniversityWallet: old.UniversityWallet,
+		DegreeName:       old.DegreeName,
+		Description:      old.Description,
+		Type:             old.Type,
+		Major:            old.Major,
+		IssuedDate:       old.IssuedDate,
+		GraduationDate:   old.GraduationDate,
+		IPFSLink:         ipfs.CanonicalLink(body.IPFSLink),
+		DeanSig:          strings.TrimSpace(body.DeanSig),
+		TemplateID:       old.TemplateID,
+		SupersedesID:     &old.ID,
+		UserID:           old.UserID,
+		OrganizationID:   old.OrganizationID,
+	}
+	if body.DegreeName != nil {
+		next.DegreeName = strings.TrimSpace(*body.DegreeName)
+	}
+	if body.Type != nil {
+		next.Type = strings.TrimSpace(*body.Type)
+	}
+	if body.Major != nil {
+		next.Major = strings.TrimSpace(*body.Major)
+	}
+	if body.Description != nil {
+		next.Description = *body.Description
+	}
+	if body.GraduationDate != nil {
+		next.GraduationDate = strings.TrimSpace(*body.GraduationDate)
+	}
+	if body.IssuedDate != nil {
+		t, err := parseIssuedDate(*body.IssuedDate)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		next.IssuedDate = t
+	}
+	if next.DegreeName == "" || next.Type == "" {
+		http.Error(w, "degree_name and type cannot be empty", http.StatusBadRequest)
+		return
+	}
+
+	// A changed degree name or major is re-resolved against the catalog
+	if body.DegreeName != nil || body.Major != nil {
+		matcher, err := orgProgramMatcher(org.ID)
+		if err != nil {
+			http.Error(w, "database error", http.StatusInternalServerError)
+			return
+		}
+		next.DegreeID = nil
+		if p := matcher.Match(next.DegreeName); p != nil {
+			if !offersMajor(p, next.Major) {
+				http.Error(w, fmt.Sprintf("'major' %q is not offered by program %q", next.Major, p.Name), http.StatusBadRequest)
+				return
+			}
+			next.DegreeID = &p.ID
+		}
+	}
+
+	signatory, err := issuingSignatory(org.ID, next)
+	if isSignatureError(err) {
+		http.Error(w, "Invalid 'dean_sig': "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	next.SignatoryID = &signatory.ID
+
+	hash, err := eip712.CredentialHash(eip712.FieldsOf(next))
+	if err != nil {
+		http.Error(w, "failed to hash credential", http.StatusInternalServerError)
+		return
+	}
+	next.CanonicalHash = hash
+
+	md, err := ipfs.FetchMetadata(r.Context(), next.IPFSLink)
+	if err != nil {
+		http.Error(w, "Could not verify 'ipfs_link' content: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	env, err := envelopeForMetadata(org.ID, next.StudentWallet, md, old.ID)
+	if err != nil {
+		http.Error(w, "Could not link private data: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	sdSet, err := disclosureSetForMetadata(org.ID, next.StudentWallet, md, old.ID)
+	if err != nil {
+		http.Error(w, "Could not link disclosures: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	mismatches := metadataMismatches(md, next)
+	if next.TemplateID != nil {
+		if tpl, err := orgTemplate(org.ID, *next.TemplateID); err == nil {
+			// Sealed and withheld attributes are left out of the metadata on purpose
+			omitted := slices.Concat(sealedTraits(env), withheldTraits(sdSet))
+			mismatches = append(mismatches, ipfs.TemplateMismatches(md, tpl, omitted...)...)
+		}
+	}
+	if len(mismatches) > 0 {
+		writeJSONResp(w, http.StatusUnprocessableEntity, map[string]any{
+			"error":      "ipfs_link content does not match reissued fields or template",
+			"mismatches": mismatches,
+		})
+		return
+	}
+
+	tr, err := transcriptForMetadata(org.ID, next.StudentWallet, md, old.ID)
+	if errors.Is(err, errTranscriptUnmatched) {
+		http.Error(w, "Could not link transcript: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+
+	var student models.Users
+	if err := db.DB.First(&student, next.UserID).Error; err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	if err := commitDisclosures(&next, sdSet, studentName(student), md); err != nil {
+		http.Error(w, "failed to salt credential fields", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now().UTC()
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the old row so concurrent reissues cannot both succeed
+		var locked models.Credential
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "status").
+			Where("id = ?", old.ID).First(&locked).Error; err != nil {
+			return err
+		}
+		if locked.Status != "" && locked.Status != models.CredentialStatusActive {
+			return errAlreadySuperseded
+		}
+		if err := tx.Create(&next).Error; err != nil {
+			return err
+		}
+		if tr != nil {
+			if err := tx.Model(tr).Update("credential_id", next.ID).Error; err != nil {
+				return err
+			}
+		}
+		if env != nil {
+			if err := tx.Model(env).Update("credential_id", next.ID).Error; err != nil {
+				return err
+			}
+		}
+		if sdSet != nil {
+			if err := tx.Model(sdSet).Update("credential_id", next.ID).Error; err != nil {
+				return err
+			}
+		}
+		if err := jobs.AttachPin(tx, next.IPFSLink, next.ID); err != nil {
+			return err
+		}
+		if err := jobs.RetainAssets(tx, next.ID, md.Image, md.AnimationURL); err != nil {
+			return err
+		}
+		return tx.Model(&models.Credential{}).Where("id = ?", old.ID).Updates(map[string]any{
+			"status":              models.CredentialStatusSuperseded,
+			"superseded_by_id":    next.ID,
+			"superseded_at":       now,
+			"supersession_reason": body.Reason,
+		}).Error
+	})
+	if errors.Is(err, errAlreadySuperseded) {
+		http.Error(w, "credential is no longer active", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		fmt.Println("Reissue failed:", err)
+		http.Error(w, "failed to reissue credential", http.StatusInternalServerError)
+		return
+	}
+
+	if err := db.DB.Preload("User").Preload("Organization").Preload("Degree").Where("id = ?", next.ID).First(&next).Error; err != nil {
+		http.Error(w, "Error when preloading", http.StatusInternalServerError)
+		return
+	}
+	old.Status = models.CredentialStatusSuperseded
+	old.SupersededByID = &next.ID
+	old.SupersededAt = &now
+	old.SupersessionReason = body.Reason
+	writeJSONResp(w, http.StatusCreated, map[string]any{
+		"credential": next,
+		"superseded": credentialStatusPayload(old),
+	})
+}
+
+// GET /api/v1/credentials/{id}/history (public)
+// The supersession chain containing {id}, oldest first.
+func CredentialHistory(w http.ResponseWriter, r *http.Request) {
+	var cred models.Credential
+	if err := db.DB.Where("id = ?", chi.URLParam(r, "id")).First(&cred).Error; err != nil {
+		http.Error(w, "credential not found", http.StatusNotFound)
+		return
+	}
+	// Walk back to the first credential, then forward to the current one
+	for i := 0; cred.SupersedesID != nil && i < maxSupersessionHops; i++ {
+		var prev models.Credential
+		if err := db.DB.Where("id = ?", *cred.SupersedesID).First(&prev).Error; err != nil {
+			break
+		}
+		cred = prev
+	}
+	chain := []map[string]any{credentialStatusPayload(cred)}
+	for i := 0; cred.SupersededByID != nil && i < maxSupersessionHops; i++ {
+		var next models.Credential
+		if err := db.DB.Where("id = ?", *cred.SupersededByID).First(&next).Error; err != nil {
+			break
+		}
+		cred = next
+		chain = append(chain, credentialStatusPayload(cred))
+	}
+	writeJSONResp(w, http.StatusOK, map[string]any{
+		"current_credential_id": cred.ID,
+		"chain":                 chain,
+	})
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"vericred/internal/db"
+	"vericred/internal/models"
+)
+
+// POST /api/v1/credentials/{id}/revoke (protected, issuing org)
+// Body: { reason }
+func RevokeCredential(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	cred, ok := issuedCredential(w, org, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(body.Reason) == "" {
+		http.Error(w, "reason is required", http.StatusBadRequest)
+		return
+	}
+	if cred.Status == models.CredentialStatusRevoked {
+		http.Error(w, "credential already revoked", http.StatusConflict)
+		return
+	}
+
+	now := time.Now().UTC()
+	cred.Status = models.CredentialStatusRevoked
+	cred.RevokedAt = &now
+	cred.RevocationReason = strings.TrimSpace(body.Reason)
+	if err := db.DB.Model(&models.Credential{}).Where("id = ?", cred.ID).Updates(map[string]any{
+		"status":            cred.Status,
+		"revoked_at":        cred.RevokedAt,
+		"revocation_reason": cred.RevocationReason,
+	}).Error; err != nil {
+		http.Error(w, "failed to revoke credential", http.StatusInternalServerError)
+		return
+	}
+	writeJSONResp(w, http.StatusOK, credentialStatusPayload(cred))
+}
+
+// GET /api/v1/credentials/{id}/status (public)
+// Revocation status referenced by credentialStatus in exported VCs. A
+// superseded credential points at its current replacement.
+func CredentialStatus(w http.ResponseWriter, r *http.Request) {
+	var cred models.Credential
+	if err := db.DB.Where("id = ?", chi.URLParam(r, "id")).First(&cred).Error; err != nil {
+		http.Error(w, "credential not found", http.StatusNotFound)
+		return
+	}
+	writeJSONResp(w, http.StatusOK, credentialStatusPayload(cred))
+}
+
+func credentialStatusPayload(cred models.Credential) map[string]any {
+	status := cred.Status
+	if status == "" {
+		status = models.CredentialStatusActive
+	}
+	return map[string]any{
+		"id":                    cred.ID,
+		"status":                status,
+		"revoked":               status == models.CredentialStatusRevoked,
+		"revoked_at":            cred.RevokedAt,
+		"revocation_reason":     cred.RevocationReason,
+		"superseded":            status == models.CredentialStatusSuperseded,
+		"supersedes_id":         cred.SupersedesID,
+		"superseded_by_id":      cred.SupersededByID,
+		"superseded_at":         cred.SupersededAt,
+		"supersession_reason":   cred.SupersessionReason,
+		"current_credential_id": currentCredentialID(cred),
+	}
+}
@@ -1,6 +1,11 @@
 package handlers
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,12 +19,24 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 
 	"vericred/internal/db"
+	"vericred/internal/disclosure"
+	"vericred/internal/envelope"
+	"vericred/internal/eth/ipfs"
 	"vericred/internal/middleware"
 	"vericred/internal/models"
 )
 
 type shareClaims struct {
 	CredentialID string `json:"credential_id"`
+	// Selective links carry the encoded disclosures the holder chose to
+	// reveal and grant nothing beyond them.
+	Selective   bool     `json:"selective,omitempty"`
+	Disclosures []string `json:"sd,omitempty"`
+	// The content key of the credential's private data envelope, which the
+	// holder unwrapped client-side, encrypted under the share secret (see
+	// sealShareKey) so the URL alone never reveals it; the server opens it
+	// only while the link is valid.
+	PrivateKey string `json:"pk,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -32,6 +49,8 @@ type generateShareLinkResp struct {
 	ShareableURL string `json:"shareable_url"`
 }
 
+var errShareSecret = errors.New("missing SHARE_TOKEN_SECRET/JWT_SECRET")
+
 func getShareSecret() ([]byte, error) {
 	if s := os.Getenv("SHARE_TOKEN_SECRET"); s != "" {
 		return []byte(s), nil
@@ -39,7 +58,7 @@ func getShareSecret() ([]byte, error) {
 	if s := os.Getenv("JWT_SECRET"); s != "" {
 		return []byte(s), nil
 	}
-	return nil, errors.New("missing SHARE_TOKEN_SECRET/JWT_SECRET")
+	return nil, errShareSecret
 }
 
 // POST /api/v1/credentials/generate-share-link (protected)
@@ -117,32 +136,76 @@ func GenerateShareLink(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	secret, err := getShareSecret()
-	if err != nil {
-		http.Error(w, "server misconfigured", http.StatusInternalServerError)
-		return
+	claims := shareClaims{CredentialID: credID}
+	// "disclose": ["degree_name", ...] makes the link selective
+	if v, ok := payload["disclose"]; ok {
+		list, ok := v.([]any)
+		if !ok {
+			http.Error(w, "disclose must be a list of field names", http.StatusBadRequest)
+			return
+		}
+		var names []string
+		for _, x := range list {
+			if n, ok := x.(string); ok {
+				names = append(names, n)
+			}
+		}
+		if err := ensureDisclosures(r.Context(), &cred); err != nil {
+			fmt.Println("disclosure backfill failed:", err)
+			http.Error(w, "failed to prepare disclosures", http.StatusInternalServerError)
+			return
+		}
+		selected, unknown := disclosure.Select(cred.SDDisclosures, names)
+		if len(unknown) > 0 {
+			writeJSONResp(w, http.StatusBadRequest, map[string]any{
+				"error":     "unknown fields in disclose",
+				"unknown":   unknown,
+				"available": disclosure.Names(cred.SDDisclosures),
+			})
+			return
+		}
+		claims.Selective = true
+		claims.Disclosures = selected
+	}
+	// "private_key": the unwrapped content key, to let the viewer read the
+	// encrypted attributes
+	if v, ok := payload["private_key"].(string); ok && strings.TrimSpace(v) != "" {
+		if claims.Selective {
+			http.Error(w, "private_key cannot be combined with disclose", http.StatusBadRequest)
+			return
+		}
+		var env models.PrivateEnvelope
+		if err := db.DB.Where("credential_id = ?", cred.ID).First(&env).Error; err != nil {
+			http.Error(w, "credential has no private data", http.StatusBadRequest)
+			return
+		}
+		if _, err := envelope.Open(env.Nonce, env.Ciphertext, env.Commitment, v); err != nil {
+			http.Error(w, "private_key does not open the credential's private data: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		sealed, err := sealShareKey(strings.TrimSpace(v))
+		if errors.Is(err, errShareSecret) {
+			http.Error(w, "server misconfigured", http.StatusInternalServerError)
+			return
+		}
+		if err != nil {
+			http.Error(w, "failed to seal private_key", http.StatusInternalServerError)
+			return
+		}
+		claims.PrivateKey = sealed
 	}
 
-	exp := time.Now().Add(time.Duration(expires) * time.Hour)
-	claims := shareClaims{
-		CredentialID: credID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(exp),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+	signed, err := signShareToken(claims, time.Duration(expires)*time.Hour)
+	if errors.Is(err, errShareSecret) {
+		http.Error(w, "server misconfigured", http.StatusInternalServerError)
+		return
 	}
-	tok := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signed, err := tok.SignedString(secret)
 	if err != nil {
 		http.Error(w, "failed to sign share token", http.StatusInternalServerError)
 		return
 	}
 
-	base := os.Getenv("FRONTEND_BASE_URL")
-	if base == "" {
-		base = "http://localhost:3000"
-	}
-	url := fmt.Sprintf("%s/verify/%s?token=%s", trimRightSlash(base), credID, signed)
+	url := shareURL(credID, signed)
 	_ = json.NewEncoder(w).Encode(generateShareLinkResp{ShareableURL: url})
 }
 
@@ -160,26 +223,12 @@ func GetCredentialInfo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	secret, err := getShareSecret()
-	if err != nil {
+	claims, err := parseShareToken(tokenStr)
+	if errors.Is(err, errShareSecret) {
 		http.Error(w, "server misconfigured", http.StatusInternalServerError)
 		return
 	}
-
-	parsed, err := jwt.ParseWithClaims(tokenStr, &shareClaims{}, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return secret, nil
-	})
-	fmt.Println("Parsed: ", parsed)
-	if err != nil || !parsed.Valid {
-		http.Error(w, "This verification link is invalid or has expired.", http.StatusUnauthorized)
-		return
-	}
-	claims, ok := parsed.Claims.(*shareClaims)
-	fmt.Println("claims: ", claims)
-	if !ok || claims.CredentialID == "" || claims.ExpiresAt == nil || time.Now().After(claims.ExpiresAt.Time) {
+	if err != nil {
 		http.Error(w, "This verification link is invalid or has expired.", http.StatusUnauthorized)
 		return
 	}
@@ -193,24 +242,134 @@ func GetCredentialInfo(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "credential not found", http.StatusNotFound)
 		return
 	}
+	if claims.Selective {
+		selectiveCredentialInfo(w, r, cred, claims)
+		return
+	}
 
-	// Optionally fetch IPFS document (best-effort)
-	var ipfs any
-	if cred.IPFSLink != "" {
-		client := &http.Client{Timeout: 10 * time.Second}
-		if resp, e := client.Get(cred.IPFSLink); e == nil && resp != nil && resp.Body != nil {
-			defer resp.Body.Close()
-			_ = json.NewDecoder(resp.Body).Decode(&ipfs)
-		}
+	// Fetch the IPFS document (best-effort) and check it, the row and the
+	// token against the canonical hash
+	var doc any
+	var md *ipfs.Metadata
+	res, fetchErr := ipfs.Resolve(r.Context(), cred.IPFSLink)
+	if fetchErr == nil {
+		_ = json.Unmarshal(res.Data, &doc)
+		md, fetchErr = ipfs.ParseMetadata(res.Data)
 	}
 
 	_ = json.NewEncoder(w).Encode(map[string]any{
 		"credential":     cred,
-		"ipfs":           ipfs,
+		"ipfs":           doc,
+		"fields":         disclosedFields(cred),
+		"integrity":      credentialIntegrity(r.Context(), cred, md, res, fetchErr),
+		"status":         credentialStatusPayload(cred),
+		"signatory":      signatoryReport(cred),
+		"transcript":     transcriptView(cred, md),
+		"private":        privateView(cred, openShareKey(claims.PrivateKey)),
+		"branding":       issuerBranding(cred.OrganizationID),
 		"valid_until":    claims.ExpiresAt.Time,
 	})
 }
 
+// signShareToken signs claims as a share token valid for ttl.
+func signShareToken(claims shareClaims, ttl time.Duration) (string, error) {
+	return signShareTokenUntil(claims, time.Now().Add(ttl))
+}
+
+// signShareTokenUntil signs claims as a share token that expires at expires.
+func signShareTokenUntil(claims shareClaims, expires time.Time) (string, error) {
+	secret, err := getShareSecret()
+	if err != nil {
+		return "", err
+	}
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(expires),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+}
+
+// shareKeyCipher is AES-256-GCM under a key derived from the share secret.
+func shareKeyCipher() (cipher.AEAD, error) {
+	secret, err := getShareSecret()
+	if err != nil {
+		return nil, err
+	}
+	key := sha256.Sum256(append([]byte("vericred share content key\x00"), secret...))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealShareKey encrypts a private data content key for a share token's pk
+// claim.
+func sealShareKey(contentKey string) (string, error) {
+	gcm, err := shareKeyCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(contentKey), nil)), nil
+}
+
+// openShareKey reverses sealShareKey, returning "" for a missing or
+// unreadable claim.
+func openShareKey(sealed string) string {
+	if sealed == "" {
+		return ""
+	}
+	gcm, err := shareKeyCipher()
+	if err != nil {
+		return ""
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(sealed)
+	if err != nil || len(raw) < gcm.NonceSize() {
+		return ""
+	}
+	plain, err := gcm.Open(nil, raw[:gcm.NonceSize()], raw[gcm.NonceSize():], nil)
+	if err != nil {
+		return ""
+	}
+	return string(plain)
+}
+
+// shareURL is the frontend verification page for a credential and token.
+func shareURL(credID, token string) string {
+	base := os.Getenv("FRONTEND_BASE_URL")
+	if base == "" {
+		base = "http://localhost:3000"
+	}
+	return fmt.Sprintf("%s/verify/%s?token=%s", trimRightSlash(base), credID, token)
+}
+
+// parseShareToken validates a share token and returns its claims.
+func parseShareToken(tokenStr string) (*shareClaims, error) {
+	secret, err := getShareSecret()
+	if err != nil {
+		return nil, errShareSecret
+	}
+
+	parsed, err := jwt.ParseWithClaims(tokenStr, &shareClaims{}, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return secret, nil
+	})
+	if err != nil || !parsed.Valid {
+		return nil, errors.New("invalid share token")
+	}
+	claims, ok := parsed.Claims.(*shareClaims)
+	if !ok || claims.CredentialID == "" || claims.ExpiresAt == nil || time.Now().After(claims.ExpiresAt.Time) {
+		return nil, errors.New("invalid or expired share token")
+	}
+	return claims, nil
+}
+
 func equalCaseInsensitive(a, b string) bool {
 	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
 }
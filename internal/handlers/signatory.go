@@ -0,0 +1,330 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+
+	"vericred/internal/db"
+	"vericred/internal/eip712"
+	"vericred/internal/models"
+)
+
+var (
+	errSigMalformed     = errors.New("dean_sig is not a valid EIP-712 CredentialAttestation signature")
+	errSigUnknownSigner = errors.New("dean_sig was not made by a registered signatory of the issuing organization")
+	errSigKeyNotValid   = errors.New("signatory key was not valid at the issue date")
+	errSigKeyRevoked    = errors.New("signatory key has been revoked")
+	errSigKeyExpired    = errors.New("signatory key has expired")
+)
+
+// validAt reports whether s's key covers a credential issued at t (compared
+// by calendar day).
+func validAt(s models.Signatory, t time.Time) bool {
+	day := t.UTC().Format("2006-01-02")
+	if day < s.ValidFrom.UTC().Format("2006-01-02") {
+		return false
+	}
+	if s.ValidUntil != nil && day > s.ValidUntil.UTC().Format("2006-01-02") {
+		return false
+	}
+	if s.RevokedAt != nil && !t.Before(*s.RevokedAt) {
+		return false
+	}
+	return true
+}
+
+// deanSigner recovers the signer of cred.DeanSig and looks them up among
+// orgID's signatories. The signatory is returned whenever one is found, with
+// errSigKeyNotValid if the key did not cover the issue date.
+func deanSigner(orgID uint, cred models.Credential) (*models.Signatory, common.Address, error) {
+	addr, err := eip712.RecoverCredentialSigner(eip712.FieldsOf(cred), cred.DeanSig)
+	if err != nil {
+		return nil, addr, errSigMalformed
+	}
+	var s models.Signatory
+	err = db.DB.Where("organization_id = ? AND LOWER(wallet) = LOWER(?)", orgID, addr.Hex()).First(&s).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, addr, errSigUnknownSigner
+	}
+	if err != nil {
+		return nil, addr, err
+	}
+	if !validAt(s, cred.IssuedDate) {
+		return &s, addr, errSigKeyNotValid
+	}
+	return &s, addr, nil
+}
+
+// issuingSignatory checks cred.DeanSig at issuance time: on top of
+// deanSigner, a revoked or expired key cannot sign new credentials even if
+// they are backdated.
+func issuingSignatory(orgID uint, cred models.Credential) (*models.Signatory, error) {
+	s, _, err := deanSigner(orgID, cred)
+	if err != nil {
+		return nil, err
+	}
+	if err := canSignAt(*s, time.Now()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// canSignAt reports why s's key cannot sign a new credential at now, if it
+// can't: it has been revoked, or its last valid day (compared by calendar
+// day) has passed.
+func canSignAt(s models.Signatory, now time.Time) error {
+	if s.RevokedAt != nil {
+		return errSigKeyRevoked
+	}
+	if s.ValidUntil != nil && now.UTC().Format("2006-01-02") > s.ValidUntil.UTC().Format("2006-01-02") {
+		return errSigKeyExpired
+	}
+	return nil
+}
+
+// isSignatureError reports whether err is a dean_sig rejection rather than a
+// database failure.
+func isSignatureError(err error) bool {
+	return errors.Is(err, errSigMalformed) || errors.Is(err, errSigUnknownSigner) ||
+		errors.Is(err, errSigKeyNotValid) || errors.Is(err, errSigKeyRevoked) ||
+		errors.Is(err, errSigKeyExpired)
+}
+
+// signatoryReport describes who signed cred and whether their key was valid
+// at the issue date, for verification responses.
+func signatoryReport(cred models.Credential) map[string]any {
+	out := map[string]any{"signature_valid": false, "key_valid_at_issue": false}
+	if cred.DeanSig == "" {
+		out["detail"] = "credential carries no signatory signature"
+		return out
+	}
+	s, addr, err := deanSigner(cred.OrganizationID, cred)
+	if errors.Is(err, errSigMalformed) {
+		out["detail"] = err.Error()
+		return out
+	}
+	out["signer"] = addr.Hex()
+	out["signature_valid"] = true
+	if s != nil {
+		out["signatory"] = map[string]any{"id": s.ID, "name": s.Name, "role": s.Role, "wallet": s.Wallet}
+	}
+	switch {
+	case err == nil:
+		out["key_valid_at_issue"] = true
+	case isSignatureError(err):
+		out["detail"] = err.Error()
+	default:
+		out["detail"] = "could not look up signatory"
+	}
+	return out
+}
+
+// POST /api/v1/credentials/signing-payload (protected, verified org)
+// Body: { student_wallet, degree_name, type, major, issued_date, graduation_date }
+// Returns the CredentialAttestation typed data a signatory signs with
+// eth_signTypedData_v4; the signature is sent as dean_sig.
+func CredentialSigningPayload(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		StudentWallet  string `json:"student_wallet"`
+		DegreeName     string `json:"degree_name"`
+		Type           string `json:"type"`
+		Major          string `json:"major"`
+		IssuedDate     string `json:"issued_date"`
+		GraduationDate string `json:"graduation_date"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if !common.IsHexAddress(body.StudentWallet) {
+		http.Error(w, "student_wallet must be a wallet address", http.StatusBadRequest)
+		return
+	}
+	issued, err := parseIssuedDate(body.IssuedDate)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	cred := models.Credential{
+		StudentWallet:    body.StudentWallet,
+		UniversityWallet: org.MetamaskAddress,
+		DegreeName:       body.DegreeName,
+		Type:             body.Type,
+		Major:            body.Major,
+		IssuedDate:       issued,
+		GraduationDate:   body.GraduationDate,
+	}
+	writeJSONResp(w, http.StatusOK, eip712.CredentialTypedData(eip712.FieldsOf(cred)))
+}
+
+type signatoryReq struct {
+	Name       string  `json:"name"`
+	Role       string  `json:"role"`
+	Wallet     string  `json:"wallet"`
+	ValidFrom  string  `json:"valid_from"`
+	ValidUntil *string `json:"valid_until"`
+}
+
+// apply validates req and copies it onto s.
+func (req signatoryReq) apply(s *models.Signatory) []string {
+	var out []string
+	s.Name = strings.TrimSpace(req.Name)
+	if s.Name == "" {
+		out = append(out, "name is required")
+	}
+	s.Role = strings.ToLower(strings.TrimSpace(req.Role))
+	switch s.Role {
+	case models.SignatoryRoleDean, models.SignatoryRoleRegistrar, models.SignatoryRoleOther:
+	default:
+		out = append(out, "role must be dean, registrar or other")
+	}
+	if !common.IsHexAddress(req.Wallet) {
+		out = append(out, "wallet must be a wallet address")
+	} else {
+		s.Wallet = common.HexToAddress(req.Wallet).Hex()
+	}
+	from, err := parseIssuedDate(req.ValidFrom)
+	if err != nil {
+		out = append(out, "valid_from must be RFC3339 or YYYY-MM-DD")
+	}
+	s.ValidFrom = from
+	s.ValidUntil = nil
+	if req.ValidUntil != nil && strings.TrimSpace(*req.ValidUntil) != "" {
+		until, err := parseIssuedDate(*req.ValidUntil)
+		if err != nil {
+			out = append(out, "valid_until must be RFC3339 or YYYY-MM-DD")
+		} else if until.Before(from) {
+			out = append(out, "valid_until is before valid_from")
+		} else {
+			s.ValidUntil = &until
+		}
+	}
+	return out
+}
+
+func orgSignatory(w http.ResponseWriter, r *http.Request, orgID uint) (models.Signatory, bool) {
+	var s models.Signatory
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid signatory id", http.StatusBadRequest)
+		return s, false
+	}
+	if err := db.DB.Where("id = ? AND organization_id = ?", id, orgID).First(&s).Error; err != nil {
+		http.Error(w, "signatory not found", http.StatusNotFound)
+		return s, false
+	}
+	return s, true
+}
+
+// GET /api/v1/org/signatories (protected, verified org)
+func ListSignatories(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	var list []models.Signatory
+	if err := db.DB.Where("organization_id = ?", org.ID).Order("valid_from DESC").Find(&list).Error; err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	writeJSONResp(w, http.StatusOK, list)
+}
+
+// POST /api/v1/org/signatories (protected, verified org)
+// Body: { name, role, wallet, valid_from, valid_until? }
+func CreateSignatory(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	var req signatoryReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	s := models.Signatory{OrganizationID: org.ID}
+	if problems := req.apply(&s); len(problems) > 0 {
+		writeJSONResp(w, http.StatusBadRequest, map[string]any{"error": "invalid signatory", "problems": problems})
+		return
+	}
+	if err := db.DB.Create(&s).Error; err != nil {
+		fmt.Println("Failed to create signatory:", err)
+		http.Error(w, "failed to create signatory (wallet already registered?)", http.StatusConflict)
+		return
+	}
+	writeJSONResp(w, http.StatusCreated, s)
+}
+
+// PUT /api/v1/org/signatories/{id} (protected, verified org)
+// Same body as create. The wallet cannot change; register a new signatory
+// for a new key.
+func UpdateSignatory(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	s, ok := orgSignatory(w, r, org.ID)
+	if !ok {
+		return
+	}
+	var req signatoryReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	wallet := s.Wallet
+	if req.Wallet == "" {
+		req.Wallet = wallet
+	}
+	problems := req.apply(&s)
+	if !strings.EqualFold(s.Wallet, wallet) {
+		problems = append(problems, "wallet cannot be changed")
+	}
+	if len(problems) > 0 {
+		writeJSONResp(w, http.StatusBadRequest, map[string]any{"error": "invalid signatory", "problems": problems})
+		return
+	}
+	if err := db.DB.Save(&s).Error; err != nil {
+		http.Error(w, "failed to update signatory", http.StatusInternalServerError)
+		return
+	}
+	writeJSONResp(w, http.StatusOK, s)
+}
+
+// POST /api/v1/org/signatories/{id}/revoke (protected, verified org)
+// Stops the key from signing credentials issued from now on; credentials it
+// signed earlier stay valid.
+func RevokeSignatory(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	s, ok := orgSignatory(w, r, org.ID)
+	if !ok {
+		return
+	}
+	if s.RevokedAt != nil {
+		http.Error(w, "signatory already revoked", http.StatusConflict)
+		return
+	}
+	now := time.Now().UTC()
+	s.RevokedAt = &now
+	if err := db.DB.Model(&s).Update("revoked_at", now).Error; err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	writeJSONResp(w, http.StatusOK, s)
+}
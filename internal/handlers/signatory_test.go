@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"vericred/internal/models"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func TestSignatoryValidAt(t *testing.T) {
+	s := models.Signatory{
+		ValidFrom:  day("2024-01-10T15:00:00Z"),
+		ValidUntil: ptr(day("2025-06-30T00:00:00Z")),
+	}
+	revoked := s
+	revoked.RevokedAt = ptr(day("2025-03-01T12:00:00Z"))
+	tests := []struct {
+		name   string
+		s      models.Signatory
+		issued string
+		want   bool
+	}{
+		{"before valid_from", s, "2024-01-09T23:59:59Z", false},
+		{"on valid_from day", s, "2024-01-10T00:00:00Z", true},
+		{"active", s, "2024-09-01T00:00:00Z", true},
+		{"last valid day", s, "2025-06-30T23:59:59Z", true},
+		{"day after valid_until", s, "2025-07-01T00:00:00Z", false},
+		{"no valid_until", models.Signatory{ValidFrom: s.ValidFrom}, "2040-01-01T00:00:00Z", true},
+		{"issued before revocation", revoked, "2025-03-01T11:59:59Z", true},
+		{"issued at revocation", revoked, "2025-03-01T12:00:00Z", false},
+		{"issued after revocation", revoked, "2025-04-01T00:00:00Z", false},
+		{"other time zone", s, "2025-07-01T01:00:00+02:00", true}, // 2025-06-30 in UTC
+	}
+	for _, tt := range tests {
+		if got := validAt(tt.s, day(tt.issued)); got != tt.want {
+			t.Errorf("%s: validAt(%s) = %v, want %v", tt.name, tt.issued, got, tt.want)
+		}
+	}
+}
+
+func TestSignatoryCanSignAt(t *testing.T) {
+	active := models.Signatory{ValidFrom: day("2024-01-10T00:00:00Z")}
+	expiring := active
+	expiring.ValidUntil = ptr(day("2025-06-30T00:00:00Z"))
+	revoked := active
+	revoked.RevokedAt = ptr(day("2025-03-01T12:00:00Z"))
+	tests := []struct {
+		name string
+		s    models.Signatory
+		now  string
+		want error
+	}{
+		{"active", active, "2030-01-01T00:00:00Z", nil},
+		{"before expiry", expiring, "2025-06-29T12:00:00Z", nil},
+		{"expiry day", expiring, "2025-06-30T23:59:59Z", nil},
+		{"day after expiry", expiring, "2025-07-01T00:00:00Z", errSigKeyExpired},
+		{"expired in UTC", expiring, "2025-06-30T20:00:00-05:00", errSigKeyExpired},
+		{"long expired", expiring, "2027-01-01T00:00:00Z", errSigKeyExpired},
+		// Revocation stops new issuance even for credentials dated before it
+		{"revoked", revoked, "2025-03-01T12:00:00Z", errSigKeyRevoked},
+		{"revoked_at in the future", revoked, "2025-02-01T00:00:00Z", errSigKeyRevoked},
+	}
+	for _, tt := range tests {
+		err := canSignAt(tt.s, day(tt.now))
+		if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+			t.Errorf("%s: canSignAt(%s) = %v, want %v", tt.name, tt.now, err, tt.want)
+		}
+		if err != nil && !isSignatureError(err) {
+			t.Errorf("%s: %v is not reported as a signature error", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+
+	"vericred/internal/branding"
+	"vericred/internal/db"
+	"vericred/internal/disclosure"
+	"vericred/internal/eth/ipfs"
+	"vericred/internal/jobs"
+	"vericred/internal/models"
+)
+
+// orgTemplate loads template id owned by the organization.
+func orgTemplate(orgID, id uint) (models.CredentialTemplate, error) {
+	var t models.CredentialTemplate
+	err := db.DB.Where("id = ? AND organization_id = ?", id, orgID).First(&t).Error
+	return t, err
+}
+
+// orgRequiresTemplate reports whether the organization has active templates,
+// in which case every issuance and metadata upload must name one.
+func orgRequiresTemplate(orgID uint) (bool, error) {
+	var n int64
+	err := db.DB.Model(&models.CredentialTemplate{}).
+		Where("organization_id = ? AND active = ?", orgID, true).
+		Count(&n).Error
+	return n > 0, err
+}
+
+// resolveTemplate looks up the template an issuance names, enforcing that
+// orgs with templates always use one. It returns nil when no template applies.
+// On failure it writes the HTTP error and returns false.
+func resolveTemplate(w http.ResponseWriter, orgID uint, id *uint) (*models.CredentialTemplate, bool) {
+	if id == nil {
+		required, err := orgRequiresTemplate(orgID)
+		if err != nil {
+			http.Error(w, "database error", http.StatusInternalServerError)
+			return nil, false
+		}
+		if required {
+			http.Error(w, "template_id is required: organization issues from credential templates", http.StatusBadRequest)
+			return nil, false
+		}
+		return nil, true
+	}
+	t, err := orgTemplate(orgID, *id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "template not found", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return nil, false
+	}
+	if !t.Active {
+		http.Error(w, "template is inactive", http.StatusBadRequest)
+		return nil, false
+	}
+	return &t, true
+}
+
+func templateIDParam(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid template id", http.StatusBadRequest)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// GET /api/v1/org/credential-templates (protected, verified org)
+func ListCredentialTemplates(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	var templates []models.CredentialTemplate
+	if err := db.DB.Where("organization_id = ?", org.ID).Order("name").Find(&templates).Error; err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	writeJSONResp(w, http.StatusOK, templates)
+}
+
+// GET /api/v1/org/credential-templates/{id} (protected, verified org)
+func GetCredentialTemplate(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	id, ok := templateIDParam(w, r)
+	if !ok {
+		return
+	}
+	t, err := orgTemplate(org.ID, id)
+	if err != nil {
+		http.Error(w, "template not found", http.StatusNotFound)
+		return
+	}
+	writeJSONResp(w, http.StatusOK, t)
+}
+
+// POST /api/v1/org/credential-templates (protected, verified org)
+// Body: { name, credential_type, degree_name, default_description, attributes: [{ trait_type, type, required, enum, default }] }
+func CreateCredentialTemplate(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	var t models.CredentialTemplate
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	t.ID = 0
+	t.OrganizationID = org.ID
+	t.Active = true
+	if problems := ipfs.ValidateTemplate(t); len(problems) > 0 {
+		writeJSONResp(w, http.StatusBadRequest, map[string]any{"error": "invalid template", "problems": problems})
+		return
+	}
+	if err := db.DB.Create(&t).Error; err != nil {
+		fmt.Println("Failed to create template:", err)
+		http.Error(w, "failed to create template (name must be unique)", http.StatusConflict)
+		return
+	}
+	writeJSONResp(w, http.StatusCreated, t)
+}
+
+// PUT /api/v1/org/credential-templates/{id} (protected, verified org)
+// Same body as create, plus optional active. Existing credentials keep the
+// metadata they were issued with.
+func UpdateCredentialTemplate(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	id, ok := templateIDParam(w, r)
+	if !ok {
+		return
+	}
+	t, err := orgTemplate(org.ID, id)
+	if err != nil {
+		http.Error(w, "template not found", http.StatusNotFound)
+		return
+	}
+	var body struct {
+		models.CredentialTemplate
+		Active *bool `json:"active"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	t.Name = body.Name
+	t.CredentialType = body.CredentialType
+	t.DegreeName = body.DegreeName
+	t.DefaultDescription = body.DefaultDescription
+	t.Attributes = body.Attributes
+	if body.Active != nil {
+		t.Active = *body.Active
+	}
+	if problems := ipfs.ValidateTemplate(t); len(problems) > 0 {
+		writeJSONResp(w, http.StatusBadRequest, map[string]any{"error": "invalid template", "problems": problems})
+		return
+	}
+	if err := db.DB.Save(&t).Error; err != nil {
+		fmt.Println("Failed to update template:", err)
+		http.Error(w, "failed to update template (name must be unique)", http.StatusConflict)
+		return
+	}
+	writeJSONResp(w, http.StatusOK, t)
+}
+
+// DELETE /api/v1/org/credential-templates/{id} (protected, verified org)
+// Templates already used for issuance are deactivated instead of deleted.
+func DeleteCredentialTemplate(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	id, ok := templateIDParam(w, r)
+	if !ok {
+		return
+	}
+	t, err := orgTemplate(org.ID, id)
+	if err != nil {
+		http.Error(w, "template not found", http.StatusNotFound)
+		return
+	}
+	var used int64
+	if err := db.DB.Model(&models.Credential{}).Where("template_id = ?", t.ID).Count(&used).Error; err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	if used > 0 {
+		if err := db.DB.Model(&t).Update("active", false).Error; err != nil {
+			http.Error(w, "database error", http.StatusInternalServerError)
+			return
+		}
+		writeJSONResp(w, http.StatusOK, map[string]any{"id": t.ID, "deactivated": true})
+		return
+	}
+	if err := db.DB.Delete(&t).Error; err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	writeJSONResp(w, http.StatusOK, map[string]any{"id": t.ID, "deleted": true})
+}
+
+type uploadMetadataReq struct {
+	TemplateID   *uint `json:"template_id"`
+	TranscriptID *uint `json:"transcript_id"`
+	// Trait types to encrypt for the student and issuer instead of pinning
+	Private []string `json:"private"`
+	ipfs.Issuance
+	// Present only in the old raw-metadata body, which is no longer pinned
+	Attributes json.RawMessage `json:"attributes"`
+}
+
+// POST /api/uploadtoipfs (protected, verified org)
+// The body is an ipfs.Issuance (student_wallet, degree_name, type, major,
+// issued_date, graduation_date, description, dean_sig, values, image,
+// animation_url). With template_id the metadata is built from the template;
+// without it, values become free-form attributes and only orgs without
+// templates may do that. The issuer wallet is always the authenticated
+// org's, the canonical credential hash is added as the "Credential Hash"
+// attribute, and transcript_id adds the transcript's hash as "Transcript
+// Hash". Attributes named in private are encrypted (see sealPrivateTraits)
+// and only their commitment is pinned. The fields are then salted for
+// selective disclosure: attributes other than the standard traits are left
+// out of the pinned metadata, which carries the root of the disclosure
+// digests as "Disclosure Commitment". image and animation_url must be
+// ipfs:// URIs of assets the org uploaded to /api/v1/org/assets; when image
+// or background_color is unset the org's branding fills it. The result
+// is validated against ipfs.MetadataSchema before it is pinned.
+func UploadCredentialMetadata(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	var req uploadMetadataReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if len(req.Attributes) > 0 {
+		http.Error(w, "raw metadata is not accepted; send the credential fields (student_wallet, degree_name, type, issued_date, ...) and values", http.StatusBadRequest)
+		return
+	}
+	tpl, ok := resolveTemplate(w, org.ID, req.TemplateID)
+	if !ok {
+		return
+	}
+
+	req.IssuerWallet = org.MetamaskAddress
+	var md *ipfs.Credentials
+	var problems []string
+	if tpl != nil {
+		md, problems = ipfs.BuildFromTemplate(*tpl, req.Issuance)
+	} else {
+		md, problems = ipfs.BuildMetadata(req.Issuance)
+	}
+	if len(problems) == 0 {
+		problems = assetProblems(org.ID, map[string]string{"image": md.Image, "animation_url": md.AnimationURL})
+	}
+	if len(problems) == 0 {
+		brand, err := branding.Load(org.ID)
+		if err != nil {
+			http.Error(w, "failed to load branding", http.StatusInternalServerError)
+			return
+		}
+		branding.ApplyToMetadata(md, brand)
+	}
+	if len(problems) > 0 {
+		writeJSONResp(w, http.StatusUnprocessableEntity, map[string]any{"error": "invalid credential metadata", "problems": problems})
+		return
+	}
+	var env *models.PrivateEnvelope
+	if len(req.Private) > 0 {
+		env, problems = sealPrivateTraits(org, strings.TrimSpace(req.StudentWallet), md, req.Private)
+		if len(problems) > 0 {
+			writeJSONResp(w, http.StatusUnprocessableEntity, map[string]any{"error": "cannot encrypt private attributes", "problems": problems})
+			return
+		}
+	}
+	if req.TranscriptID != nil {
+		var t models.Transcript
+		if err := db.DB.Where("id = ? AND organization_id = ?", *req.TranscriptID, org.ID).First(&t).Error; err != nil {
+			http.Error(w, "transcript not found", http.StatusNotFound)
+			return
+		}
+		if t.CredentialID != nil || !equalCaseInsensitive(t.StudentWallet, strings.TrimSpace(req.StudentWallet)) {
+			http.Error(w, "transcript is already linked or belongs to another student", http.StatusUnprocessableEntity)
+			return
+		}
+		ipfs.SetTrait(md, ipfs.TraitTranscriptHash, t.Hash)
+	}
+	sd, err := disclosure.Withhold(md, studentNameForWallet(strings.TrimSpace(req.StudentWallet)))
+	if err != nil {
+		writeJSONResp(w, http.StatusUnprocessableEntity, map[string]any{"error": "invalid credential metadata", "problems": []string{err.Error()}})
+		return
+	}
+	hash, _ := (&ipfs.Metadata{Attributes: md.Attributes}).Trait(ipfs.TraitCredentialHash)
+
+	link, size, err := ipfs.PinMetadata(r.Context(), md)
+	var schemaErr *ipfs.SchemaError
+	if errors.As(err, &schemaErr) {
+		writeJSONResp(w, http.StatusUnprocessableEntity, map[string]any{"error": "invalid credential metadata", "problems": schemaErr.Problems})
+		return
+	}
+	if err != nil {
+		fmt.Println("IPFS upload failed:", err)
+		http.Error(w, "failed to upload to IPFS", http.StatusInternalServerError)
+		return
+	}
+	fmt.Println(link)
+	if err := jobs.RecordPin(link, size, "credential.json", org.MetamaskAddress, &org.ID); err != nil {
+		fmt.Println("Failed to record pin:", err)
+	}
+	resp := map[string]any{
+		"ipfslink":       link,
+		"gateway_url":    ipfs.GatewayURL(link),
+		"metadata":       md,
+		"canonical_hash": hash,
+		// The fields the holder can later disclose selectively
+		"disclosure_commitment": sd.Root,
+	}
+	if env != nil {
+		if err := db.DB.Create(env).Error; err != nil {
+			fmt.Println("Failed to store private envelope:", err)
+			http.Error(w, "database error", http.StatusInternalServerError)
+			return
+		}
+		resp["private_commitment"] = env.Commitment
+	}
+	if err := db.DB.Create(&models.DisclosureSet{
+		OrganizationID: org.ID,
+		StudentWallet:  strings.TrimSpace(req.StudentWallet),
+		Root:           sd.Root,
+		Disclosures:    sd.Disclosures,
+		Digests:        sd.Digests,
+		Withheld:       sd.Withheld,
+	}).Error; err != nil {
+		fmt.Println("Failed to store disclosures:", err)
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	writeJSONResp(w, http.StatusOK, resp)
+}
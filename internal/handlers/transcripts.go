@@ -0,0 +1,466 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+
+	"vericred/internal/db"
+	"vericred/internal/eth/ipfs"
+	"vericred/internal/models"
+	"vericred/internal/transcript"
+)
+
+var errTranscriptUnmatched = errors.New("metadata names no unlinked transcript of this student")
+
+// transcriptRow is one course line of a transcript CSV.
+type transcriptRow struct {
+	Row           int
+	StudentWallet string
+	CredentialID  string
+	Term          string
+	TermSequence  int
+	CourseCode    string
+	CourseTitle   string
+	Credits       float64
+	Grade         string
+	GradePoints   *float64
+	CGPA          *float64
+	GradeScale    float64
+}
+
+// key groups rows into transcripts: by credential when given, otherwise by
+// student wallet.
+func (r transcriptRow) key() string {
+	if r.CredentialID != "" {
+		return "cred:" + r.CredentialID
+	}
+	return "wallet:" + strings.ToLower(r.StudentWallet)
+}
+
+// parseTranscriptCSV reads course rows from CSV with a header row. Columns:
+// student_wallet, credential_id, term, term_sequence, course_code,
+// course_title, credits, grade, grade_points, cgpa, grade_scale. Problems are
+// collected per row rather than failing on the first.
+func parseTranscriptCSV(rd io.Reader) ([]transcriptRow, []rowProblems, error) {
+	reader := csv.NewReader(rd)
+	reader.TrimLeadingSpace = true
+	headers, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read CSV header: %w", err)
+	}
+	for i := range headers {
+		headers[i] = strings.ToLower(strings.TrimSpace(headers[i]))
+	}
+
+	var rows []transcriptRow
+	var bad []rowProblems
+	for n := 1; ; n++ {
+		rec, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, bad, nil
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read CSV row %d: %w", n, err)
+		}
+		row := transcriptRow{Row: n}
+		var problems []string
+		number := func(h, v string) (float64, bool) {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil || f < 0 {
+				problems = append(problems, fmt.Sprintf("%s %q is not a non-negative number", h, v))
+				return 0, false
+			}
+			return f, true
+		}
+		for i, h := range headers {
+			if i >= len(rec) {
+				break
+			}
+			v := strings.TrimSpace(rec[i])
+			if v == "" {
+				continue
+			}
+			switch h {
+			case "student_wallet":
+				row.StudentWallet = v
+			case "credential_id":
+				row.CredentialID = v
+			case "term":
+				row.Term = v
+			case "term_sequence":
+				seq, err := strconv.Atoi(v)
+				if err != nil || seq < 1 {
+					problems = append(problems, fmt.Sprintf("term_sequence %q must be a positive integer", v))
+				}
+				row.TermSequence = seq
+			case "course_code":
+				row.CourseCode = v
+			case "course_title":
+				row.CourseTitle = v
+			case "credits":
+				row.Credits, _ = number(h, v)
+			case "grade":
+				row.Grade = v
+			case "grade_points":
+				if f, ok := number(h, v); ok {
+					row.GradePoints = &f
+				}
+			case "cgpa":
+				if f, ok := number(h, v); ok {
+					row.CGPA = &f
+				}
+			case "grade_scale":
+				row.GradeScale, _ = number(h, v)
+			}
+		}
+		if row.StudentWallet == "" && row.CredentialID == "" {
+			problems = append(problems, "student_wallet or credential_id is required")
+		}
+		if row.StudentWallet != "" && !common.IsHexAddress(row.StudentWallet) {
+			problems = append(problems, fmt.Sprintf("student_wallet %q is not an address", row.StudentWallet))
+		}
+		if row.Term == "" {
+			problems = append(problems, "term is required")
+		}
+		if row.CourseCode == "" {
+			problems = append(problems, "course_code is required")
+		}
+		if row.Grade == "" {
+			problems = append(problems, "grade is required")
+		}
+		if row.GradeScale > 0 && row.GradePoints != nil && *row.GradePoints > row.GradeScale {
+			problems = append(problems, fmt.Sprintf("grade_points %v exceed grade_scale %v", *row.GradePoints, row.GradeScale))
+		}
+		if len(problems) > 0 {
+			bad = append(bad, rowProblems{Row: n, Problems: problems})
+			continue
+		}
+		rows = append(rows, row)
+	}
+}
+
+// buildTranscripts groups rows into transcripts for org, resolving
+// credential ids and checking per-transcript consistency. Rows that make a
+// transcript invalid are reported against that transcript's first row.
+func buildTranscripts(org models.Organization, rows []transcriptRow) ([]models.Transcript, []rowProblems, error) {
+	var order []string
+	groups := map[string][]transcriptRow{}
+	for _, r := range rows {
+		k := r.key()
+		if _, ok := groups[k]; !ok {
+			order = append(order, k)
+		}
+		groups[k] = append(groups[k], r)
+	}
+
+	var out []models.Transcript
+	var bad []rowProblems
+	for _, k := range order {
+		g := groups[k]
+		first := g[0]
+		var problems []string
+		t := models.Transcript{OrganizationID: org.ID, StudentWallet: first.StudentWallet}
+
+		if first.CredentialID != "" {
+			var cred models.Credential
+			err := db.DB.Where("id = ? AND organization_id = ?", first.CredentialID, org.ID).First(&cred).Error
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				problems = append(problems, fmt.Sprintf("credential %s not found for this organization", first.CredentialID))
+			case err != nil:
+				return nil, nil, err
+			default:
+				if t.StudentWallet != "" && !equalCaseInsensitive(t.StudentWallet, cred.StudentWallet) {
+					problems = append(problems, "student_wallet does not match the credential's student")
+				}
+				t.StudentWallet = cred.StudentWallet
+				var linked int64
+				if err := db.DB.Model(&models.Transcript{}).Where("credential_id = ?", cred.ID).Count(&linked).Error; err != nil {
+					return nil, nil, err
+				}
+				if linked > 0 {
+					problems = append(problems, fmt.Sprintf("credential %s already has a transcript", cred.ID))
+				}
+				id := cred.ID
+				t.CredentialID = &id
+			}
+		}
+
+		var cgpa *float64
+		terms := map[string]int{}
+		courses := map[string]bool{}
+		for _, r := range g {
+			if r.StudentWallet != "" && t.StudentWallet != "" && !equalCaseInsensitive(r.StudentWallet, t.StudentWallet) {
+				problems = append(problems, fmt.Sprintf("row %d: student_wallet differs from the transcript's student", r.Row))
+			}
+			if r.CGPA != nil {
+				if cgpa != nil && *cgpa != *r.CGPA {
+					problems = append(problems, fmt.Sprintf("row %d: cgpa differs from an earlier row", r.Row))
+				}
+				cgpa = r.CGPA
+			}
+			if r.GradeScale > 0 {
+				t.GradeScale = r.GradeScale
+			}
+			i, ok := terms[r.Term]
+			if !ok {
+				i = len(t.Terms)
+				terms[r.Term] = i
+				seq := r.TermSequence
+				if seq == 0 {
+					seq = i + 1
+				}
+				t.Terms = append(t.Terms, models.TranscriptTerm{Name: r.Term, Sequence: seq})
+			} else if r.TermSequence != 0 && r.TermSequence != t.Terms[i].Sequence {
+				problems = append(problems, fmt.Sprintf("row %d: term %q has conflicting term_sequence", r.Row, r.Term))
+			}
+			ck := r.Term + "\x00" + strings.ToLower(r.CourseCode)
+			if courses[ck] {
+				problems = append(problems, fmt.Sprintf("row %d: course %s appears twice in term %q", r.Row, r.CourseCode, r.Term))
+			}
+			courses[ck] = true
+			t.Terms[i].Courses = append(t.Terms[i].Courses, models.TranscriptCourse{
+				Code:        r.CourseCode,
+				Title:       r.CourseTitle,
+				Credits:     r.Credits,
+				Grade:       r.Grade,
+				GradePoints: r.GradePoints,
+			})
+		}
+		// Terms are ordered (and hashed) by sequence, so two terms sharing one
+		// would make the order depend on the row order of the upload.
+		seqTerms := map[int]string{}
+		for _, term := range t.Terms {
+			if other, dup := seqTerms[term.Sequence]; dup {
+				problems = append(problems, fmt.Sprintf("terms %q and %q have the same term_sequence %d", other, term.Name, term.Sequence))
+			}
+			seqTerms[term.Sequence] = term.Name
+		}
+		if t.GradeScale > 0 && cgpa != nil && *cgpa > t.GradeScale {
+			problems = append(problems, fmt.Sprintf("cgpa %v exceeds grade_scale %v", *cgpa, t.GradeScale))
+		}
+		if len(problems) > 0 {
+			bad = append(bad, rowProblems{Row: first.Row, Problems: problems})
+			continue
+		}
+		transcript.Normalize(&t, cgpa)
+		t.Hash = transcript.Hash(t)
+		out = append(out, t)
+	}
+	return out, bad, nil
+}
+
+// POST /api/v1/org/transcripts/upload (protected, verified org)
+// CSV (text/csv body or multipart field "file"), one row per course. Rows are
+// grouped into transcripts by credential_id, or by student_wallet for
+// transcripts uploaded ahead of issuance. Nothing is stored unless every
+// row passes.
+func UploadTranscripts(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+
+	var body io.Reader = r.Body
+	ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if ct == "multipart/form-data" {
+		if err := r.ParseMultipartForm(10 << 20); err != nil {
+			http.Error(w, "failed to parse form", http.StatusBadRequest)
+			return
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "CSV file field 'file' is required", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	rows, bad, err := parseTranscriptCSV(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(rows) == 0 && len(bad) == 0 {
+		http.Error(w, "no transcript rows", http.StatusBadRequest)
+		return
+	}
+	transcripts, groupBad, err := buildTranscripts(org, rows)
+	if err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	bad = append(bad, groupBad...)
+	if len(bad) > 0 {
+		writeJSONResp(w, http.StatusUnprocessableEntity, map[string]any{
+			"error": "transcript rows failed validation",
+			"rows":  bad,
+		})
+		return
+	}
+
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		for i := range transcripts {
+			if err := tx.Create(&transcripts[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		fmt.Println("Transcript upload failed:", err)
+		http.Error(w, "failed to store transcripts", http.StatusInternalServerError)
+		return
+	}
+
+	summary := make([]map[string]any, 0, len(transcripts))
+	for _, t := range transcripts {
+		summary = append(summary, map[string]any{
+			"id":             t.ID,
+			"student_wallet": t.StudentWallet,
+			"credential_id":  t.CredentialID,
+			"terms":          len(t.Terms),
+			"total_credits":  t.TotalCredits,
+			"cgpa":           t.CGPA,
+			"hash":           t.Hash,
+		})
+	}
+	writeJSONResp(w, http.StatusCreated, map[string]any{"transcripts": summary})
+}
+
+// GET /api/v1/org/transcripts?student_wallet=&unlinked=true (protected, verified org)
+func ListTranscripts(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	q := db.DB.Where("organization_id = ?", org.ID)
+	if wallet := strings.TrimSpace(r.URL.Query().Get("student_wallet")); wallet != "" {
+		q = q.Where("LOWER(student_wallet) = LOWER(?)", wallet)
+	}
+	if r.URL.Query().Get("unlinked") == "true" {
+		q = q.Where("credential_id IS NULL")
+	}
+	var list []models.Transcript
+	if err := q.Order("created_at DESC").Limit(200).Find(&list).Error; err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	writeJSONResp(w, http.StatusOK, list)
+}
+
+// DELETE /api/v1/org/transcripts/{id} (protected, verified org)
+// Only transcripts not yet linked to a credential can be deleted.
+func DeleteTranscript(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	var t models.Transcript
+	if err := db.DB.Where("id = ? AND organization_id = ?", chi.URLParam(r, "id"), org.ID).First(&t).Error; err != nil {
+		http.Error(w, "transcript not found", http.StatusNotFound)
+		return
+	}
+	if t.CredentialID != nil {
+		http.Error(w, "transcript is linked to a credential", http.StatusConflict)
+		return
+	}
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		termIDs := tx.Model(&models.TranscriptTerm{}).Select("id").Where("transcript_id = ?", t.ID)
+		if err := tx.Where("term_id IN (?)", termIDs).Delete(&models.TranscriptCourse{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("transcript_id = ?", t.ID).Delete(&models.TranscriptTerm{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&t).Error
+	}); err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	writeJSONResp(w, http.StatusOK, map[string]any{"id": t.ID, "deleted": true})
+}
+
+// loadTranscript loads a transcript with its terms and courses in order.
+func loadTranscript(q *gorm.DB) (models.Transcript, error) {
+	var t models.Transcript
+	err := q.Preload("Terms", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("sequence ASC")
+	}).Preload("Terms.Courses", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("code ASC")
+	}).First(&t).Error
+	return t, err
+}
+
+// transcriptForMetadata finds the transcript an issuance's metadata points to
+// through its Transcript Hash trait. It must belong to org and the student,
+// and be unlinked or linked to allowLinked (the credential being reissued).
+// No trait means no transcript.
+func transcriptForMetadata(orgID uint, studentWallet string, md *ipfs.Metadata, allowLinked string) (*models.Transcript, error) {
+	hash, ok := md.Trait(ipfs.TraitTranscriptHash)
+	if !ok || hash == "" {
+		return nil, nil
+	}
+	var list []models.Transcript
+	if err := db.DB.Where("organization_id = ? AND hash = ? AND LOWER(student_wallet) = LOWER(?)", orgID, hash, studentWallet).
+		Find(&list).Error; err != nil {
+		return nil, err
+	}
+	for i := range list {
+		if list[i].CredentialID == nil || *list[i].CredentialID == allowLinked {
+			return &list[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s %s", errTranscriptUnmatched, ipfs.TraitTranscriptHash, hash)
+}
+
+// transcriptView is the transcript section of verification responses, or
+// nil when the credential has none. hash_valid re-derives the hash from the
+// stored courses; anchored means the pinned metadata carries that hash.
+func transcriptView(cred models.Credential, md *ipfs.Metadata) map[string]any {
+	t, err := loadTranscript(db.DB.Where("credential_id = ?", cred.ID))
+	if err != nil {
+		return nil
+	}
+	anchored := false
+	if md != nil {
+		if h, ok := md.Trait(ipfs.TraitTranscriptHash); ok {
+			anchored = strings.EqualFold(h, t.Hash)
+		}
+	}
+	return map[string]any{
+		"transcript": t,
+		"hash_valid": transcript.Hash(t) == t.Hash,
+		"anchored":   anchored,
+	}
+}
+
+// GET /api/v1/credentials/{id}/transcript (holder/issuer session or ?token=)
+func CredentialTranscript(w http.ResponseWriter, r *http.Request) {
+	var cred models.Credential
+	if err := db.DB.Where("id = ?", chi.URLParam(r, "id")).First(&cred).Error; err != nil {
+		http.Error(w, "credential not found", http.StatusNotFound)
+		return
+	}
+	if !canReadCredential(r, cred.ID, cred.StudentWallet, cred.UniversityWallet) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	md, _ := ipfs.FetchMetadata(r.Context(), cred.IPFSLink)
+	view := transcriptView(cred, md)
+	if view == nil {
+		http.Error(w, "credential has no transcript", http.StatusNotFound)
+		return
+	}
+	writeJSONResp(w, http.StatusOK, view)
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+
+	"vericred/internal/db"
+	"vericred/internal/models"
+	"vericred/internal/vc"
+)
+
+// renderVC builds the VC for cred, attaching the stored issuer proof if any.
+func renderVC(cred models.Credential) vc.Credential {
+	doc := vc.FromCredential(cred)
+	if cred.VCProof != "" {
+		var p vc.Proof
+		if err := json.Unmarshal([]byte(cred.VCProof), &p); err == nil {
+			doc.Proof = &p
+		}
+	}
+	return doc
+}
+
+// GET /api/v1/credentials/{id}/vc
+// Holder or issuer session, or ?token= share token.
+func ExportVerifiableCredential(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var cred models.Credential
+	if err := db.DB.Preload("Organization").Where("id = ?", id).First(&cred).Error; err != nil {
+		http.Error(w, "credential not found", http.StatusNotFound)
+		return
+	}
+	if !canReadCredential(r, cred.ID, cred.StudentWallet, cred.UniversityWallet) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vc+ld+json")
+	_ = json.NewEncoder(w).Encode(renderVC(cred))
+}
+
+// GET /api/v1/credentials/{id}/vc/signing-payload (protected, issuing org)
+// Returns the EIP-712 typed data to sign with eth_signTypedData_v4.
+func VCSigningPayload(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	cred, ok := issuedCredential(w, org, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+	writeJSONResp(w, http.StatusOK, vc.TypedData(vc.FromCredential(cred)))
+}
+
+// POST /api/v1/credentials/{id}/vc/proof (protected, issuing org)
+// Body: { signature } — the issuer's signature over the signing payload.
+func AttachVCProof(w http.ResponseWriter, r *http.Request) {
+	org, ok := requireIssuer(w, r)
+	if !ok {
+		return
+	}
+	cred, ok := issuedCredential(w, org, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Signature string `json:"signature"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || strings.TrimSpace(body.Signature) == "" {
+		http.Error(w, "signature is required", http.StatusBadRequest)
+		return
+	}
+
+	doc := vc.FromCredential(cred)
+	doc.Proof = vc.NewProof(doc, strings.TrimSpace(body.Signature), time.Now())
+	if _, err := vc.VerifySignature(doc); err != nil {
+		http.Error(w, "invalid signature: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	proof, err := json.Marshal(doc.Proof)
+	if err != nil {
+		http.Error(w, "failed to encode proof", http.StatusInternalServerError)
+		return
+	}
+	if err := db.DB.Model(&models.Credential{}).Where("id = ?", cred.ID).Update("vc_proof", string(proof)).Error; err != nil {
+		http.Error(w, "failed to store proof", http.StatusInternalServerError)
+		return
+	}
+	writeJSONResp(w, http.StatusOK, doc)
+}
+
+type verifyCheck struct {
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// POST /api/v1/vc/verify (public)
+// Body: a VC as returned by the export endpoint. Checks the issuer proof,
+// that the issuer is a registered verified organization, that the
+// credential has not been revoked and that its signatory attestation is valid.
+func VerifyVerifiableCredential(w http.ResponseWriter, r *http.Request) {
+	var doc vc.Credential
+	if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	checks := map[string]verifyCheck{}
+
+	signer, err := vc.VerifySignature(doc)
+	if err != nil {
+		checks["signature"] = verifyCheck{OK: false, Detail: err.Error()}
+	} else {
+		checks["signature"] = verifyCheck{OK: true, Detail: "signed by " + signer.Hex()}
+	}
+
+	issuerAddr, err := vc.AddressFromDID(doc.Issuer.ID)
+	var org models.Organization
+	switch {
+	case err != nil:
+		checks["issuer"] = verifyCheck{OK: false, Detail: err.Error()}
+	default:
+		err = db.DB.Where("LOWER(metamask_address) = LOWER(?)", issuerAddr.Hex()).First(&org).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			checks["issuer"] = verifyCheck{OK: false, Detail: "issuer is not a registered organization"}
+		case err != nil:
+			http.Error(w, "database error", http.StatusInternalServerError)
+			return
+		case !org.IsVerified:
+			checks["issuer"] = verifyCheck{OK: false, Detail: org.OrgName + " is registered but not verified"}
+		default:
+			checks["issuer"] = verifyCheck{OK: true, Detail: org.OrgName}
+		}
+	}
+
+	var cred models.Credential
+	credID, isURN := strings.CutPrefix(doc.ID, "urn:uuid:")
+	if isURN {
+		err = db.DB.Where("id = ?", credID).First(&cred).Error
+	}
+	switch {
+	case !isURN || err != nil:
+		checks["status"] = verifyCheck{OK: false, Detail: "credential is unknown to this registry"}
+	case cred.Status == models.CredentialStatusRevoked:
+		checks["status"] = verifyCheck{OK: false, Detail: fmt.Sprintf("revoked: %s", cred.RevocationReason)}
+	case cred.Status == models.CredentialStatusSuperseded:
+		checks["status"] = verifyCheck{OK: false, Detail: fmt.Sprintf("superseded by urn:uuid:%s: %s", currentCredentialID(cred), cred.SupersessionReason)}
+	case !equalCaseInsensitive(cred.UniversityWallet, issuerAddr.Hex()):
+		checks["status"] = verifyCheck{OK: false, Detail: "credential was not issued by this issuer"}
+	default:
+		checks["status"] = verifyCheck{OK: true, Detail: "active"}
+	}
+
+	// The signatory attestation is checked against the registry's copy of
+	// the credential
+	var signatory map[string]any
+	if isURN && cred.ID != "" {
+		signatory = signatoryReport(cred)
+		ok := signatory["signature_valid"] == true && signatory["key_valid_at_issue"] == true
+		detail, _ := signatory["detail"].(string)
+		if s, found := signatory["signatory"].(map[string]any); found && ok {
+			detail = fmt.Sprintf("%s (%s)", s["name"], s["role"])
+		}
+		checks["signatory"] = verifyCheck{OK: ok, Detail: detail}
+	}
+
+	verified := true
+	for _, c := range checks {
+		verified = verified && c.OK
+	}
+	resp := map[string]any{
+		"verified": verified,
+		"checks":   checks,
+	}
+	if cred.SupersededByID != nil {
+		resp["current_credential_id"] = currentCredentialID(cred)
+	}
+	if signatory != nil {
+		resp["signatory"] = signatory
+	}
+	writeJSONResp(w, http.StatusOK, resp)
+}
@@ -0,0 +1,429 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
+
+	"vericred/internal/db"
+	"vericred/internal/eip712"
+	"vericred/internal/eth"
+	"vericred/internal/eth/ipfs"
+	"vericred/internal/models"
+)
+
+// Verdicts returned by the verification endpoint.
+const (
+	verdictValid   = "valid"
+	verdictInvalid = "invalid"
+)
+
+// verdictSubject is what a verification request resolved to: the registry
+// row when there is one, and the token to check on-chain.
+type verdictSubject struct {
+	cred    *models.Credential
+	tokenID *big.Int
+	// limited is set for selective share links and for callers naming a
+	// credential they may not read: they get the verdict and checks, not
+	// the record or anything that leads back to it.
+	limited bool
+}
+
+// resolveVerdictSubject reads exactly one of token_id, credential_id or
+// token (a share token) from the query.
+func resolveVerdictSubject(w http.ResponseWriter, r *http.Request) (verdictSubject, bool) {
+	q := r.URL.Query()
+	tokenIDStr := strings.TrimSpace(q.Get("token_id"))
+	credID := strings.TrimSpace(q.Get("credential_id"))
+	share := strings.TrimSpace(q.Get("token"))
+	given := 0
+	for _, v := range []string{tokenIDStr, credID, share} {
+		if v != "" {
+			given++
+		}
+	}
+	if given != 1 {
+		http.Error(w, "pass exactly one of token_id, credential_id or token", http.StatusBadRequest)
+		return verdictSubject{}, false
+	}
+
+	var s verdictSubject
+	if share != "" {
+		claims, err := parseShareToken(share)
+		if errors.Is(err, errShareSecret) {
+			http.Error(w, "server misconfigured", http.StatusInternalServerError)
+			return s, false
+		}
+		if err != nil {
+			http.Error(w, "This verification link is invalid or has expired.", http.StatusUnauthorized)
+			return s, false
+		}
+		credID = claims.CredentialID
+		s.limited = claims.Selective
+	}
+
+	var cred models.Credential
+	var err error
+	if tokenIDStr != "" {
+		id, ok := new(big.Int).SetString(tokenIDStr, 10)
+		if !ok || id.Sign() < 0 {
+			http.Error(w, "token_id must be a non-negative integer", http.StatusBadRequest)
+			return s, false
+		}
+		s.tokenID = id
+		// A token minted outside this registry is still checked on-chain
+		err = db.DB.Where("token_id = ?", id.String()).Order("created_at DESC").First(&cred).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return s, true
+		}
+	} else {
+		err = db.DB.Where("id = ?", credID).First(&cred).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "credential not found", http.StatusNotFound)
+			return s, false
+		}
+	}
+	if err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return s, false
+	}
+	s.cred = &cred
+	if tokenIDStr == "" && share == "" {
+		s.limited = !canReadCredential(r, cred.ID, cred.StudentWallet, cred.UniversityWallet)
+	}
+	if s.tokenID == nil && cred.TokenID != "" {
+		s.tokenID, _ = new(big.Int).SetString(cred.TokenID, 10)
+	}
+	return s, true
+}
+
+// verdictStartBlock is where mint event scans begin when the mint transaction
+// isn't recorded; it shares RECONCILE_START_BLOCK (the contract's deployment
+// block).
+func verdictStartBlock() uint64 {
+	n, _ := strconv.ParseUint(strings.TrimSpace(os.Getenv("RECONCILE_START_BLOCK")), 10, 64)
+	return n
+}
+
+// contentCheck passes when the metadata was hashed and matched its CID.
+func contentCheck(res *ipfs.Resolution, fetchErr error) verifyCheck {
+	switch {
+	case res.Verified():
+		return verifyCheck{OK: true, Detail: fmt.Sprintf("%s matches its CID (from %s)", res.CID, res.Source)}
+	case res != nil && res.Integrity == ipfs.IntegrityNotIPFS:
+		return verifyCheck{OK: false, Detail: "metadata is not content-addressed"}
+	case res != nil:
+		return verifyCheck{OK: false, Detail: fmt.Sprintf("%s cannot be recomputed from the document", res.CID)}
+	case errors.Is(fetchErr, ipfs.ErrCIDMismatch):
+		return verifyCheck{OK: false, Detail: "every source served content that does not match the CID"}
+	default:
+		return verifyCheck{OK: false, Detail: "skipped: no metadata resolved"}
+	}
+}
+
+// checkMinter finds who minted tokenID and whether that account is a
+// verified organization on-chain and, when known, the credential's issuer.
+// Batch-issued credentials (platformIssued) are minted by the platform's own
+// wallet on the issuer's behalf, so for them that wallet stands in for the
+// issuer. The error is non-nil only when the node could not be reached, so
+// an outage never fails the check.
+func checkMinter(ctx context.Context, chain *eth.Client, tokenID *big.Int, recordedTx, expectedIssuer string, platformIssued bool) (verifyCheck, error) {
+	var txHash common.Hash
+	if recordedTx != "" {
+		txHash = common.HexToHash(recordedTx)
+	} else {
+		h, err := chain.MintTxOf(ctx, tokenID, verdictStartBlock())
+		if errors.Is(err, eth.ErrUnavailable) {
+			return verifyCheck{}, err
+		}
+		if err != nil {
+			return verifyCheck{OK: false, Detail: fmt.Sprintf("mint transaction not found: %v", err)}, nil
+		}
+		txHash = h
+	}
+	minter, err := chain.TxSender(ctx, txHash)
+	if errors.Is(err, eth.ErrUnavailable) {
+		return verifyCheck{}, err
+	}
+	if err != nil {
+		return verifyCheck{OK: false, Detail: fmt.Sprintf("could not read mint transaction: %v", err)}, nil
+	}
+	verified, err := chain.IsVerifiedOrg(ctx, minter)
+	if errors.Is(err, eth.ErrUnavailable) {
+		return verifyCheck{}, err
+	}
+	if err != nil {
+		return verifyCheck{OK: false, Detail: fmt.Sprintf("could not check %s on-chain: %v", minter.Hex(), err)}, nil
+	}
+	name := minter.Hex()
+	var org models.Organization
+	if err := db.DB.Where("LOWER(metamask_address) = LOWER(?)", minter.Hex()).First(&org).Error; err == nil {
+		name = fmt.Sprintf("%s (%s)", org.OrgName, minter.Hex())
+	}
+	switch {
+	case !verified:
+		return verifyCheck{OK: false, Detail: "minted by " + name + ", which is not a verified organization on-chain"}, nil
+	case expectedIssuer != "" && equalCaseInsensitive(expectedIssuer, minter.Hex()):
+		return verifyCheck{OK: true, Detail: "minted by " + name}, nil
+	case platformIssued && isPlatformMinter(chain, minter):
+		return verifyCheck{OK: true, Detail: fmt.Sprintf("minted by the platform wallet %s on behalf of %s", minter.Hex(), expectedIssuer)}, nil
+	case expectedIssuer != "":
+		return verifyCheck{OK: false, Detail: fmt.Sprintf("minted by %s, not the credential's issuer %s", name, expectedIssuer)}, nil
+	default:
+		return verifyCheck{OK: true, Detail: "minted by " + name}, nil
+	}
+}
+
+// isPlatformMinter reports whether minter is the wallet this server mints
+// from, now or in the past (a rotated key still has its transactions on
+// record).
+func isPlatformMinter(chain *eth.Client, minter common.Address) bool {
+	if signer, err := chain.Signer(); err == nil && signer.Address() == minter {
+		return true
+	}
+	var n int64
+	db.DB.Model(&models.ChainTransaction{}).
+		Where("LOWER(sender) = LOWER(?) AND kind = ?", minter.Hex(), eth.TxKindMint).
+		Count(&n)
+	return n > 0
+}
+
+// batchIssued reports whether cred was created by the issuance worker.
+func batchIssued(cred models.Credential) bool {
+	var n int64
+	db.DB.Model(&models.IssuanceJobItem{}).Where("credential_id = ?", cred.ID).Count(&n)
+	return n > 0
+}
+
+// chainUnavailable answers 503 for a chain read that failed because the
+// node could not be reached.
+func chainUnavailable(w http.ResponseWriter, what string, err error) {
+	fmt.Println("verify: "+what+":", err)
+	http.Error(w, "blockchain node unavailable, try again later", http.StatusServiceUnavailable)
+}
+
+// metadataHashCheck checks the canonical hash of metadata that has no
+// registry row to compare with: its Credential Hash attribute must match its
+// own attributes.
+func metadataHashCheck(md *ipfs.Metadata) verifyCheck {
+	f, err := ipfs.CanonicalFields(md.Attributes, md.Name)
+	if err != nil {
+		return verifyCheck{OK: false, Detail: err.Error()}
+	}
+	hash, err := eip712.CredentialHash(f)
+	if err != nil {
+		return verifyCheck{OK: false, Detail: "failed to hash metadata"}
+	}
+	got, ok := md.Trait(ipfs.TraitCredentialHash)
+	switch {
+	case !ok:
+		return verifyCheck{OK: false, Detail: "metadata has no Credential Hash attribute"}
+	case !eip712.CredentialHashMatches(f, got):
+		return verifyCheck{OK: false, Detail: fmt.Sprintf("metadata hash %s does not match its attributes (%s)", got, hash)}
+	default:
+		return verifyCheck{OK: true, Detail: hash}
+	}
+}
+
+// GET /api/v1/verify?token_id=|credential_id=|token= (public)
+// Runs every check a verifier needs in one call: the token exists, its owner
+// is the credential's student, the minter is a verified organization, the
+// metadata behind tokenURI is reachable and all hashes agree, the credential
+// is active and its signatory attestation is valid. verdict is "valid" only
+// when every check passes. By credential_id, only the holder or issuer sees
+// the record; anyone else gets the verdict and bare checks.
+func VerifyCredentialVerdict(w http.ResponseWriter, r *http.Request) {
+	s, ok := resolveVerdictSubject(w, r)
+	if !ok {
+		return
+	}
+	cred := s.cred
+	checks := map[string]verifyCheck{}
+	// A node outage is not evidence against the credential, so it fails the
+	// request rather than the verdict
+	chain, err := eth.Default(r.Context())
+	if err != nil {
+		chainUnavailable(w, "chain client", err)
+		return
+	}
+
+	// Token exists and is held by the student
+	var owner common.Address
+	tokenExists := false
+	if s.tokenID == nil {
+		checks["token"] = verifyCheck{OK: false, Detail: "credential has no token id (not minted or not yet reconciled)"}
+	} else if o, err := chain.OwnerOf(r.Context(), s.tokenID); errors.Is(err, eth.ErrUnavailable) {
+		chainUnavailable(w, "ownerOf", err)
+		return
+	} else if errors.Is(err, eth.ErrTokenNotFound) {
+		checks["token"] = verifyCheck{OK: false, Detail: fmt.Sprintf("token %s does not exist on-chain", s.tokenID)}
+	} else if err != nil {
+		checks["token"] = verifyCheck{OK: false, Detail: fmt.Sprintf("token %s could not be read on-chain: %v", s.tokenID, err)}
+	} else {
+		owner, tokenExists = o, true
+		checks["token"] = verifyCheck{OK: true, Detail: "token " + s.tokenID.String()}
+	}
+
+	// Metadata behind the on-chain tokenURI
+	var md *ipfs.Metadata
+	var res *ipfs.Resolution
+	var fetchErr error
+	if tokenExists {
+		uri, err := chain.TokenURI(r.Context(), s.tokenID)
+		switch {
+		case errors.Is(err, eth.ErrUnavailable):
+			chainUnavailable(w, "tokenURI", err)
+			return
+		case err != nil:
+			fetchErr = err
+			checks["metadata"] = verifyCheck{OK: false, Detail: fmt.Sprintf("tokenURI lookup failed: %v", err)}
+		case cred != nil && ipfs.ContentKey(uri) != ipfs.ContentKey(cred.IPFSLink):
+			fetchErr = errors.New("tokenURI does not point at the credential's metadata")
+			checks["metadata"] = verifyCheck{OK: false, Detail: fetchErr.Error()}
+		default:
+			md, res, fetchErr = ipfs.ResolveMetadata(r.Context(), uri)
+			if fetchErr != nil {
+				checks["metadata"] = verifyCheck{OK: false, Detail: fmt.Sprintf("metadata unreachable: %v", fetchErr)}
+			} else {
+				checks["metadata"] = verifyCheck{OK: true, Detail: "resolved " + uri}
+			}
+		}
+	} else {
+		checks["metadata"] = verifyCheck{OK: false, Detail: "skipped: no token to resolve"}
+	}
+	checks["content"] = contentCheck(res, fetchErr)
+
+	// Who the token should belong to and who should have minted it
+	student, issuer := "", ""
+	if cred != nil {
+		student, issuer = cred.StudentWallet, cred.UniversityWallet
+	} else if md != nil {
+		student, _ = md.Trait(ipfs.TraitRecipientWallet)
+		issuer, _ = md.Trait(ipfs.TraitIssuerWallet)
+	}
+	switch {
+	case !tokenExists:
+		checks["owner"] = verifyCheck{OK: false, Detail: "skipped: token does not exist"}
+	case student == "":
+		checks["owner"] = verifyCheck{OK: false, Detail: "no recipient to compare the owner with"}
+	case !equalCaseInsensitive(owner.Hex(), student):
+		checks["owner"] = verifyCheck{OK: false, Detail: "token is held by " + owner.Hex() + ", not the credential's student"}
+	default:
+		checks["owner"] = verifyCheck{OK: true, Detail: "held by the credential's student"}
+	}
+
+	if tokenExists {
+		recordedTx, platformIssued := "", false
+		if cred != nil {
+			recordedTx, platformIssued = cred.MintTxHash, batchIssued(*cred)
+		}
+		check, err := checkMinter(r.Context(), chain, s.tokenID, recordedTx, issuer, platformIssued)
+		if err != nil {
+			chainUnavailable(w, "minter", err)
+			return
+		}
+		checks["minter"] = check
+	} else {
+		checks["minter"] = verifyCheck{OK: false, Detail: "skipped: token does not exist"}
+	}
+
+	// Hashes: row, metadata and chain agree
+	var integrity map[string]any
+	switch {
+	case cred != nil:
+		integrity = credentialIntegrity(r.Context(), *cred, md, res, fetchErr)
+		if integrity["chain_unavailable"] == true {
+			chainUnavailable(w, "integrity", errors.New("tokenURI lookup failed"))
+			return
+		}
+		if integrity["consistent"] == true {
+			checks["hashes"] = verifyCheck{OK: true, Detail: fmt.Sprint(integrity["canonical_hash"])}
+		} else {
+			var failed []string
+			if sub, ok := integrity["checks"].(map[string]verifyCheck); ok {
+				for name, c := range sub {
+					if !c.OK {
+						failed = append(failed, name+": "+c.Detail)
+					}
+				}
+			}
+			sort.Strings(failed)
+			checks["hashes"] = verifyCheck{OK: false, Detail: strings.Join(failed, "; ")}
+		}
+	case md != nil:
+		checks["hashes"] = metadataHashCheck(md)
+	default:
+		checks["hashes"] = verifyCheck{OK: false, Detail: "skipped: no metadata to hash"}
+	}
+
+	// Revocation and signatory, from the registry
+	var signatory map[string]any
+	switch {
+	case cred == nil:
+		checks["status"] = verifyCheck{OK: false, Detail: "credential is unknown to this registry"}
+	case cred.Status == models.CredentialStatusRevoked:
+		checks["status"] = verifyCheck{OK: false, Detail: fmt.Sprintf("revoked: %s", cred.RevocationReason)}
+	case cred.Status == models.CredentialStatusSuperseded:
+		checks["status"] = verifyCheck{OK: false, Detail: fmt.Sprintf("superseded by %s: %s", currentCredentialID(*cred), cred.SupersessionReason)}
+	default:
+		checks["status"] = verifyCheck{OK: true, Detail: "active"}
+	}
+	if cred != nil {
+		signatory = signatoryReport(*cred)
+		ok := signatory["signature_valid"] == true && signatory["key_valid_at_issue"] == true
+		detail, _ := signatory["detail"].(string)
+		if sg, found := signatory["signatory"].(map[string]any); found && ok {
+			detail = fmt.Sprintf("%s (%s)", sg["name"], sg["role"])
+		}
+		checks["signatory"] = verifyCheck{OK: ok, Detail: detail}
+	}
+
+	verdict := verdictValid
+	for _, c := range checks {
+		if !c.OK {
+			verdict = verdictInvalid
+		}
+	}
+	if s.limited {
+		// Details can name the token, its URI or wallets, any of which leads
+		// back to the full record
+		for name, c := range checks {
+			if name != "status" && name != "signatory" {
+				c.Detail = ""
+				checks[name] = c
+			}
+		}
+	}
+	resp := map[string]any{
+		"verdict":  verdict,
+		"verified": verdict == verdictValid,
+		"checks":   checks,
+	}
+	if s.tokenID != nil && !s.limited {
+		resp["token_id"] = s.tokenID.String()
+	}
+	if cred != nil && !s.limited {
+		resp["credential_id"] = cred.ID
+		resp["status"] = credentialStatusPayload(*cred)
+		resp["credential"] = map[string]any{
+			"degree_name":       cred.DegreeName,
+			"type":              cred.Type,
+			"major":             cred.Major,
+			"issued_date":       cred.IssuedDate,
+			"student_wallet":    cred.StudentWallet,
+			"university_wallet": cred.UniversityWallet,
+		}
+		resp["signatory"] = signatory
+		resp["integrity"] = integrity
+	}
+	writeJSONResp(w, http.StatusOK, resp)
+}
@@ -111,13 +111,13 @@ func VerifyDocument(w http.ResponseWriter, r *http.Request) {
 	// Fetch possible matches. Prefer exact roll match, but also allow fuzzy fallback by name/university
 	var candidates []models.LegacyCredential
 	if strings.TrimSpace(pc.RegisterNumber) != "" {
-		_ = db.DB.Preload("University").Where("roll_number = ?", pc.RegisterNumber).Find(&candidates).Error
+		_ = db.DB.Preload("University").Preload("ProgramRef").Where("roll_number = ?", pc.RegisterNumber).Find(&candidates).Error
 	}
 	if len(candidates) == 0 {
 		// Fallback: try by partial name/university to aid matching
 		nameLike := "%" + strings.ToLower(strings.TrimSpace(pc.StudentName)) + "%"
 		uniLike := "%" + strings.ToLower(strings.TrimSpace(pc.UniversityName)) + "%"
-		_ = db.DB.Preload("University").Joins("JOIN organizations ON organizations.id = legacy_credentials.university_id").
+		_ = db.DB.Preload("University").Preload("ProgramRef").Joins("JOIN organizations ON organizations.id = legacy_credentials.university_id").
 			Where("LOWER(legacy_credentials.student_name) LIKE ? OR LOWER(organizations.org_name) LIKE ?", nameLike, uniLike).
 			Limit(10).Find(&candidates).Error
 	}
@@ -172,6 +172,18 @@ func VerifyDocument(w http.ResponseWriter, r *http.Request) {
 		"roll_number_exact_match": rollMatch,
 	}
 
+	// Compare the OCR'd course with the record's catalog program, so "B.Tech"
+	// on paper matches "Bachelor of Technology" in the registry
+	var courseMatch *bool
+	if rec.ProgramRef != nil {
+		data["official_program"] = rec.ProgramRef.Name
+		if strings.TrimSpace(pc.CourseName) != "" {
+			m := newProgramMatcher([]models.Program{*rec.ProgramRef}).Match(pc.CourseName) != nil
+			courseMatch = &m
+		}
+	}
+	data["course_matches_program"] = courseMatch
+
 	// Adaptive verification policy (A):
 	// - Strict: roll exact + name >= 0.95 + university >= 0.90
 	// - Adaptive: if roll exact + name >= 0.98, accept university >= 0.88
@@ -0,0 +1,405 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"vericred/internal/branding"
+	"vericred/internal/db"
+	"vericred/internal/disclosure"
+	"vericred/internal/eip712"
+	"vericred/internal/eth"
+	"vericred/internal/eth/ipfs"
+	"vericred/internal/models"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
+)
+
+// Issuer works through queued IssuanceJobs. Each item is pinned to IPFS,
+// minted from the server wallet and recorded as a Credential, with the
+// item's state persisted after every step so a restart or retry resumes
+// where it stopped. Items are processed one at a time to keep the server
+// wallet's nonces in order.
+type Issuer struct {
+	PollInterval time.Duration
+	MintTimeout  time.Duration
+
+	wake chan struct{}
+	mu   sync.Mutex
+}
+
+var (
+	issuer     *Issuer
+	issuerOnce sync.Once
+)
+
+// GetIssuer returns the process-wide issuance worker configured from env:
+// ISSUANCE_POLL_INTERVAL (default 10s, 0 disables the worker) and
+// ISSUANCE_MINT_TIMEOUT (default 5m) for a mint to be mined.
+func GetIssuer() *Issuer {
+	issuerOnce.Do(func() {
+		issuer = &Issuer{
+			PollInterval: envDuration("ISSUANCE_POLL_INTERVAL", 10*time.Second),
+			MintTimeout:  envDuration("ISSUANCE_MINT_TIMEOUT", 5*time.Minute),
+			wake:         make(chan struct{}, 1),
+		}
+		if issuer.MintTimeout <= 0 {
+			issuer.MintTimeout = 5 * time.Minute
+		}
+	})
+	return issuer
+}
+
+// Notify wakes the worker without waiting for the next poll.
+func (is *Issuer) Notify() {
+	select {
+	case is.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start processes queued jobs until ctx is cancelled. It returns at once
+// when the poll interval is not positive.
+func (is *Issuer) Start(ctx context.Context) {
+	if is.PollInterval <= 0 {
+		log.Println("issuer: disabled (ISSUANCE_POLL_INTERVAL <= 0)")
+		return
+	}
+	ticker := time.NewTicker(is.PollInterval)
+	defer ticker.Stop()
+	for {
+		if err := is.RunPending(ctx); err != nil {
+			log.Printf("issuer: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-is.wake:
+		}
+	}
+}
+
+// RunPending processes every queued or interrupted job, oldest first.
+func (is *Issuer) RunPending(ctx context.Context) error {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+
+	for {
+		var job models.IssuanceJob
+		err := db.DB.Where("status IN ?", []string{models.IssuanceJobQueued, models.IssuanceJobRunning}).
+			Order("created_at").First(&job).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("load jobs: %w", err)
+		}
+		if err := is.runJob(ctx, job); err != nil {
+			return fmt.Errorf("job %s: %w", job.ID, err)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+func (is *Issuer) runJob(ctx context.Context, job models.IssuanceJob) error {
+	var org models.Organization
+	if err := db.DB.First(&org, job.OrganizationID).Error; err != nil {
+		return fmt.Errorf("load organization: %w", err)
+	}
+
+	now := time.Now().UTC()
+	updates := map[string]any{"status": models.IssuanceJobRunning}
+	if job.StartedAt == nil {
+		updates["started_at"] = now
+	}
+	if err := db.DB.Model(&job).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// Re-read the job so a cancel takes effect between items
+		if err := db.DB.Select("status").First(&job, "id = ?", job.ID).Error; err != nil {
+			return err
+		}
+		if job.Status == models.IssuanceJobCancelled {
+			return updateJobCounts(job.ID)
+		}
+
+		var item models.IssuanceJobItem
+		err := db.DB.Where("job_id = ? AND status IN ?", job.ID, []string{
+			models.IssuanceItemPending, models.IssuanceItemPinned, models.IssuanceItemSubmitted,
+		}).Order("row").First(&item).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := is.processItem(ctx, org, &item); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("issuer: job %s row %d failed: %v", job.ID, item.Row, err)
+			fail := map[string]any{
+				"status":   models.IssuanceItemFailed,
+				"attempts": item.Attempts + 1,
+				"error":    err.Error(),
+			}
+			// A reverted or dropped mint must be resubmitted rather than
+			// waited on again
+			if errors.Is(err, eth.ErrMintReverted) || errors.Is(err, eth.ErrTxDropped) {
+				fail["mint_tx_hash"] = ""
+			}
+			if uerr := db.DB.Model(&item).Updates(fail).Error; uerr != nil {
+				return uerr
+			}
+		}
+		if err := updateJobCounts(job.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := updateJobCounts(job.ID); err != nil {
+		return err
+	}
+	var failed int64
+	if err := db.DB.Model(&models.IssuanceJobItem{}).
+		Where("job_id = ? AND status = ?", job.ID, models.IssuanceItemFailed).
+		Count(&failed).Error; err != nil {
+		return err
+	}
+	status := models.IssuanceJobCompleted
+	if failed > 0 {
+		status = models.IssuanceJobPartial
+	}
+	return db.DB.Model(&models.IssuanceJob{}).Where("id = ?", job.ID).
+		Updates(map[string]any{"status": status, "finished_at": time.Now().UTC()}).Error
+}
+
+// processItem advances item through pin, mint and record, saving progress
+// after each step.
+func (is *Issuer) processItem(ctx context.Context, org models.Organization, item *models.IssuanceJobItem) error {
+	if item.Status == models.IssuanceItemPending || item.IPFSLink == "" {
+		pinned, err := pinItem(ctx, org, *item)
+		if err != nil {
+			return err
+		}
+		item.IPFSLink = pinned.link
+		item.ImageURI = pinned.image
+		item.SDDisclosures = pinned.sd.Disclosures
+		item.SDDigests = pinned.sd.Digests
+		item.Status = models.IssuanceItemPinned
+		if err := db.DB.Model(item).Updates(map[string]any{
+			"ipfs_link":      item.IPFSLink,
+			"image_uri":      item.ImageURI,
+			"sd_disclosures": item.SDDisclosures,
+			"sd_digests":     item.SDDigests,
+			"status":         item.Status,
+			"error":          "",
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	chain, err := eth.Default(ctx)
+	if err != nil {
+		return err
+	}
+	if item.Status == models.IssuanceItemPinned || item.MintTxHash == "" {
+		hash, err := chain.SubmitMint(ctx, item.StudentWallet, item.IPFSLink)
+		if err != nil {
+			return err
+		}
+		item.MintTxHash = hash.Hex()
+		item.Status = models.IssuanceItemSubmitted
+		if err := db.DB.Model(item).Updates(map[string]any{"mint_tx_hash": item.MintTxHash, "status": item.Status}).Error; err != nil {
+			return err
+		}
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, is.MintTimeout)
+	defer cancel()
+	tokenID, mined, err := chain.WaitMint(waitCtx, common.HexToHash(item.MintTxHash))
+	if err != nil {
+		return err
+	}
+	// A fee bump replaces the transaction under a new hash
+	item.MintTxHash = mined.Hex()
+	item.TokenID = tokenID.String()
+	return recordItem(org, item)
+}
+
+// itemIssuance is the metadata input for an item.
+func itemIssuance(org models.Organization, item models.IssuanceJobItem) ipfs.Issuance {
+	return ipfs.Issuance{
+		StudentWallet:  item.StudentWallet,
+		IssuerWallet:   org.MetamaskAddress,
+		DegreeName:     item.DegreeName,
+		Type:           item.Type,
+		Major:          item.Major,
+		IssuedDate:     item.IssuedDate.Format("2006-01-02"),
+		GraduationDate: item.GraduationDate,
+		Description:    item.Description,
+		DeanSig:        item.DeanSig,
+		Values:         item.Values,
+	}
+}
+
+// BuildItemMetadata builds the metadata an item will pin, from its template
+// when it has one.
+func BuildItemMetadata(org models.Organization, tpl *models.CredentialTemplate, item models.IssuanceJobItem) (*ipfs.Credentials, []string) {
+	in := itemIssuance(org, item)
+	if tpl != nil {
+		return ipfs.BuildFromTemplate(*tpl, in)
+	}
+	return ipfs.BuildMetadata(in)
+}
+
+// pinnedItem is what pinning an item produced: the metadata link, the image
+// it references (kept pinned once the credential is recorded) and the
+// disclosures salted for it.
+type pinnedItem struct {
+	link  string
+	image string
+	sd    disclosure.Set
+}
+
+// pinItem salts the item's fields for selective disclosure and pins its
+// metadata.
+func pinItem(ctx context.Context, org models.Organization, item models.IssuanceJobItem) (pinnedItem, error) {
+	var tpl *models.CredentialTemplate
+	if item.TemplateID != nil {
+		var t models.CredentialTemplate
+		if err := db.DB.First(&t, *item.TemplateID).Error; err != nil {
+			return pinnedItem{}, fmt.Errorf("load template: %w", err)
+		}
+		tpl = &t
+	}
+	md, problems := BuildItemMetadata(org, tpl, item)
+	if len(problems) > 0 {
+		return pinnedItem{}, fmt.Errorf("metadata: %s", strings.Join(problems, "; "))
+	}
+	brand, err := branding.Load(org.ID)
+	if err != nil {
+		return pinnedItem{}, fmt.Errorf("load branding: %w", err)
+	}
+	branding.ApplyToMetadata(md, brand)
+	var student models.Users
+	if err := db.DB.First(&student, item.UserID).Error; err != nil {
+		return pinnedItem{}, fmt.Errorf("load student: %w", err)
+	}
+	sd, err := disclosure.Withhold(md, strings.TrimSpace(student.FirstName+" "+student.LastName))
+	if err != nil {
+		return pinnedItem{}, fmt.Errorf("metadata: %w", err)
+	}
+	link, size, err := ipfs.PinMetadata(ctx, md)
+	if err != nil {
+		return pinnedItem{}, fmt.Errorf("pin metadata: %w", err)
+	}
+	if err := RecordPin(link, size, "credential.json", org.MetamaskAddress, &org.ID); err != nil {
+		log.Printf("issuance: record pin %s: %v", link, err)
+	}
+	return pinnedItem{link: link, image: md.Image, sd: sd}, nil
+}
+
+// recordItem creates the Credential for a minted item and marks it completed
+// in one transaction.
+func recordItem(org models.Organization, item *models.IssuanceJobItem) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		cred := models.Credential{
+			DegreeID:         item.DegreeID,
+			StudentWallet:    item.StudentWallet,
+			UniversityWallet: org.MetamaskAddress,
+			DegreeName:       item.DegreeName,
+			Description:      item.Description,
+			Type:             item.Type,
+			Major:            item.Major,
+			IssuedDate:       item.IssuedDate,
+			GraduationDate:   item.GraduationDate,
+			IPFSLink:         item.IPFSLink,
+			DeanSig:          item.DeanSig,
+			SignatoryID:      item.SignatoryID,
+			TokenID:          item.TokenID,
+			MintTxHash:       item.MintTxHash,
+			TemplateID:       item.TemplateID,
+			UserID:           item.UserID,
+			OrganizationID:   org.ID,
+		}
+		hash, err := eip712.CredentialHash(eip712.FieldsOf(cred))
+		if err != nil {
+			return fmt.Errorf("hash credential: %w", err)
+		}
+		cred.CanonicalHash = hash
+		var student models.Users
+		if err := tx.First(&student, item.UserID).Error; err != nil {
+			return fmt.Errorf("load student: %w", err)
+		}
+		// Items pinned before their fields were salted at pinning are salted now
+		if len(item.SDDisclosures) > 0 {
+			cred.SDDisclosures = item.SDDisclosures
+			cred.SDDigests = item.SDDigests
+		} else {
+			name := strings.TrimSpace(student.FirstName + " " + student.LastName)
+			if err := disclosure.Commit(&cred, name, item.Values); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(&cred).Error; err != nil {
+			return fmt.Errorf("create credential: %w", err)
+		}
+		if err := AttachPin(tx, cred.IPFSLink, cred.ID); err != nil {
+			return fmt.Errorf("attach pin: %w", err)
+		}
+		// The image the pinned metadata references, which the branding
+		// profile may no longer name
+		if err := RetainAssets(tx, cred.ID, item.ImageURI); err != nil {
+			return fmt.Errorf("retain assets: %w", err)
+		}
+		item.CredentialID = &cred.ID
+		item.Status = models.IssuanceItemCompleted
+		return tx.Model(item).Updates(map[string]any{
+			"token_id":      item.TokenID,
+			"mint_tx_hash":  item.MintTxHash,
+			"credential_id": cred.ID,
+			"status":        item.Status,
+			"error":         "",
+		}).Error
+	})
+}
+
+// updateJobCounts refreshes a job's completed and failed counters.
+func updateJobCounts(jobID string) error {
+	type row struct {
+		Status string
+		N      int
+	}
+	var rows []row
+	if err := db.DB.Model(&models.IssuanceJobItem{}).Select("status, COUNT(*) AS n").
+		Where("job_id = ?", jobID).Group("status").Scan(&rows).Error; err != nil {
+		return err
+	}
+	var completed, failed int
+	for _, r := range rows {
+		switch r.Status {
+		case models.IssuanceItemCompleted:
+			completed = r.N
+		case models.IssuanceItemFailed:
+			failed = r.N
+		}
+	}
+	return db.DB.Model(&models.IssuanceJob{}).Where("id = ?", jobID).
+		Updates(map[string]any{"completed": completed, "failed": failed}).Error
+}
@@ -0,0 +1,337 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"vericred/internal/asset"
+	"vericred/internal/db"
+	"vericred/internal/eth/ipfs"
+	"vericred/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrPinsRunning is returned by RunOnce when another run is in progress.
+var ErrPinsRunning = errors.New("pin maintenance already running")
+
+// RecordPin notes metadata just pinned through link. Pinning the same CID
+// again marks it pinned and restarts its garbage-collection clock.
+func RecordPin(link string, size int, name, uploader string, orgID *uint) error {
+	return recordPin(link, models.Pin{
+		Kind:           models.PinKindMetadata,
+		Name:           name,
+		Size:           int64(size),
+		UploaderWallet: uploader,
+		OrganizationID: orgID,
+	})
+}
+
+// RecordAssetPin notes an uploaded image or media asset pinned through link.
+func RecordAssetPin(link string, a *asset.Asset, name, uploader string, orgID uint) error {
+	return recordPin(link, models.Pin{
+		Kind:           models.PinKindAsset,
+		Name:           name,
+		ContentType:    a.ContentType,
+		Size:           int64(len(a.Data)),
+		Width:          a.Width,
+		Height:         a.Height,
+		UploaderWallet: uploader,
+		OrganizationID: &orgID,
+	})
+}
+
+func recordPin(link string, pin models.Pin) error {
+	cid := pinCID(link)
+	if cid == "" {
+		return fmt.Errorf("not an IPFS link: %q", link)
+	}
+	now := time.Now().UTC()
+	pin.CID = cid
+	pin.Store = ipfs.Store().Name()
+	pin.Status = models.PinStatusPinned
+	pin.PinnedAt = now
+	return db.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "cid"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"status":      models.PinStatusPinned,
+			"store":       pin.Store,
+			"pinned_at":   now,
+			"unpinned_at": nil,
+			"error":       "",
+			"updated_at":  now,
+		}),
+	}).Create(&pin).Error
+}
+
+// AttachPin links the pin behind link to a credential so it is kept.
+func AttachPin(tx *gorm.DB, link, credID string) error {
+	cid := pinCID(link)
+	if cid == "" {
+		return nil
+	}
+	return tx.Model(&models.Pin{}).Where("cid = ?", cid).Update("credential_id", credID).Error
+}
+
+// RetainAssets keeps the asset pins behind links (a credential's image and
+// animation_url) by attaching them to credID. Assets are shared between
+// credentials, so one already attached stays with its first credential.
+func RetainAssets(tx *gorm.DB, credID string, links ...string) error {
+	var cids []string
+	for _, link := range links {
+		if cid := pinCID(link); cid != "" {
+			cids = append(cids, cid)
+		}
+	}
+	if len(cids) == 0 {
+		return nil
+	}
+	return tx.Model(&models.Pin{}).
+		Where("cid IN ? AND kind = ? AND credential_id IS NULL", cids, models.PinKindAsset).
+		Update("credential_id", credID).Error
+}
+
+// pinCID is the CID a stored link points at, or "" for non-IPFS links.
+func pinCID(link string) string {
+	key := ipfs.ContentKey(link)
+	if key == strings.TrimSpace(link) {
+		return ""
+	}
+	cid, _, _ := strings.Cut(key, "/")
+	if !ipfs.ValidCID(cid) {
+		return ""
+	}
+	return cid
+}
+
+// PinKeeper garbage-collects pins that never became credentials, optionally
+// releases pins of revoked credentials, and checks that pins still exist in
+// the store, re-pinning them from the gateways when they don't.
+type PinKeeper struct {
+	Interval time.Duration
+	// Unattached pins older than GCAfter are unpinned.
+	GCAfter time.Duration
+	// Pins of credentials revoked longer than RevokedAfter ago are unpinned;
+	// zero keeps them.
+	RevokedAfter time.Duration
+	// Pins are re-checked once CheckEvery has passed since the last check.
+	CheckEvery time.Duration
+	BatchSize  int
+
+	mu sync.Mutex
+}
+
+// PinSummary describes one maintenance run.
+type PinSummary struct {
+	Collected  int       `json:"collected"`
+	Released   int       `json:"released"`
+	Checked    int       `json:"checked"`
+	Repinned   int       `json:"repinned"`
+	Missing    int       `json:"missing"`
+	Failed     int       `json:"failed"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+var (
+	pinKeeper     *PinKeeper
+	pinKeeperOnce sync.Once
+)
+
+// GetPinKeeper returns the process-wide pin keeper configured from env:
+// PIN_MAINTENANCE_INTERVAL (default 6h, 0 disables the loop), PIN_GC_AFTER
+// (default 0, no garbage collection), PIN_RELEASE_REVOKED_AFTER (default 0, keep),
+// PIN_CHECK_EVERY (default 24h) and PIN_BATCH_SIZE (default 200).
+func GetPinKeeper() *PinKeeper {
+	pinKeeperOnce.Do(func() {
+		pinKeeper = &PinKeeper{
+			Interval:     envDuration("PIN_MAINTENANCE_INTERVAL", 6*time.Hour),
+			GCAfter:      envDuration("PIN_GC_AFTER", 0),
+			RevokedAfter: envDuration("PIN_RELEASE_REVOKED_AFTER", 0),
+			CheckEvery:   envDuration("PIN_CHECK_EVERY", 24*time.Hour),
+			BatchSize:    int(envUint("PIN_BATCH_SIZE", 200)),
+		}
+		if pinKeeper.BatchSize <= 0 {
+			pinKeeper.BatchSize = 200
+		}
+	})
+	return pinKeeper
+}
+
+// Start runs maintenance immediately and then every Interval until ctx is
+// cancelled. It returns at once when the interval is not positive.
+func (pk *PinKeeper) Start(ctx context.Context) {
+	if pk.Interval <= 0 {
+		log.Println("pins: disabled (PIN_MAINTENANCE_INTERVAL <= 0)")
+		return
+	}
+	ticker := time.NewTicker(pk.Interval)
+	defer ticker.Stop()
+	for {
+		if sum, err := pk.RunOnce(ctx); err != nil && !errors.Is(err, ErrPinsRunning) {
+			log.Printf("pins: run failed: %v", err)
+		} else if err == nil {
+			log.Printf("pins: collected=%d released=%d checked=%d repinned=%d missing=%d failed=%d",
+				sum.Collected, sum.Released, sum.Checked, sum.Repinned, sum.Missing, sum.Failed)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce unpins stale uploads, releases revoked credentials' pins and
+// checks one batch of pins that are due.
+func (pk *PinKeeper) RunOnce(ctx context.Context) (PinSummary, error) {
+	if !pk.mu.TryLock() {
+		return PinSummary{}, ErrPinsRunning
+	}
+	defer pk.mu.Unlock()
+
+	sum := PinSummary{StartedAt: time.Now().UTC()}
+	store := ipfs.Store()
+	now := time.Now().UTC()
+
+	// Uploads never attached to a credential, except assets a branding
+	// profile still uses and metadata a credential row or an on-chain
+	// tokenURI refers to (a token minted without its /credmint record shows
+	// up only as a discrepancy, and its pin may be the only copy), and
+	// anything a batch item still being minted uses
+	if pk.GCAfter > 0 {
+		var stale []models.Pin
+		if err := db.DB.Where("status = ? AND credential_id IS NULL AND pinned_at < ? AND store = ?",
+			models.PinStatusPinned, now.Add(-pk.GCAfter), store.Name()).
+			Where("NOT EXISTS (SELECT 1 FROM organization_brandings b WHERE b.logo_uri = 'ipfs://' || pins.cid OR b.seal_uri = 'ipfs://' || pins.cid)").
+			Where("NOT EXISTS (SELECT 1 FROM credentials c WHERE c.ipfs_link LIKE '%' || pins.cid || '%')").
+			Where("NOT EXISTS (SELECT 1 FROM credential_discrepancies d WHERE d.token_uri LIKE '%' || pins.cid || '%')").
+			Where("NOT EXISTS (SELECT 1 FROM issuance_job_items i WHERE i.credential_id IS NULL AND (i.ipfs_link LIKE '%' || pins.cid || '%' OR i.image_uri = 'ipfs://' || pins.cid))").
+			Limit(pk.BatchSize).Find(&stale).Error; err != nil {
+			return sum, fmt.Errorf("load stale pins: %w", err)
+		}
+		for _, p := range stale {
+			if err := pk.unpin(ctx, store, p); err != nil {
+				log.Printf("pins: unpin %s: %v", p.CID, err)
+				sum.Failed++
+				continue
+			}
+			sum.Collected++
+		}
+	}
+
+	// Metadata of long-revoked credentials; assets may back other
+	// credentials and are kept
+	if pk.RevokedAfter > 0 {
+		var revoked []models.Pin
+		if err := db.DB.Joins("JOIN credentials ON credentials.id = pins.credential_id").
+			Where("pins.status = ? AND pins.kind = ? AND pins.store = ? AND credentials.status = ? AND credentials.revoked_at < ?",
+				models.PinStatusPinned, models.PinKindMetadata, store.Name(), models.CredentialStatusRevoked, now.Add(-pk.RevokedAfter)).
+			Limit(pk.BatchSize).Find(&revoked).Error; err != nil {
+			return sum, fmt.Errorf("load revoked pins: %w", err)
+		}
+		for _, p := range revoked {
+			if err := pk.unpin(ctx, store, p); err != nil {
+				log.Printf("pins: release %s: %v", p.CID, err)
+				sum.Failed++
+				continue
+			}
+			sum.Released++
+		}
+	}
+
+	// Presence checks, oldest first
+	var due []models.Pin
+	if err := db.DB.Where("status IN ? AND store = ? AND (last_checked_at IS NULL OR last_checked_at < ?)",
+		[]string{models.PinStatusPinned, models.PinStatusMissing}, store.Name(), now.Add(-pk.CheckEvery)).
+		Order("last_checked_at ASC NULLS FIRST").Limit(pk.BatchSize).Find(&due).Error; err != nil {
+		return sum, fmt.Errorf("load pins to check: %w", err)
+	}
+	for _, p := range due {
+		if ctx.Err() != nil {
+			return sum, ctx.Err()
+		}
+		sum.Checked++
+		repinned, err := pk.check(ctx, store, p)
+		switch {
+		case err != nil:
+			log.Printf("pins: %s: %v", p.CID, err)
+			sum.Missing++
+		case repinned:
+			sum.Repinned++
+		}
+	}
+
+	sum.FinishedAt = time.Now().UTC()
+	return sum, nil
+}
+
+func (pk *PinKeeper) unpin(ctx context.Context, store ipfs.ContentStore, p models.Pin) error {
+	if err := store.Unpin(ctx, p.CID); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	return db.DB.Model(&p).Updates(map[string]any{
+		"status":      models.PinStatusUnpinned,
+		"unpinned_at": now,
+		"error":       "",
+	}).Error
+}
+
+// check confirms p is still in the store and re-pins it from wherever the
+// resolver can find it when it isn't. It reports whether it re-pinned, and
+// returns an error when the pin is missing and could not be restored.
+func (pk *PinKeeper) check(ctx context.Context, store ipfs.ContentStore, p models.Pin) (bool, error) {
+	now := time.Now().UTC()
+	record := func(status, msg string) error {
+		return db.DB.Model(&p).Updates(map[string]any{
+			"status":          status,
+			"last_checked_at": now,
+			"error":           msg,
+		}).Error
+	}
+
+	pinned, err := store.Pinned(ctx, p.CID)
+	if err != nil {
+		// The store could not answer; try again next run
+		return false, fmt.Errorf("check: %w", err)
+	}
+	if pinned {
+		return false, record(models.PinStatusPinned, "")
+	}
+
+	resolve := ipfs.Resolve
+	if p.Kind == models.PinKindAsset {
+		resolve = ipfs.ResolveAsset
+	}
+	res, err := resolve(ctx, ipfs.CanonicalURI(p.CID))
+	if err == nil {
+		// Content the resolver could not hash itself (multi-block files)
+		// is verified by the store computing the same CID
+		var cid string
+		cid, err = store.Put(ctx, p.Name, res.Data)
+		if err == nil && cid != p.CID {
+			if !res.Verified() {
+				_ = store.Unpin(ctx, cid)
+			}
+			err = fmt.Errorf("store pinned it as %s", cid)
+		}
+	}
+	if err != nil {
+		msg := "missing from " + store.Name() + ": " + err.Error()
+		if len(msg) > 500 {
+			msg = msg[:500]
+		}
+		if rerr := record(models.PinStatusMissing, msg); rerr != nil {
+			return false, rerr
+		}
+		return false, errors.New(msg)
+	}
+	return true, record(models.PinStatusPinned, "")
+}
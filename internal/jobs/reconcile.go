@@ -0,0 +1,439 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"vericred/internal/db"
+	"vericred/internal/eth"
+	"vericred/internal/eth/ipfs"
+	"vericred/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const reconcileCursorName = "credential_reconciler"
+
+// ErrReconcileRunning is returned by RunOnce when another run is in progress.
+var ErrReconcileRunning = errors.New("reconciliation already running")
+
+// Reconciler scans Transfer events from the credential contract and matches
+// minted tokens to Credential rows by tokenURI and recipient. It backfills
+// token IDs on matched rows and records CredentialDiscrepancy rows for
+// anything that exists on only one side.
+type Reconciler struct {
+	Interval      time.Duration
+	StartBlock    uint64
+	ChunkSize     uint64
+	Confirmations uint64
+	Grace         time.Duration
+
+	mu sync.Mutex
+}
+
+// ReconcileSummary describes the outcome of a single reconciliation run.
+type ReconcileSummary struct {
+	FromBlock        uint64    `json:"from_block"`
+	ToBlock          uint64    `json:"to_block"`
+	Events           int       `json:"events"`
+	Backfilled       int       `json:"backfilled"`
+	NewDiscrepancies int       `json:"new_discrepancies"`
+	Resolved         int       `json:"resolved"`
+	CaughtUp         bool      `json:"caught_up"`
+	StartedAt        time.Time `json:"started_at"`
+	FinishedAt       time.Time `json:"finished_at"`
+}
+
+var (
+	reconciler     *Reconciler
+	reconcilerOnce sync.Once
+)
+
+// GetReconciler returns the process-wide reconciler configured from env:
+// RECONCILE_INTERVAL (default 0, which leaves the loop off), RECONCILE_START_BLOCK
+// (the contract's deployment block; scanning from 0 is slow on public chains),
+// RECONCILE_CHUNK_SIZE (default 5000), RECONCILE_CONFIRMATIONS (default 6) and
+// RECONCILE_GRACE (default 1h) before an unminted credential is reported.
+func GetReconciler() *Reconciler {
+	reconcilerOnce.Do(func() {
+		reconciler = &Reconciler{
+			Interval:      envDuration("RECONCILE_INTERVAL", 0),
+			StartBlock:    envUint("RECONCILE_START_BLOCK", 0),
+			ChunkSize:     envUint("RECONCILE_CHUNK_SIZE", 5000),
+			Confirmations: envUint("RECONCILE_CONFIRMATIONS", 6),
+			Grace:         envDuration("RECONCILE_GRACE", time.Hour),
+		}
+		if reconciler.ChunkSize == 0 {
+			reconciler.ChunkSize = 5000
+		}
+	})
+	return reconciler
+}
+
+// Start runs the reconciler immediately and then every Interval until ctx is
+// cancelled. It returns at once when the interval is not positive.
+func (rc *Reconciler) Start(ctx context.Context) {
+	if rc.Interval <= 0 {
+		log.Println("reconciler: disabled (RECONCILE_INTERVAL <= 0)")
+		return
+	}
+	if rc.StartBlock == 0 {
+		log.Println("reconciler: RECONCILE_START_BLOCK is not set, scanning from block 0")
+	}
+	ticker := time.NewTicker(rc.Interval)
+	defer ticker.Stop()
+	for {
+		if sum, err := rc.RunOnce(ctx); err != nil && !errors.Is(err, ErrReconcileRunning) {
+			log.Printf("reconciler: run failed: %v", err)
+		} else if err == nil {
+			log.Printf("reconciler: blocks %d-%d events=%d backfilled=%d new=%d resolved=%d",
+				sum.FromBlock, sum.ToBlock, sum.Events, sum.Backfilled, sum.NewDiscrepancies, sum.Resolved)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce scans all confirmed blocks since the stored cursor, then re-checks
+// open discrepancies and reports credentials that were never minted.
+func (rc *Reconciler) RunOnce(ctx context.Context) (ReconcileSummary, error) {
+	if !rc.mu.TryLock() {
+		return ReconcileSummary{}, ErrReconcileRunning
+	}
+	defer rc.mu.Unlock()
+
+	sum := ReconcileSummary{StartedAt: time.Now().UTC()}
+
+	chain, err := eth.Default(ctx)
+	if err != nil {
+		return sum, err
+	}
+	head, err := chain.LatestBlock(ctx)
+	if err != nil {
+		return sum, fmt.Errorf("latest block: %w", err)
+	}
+	target := uint64(0)
+	if head > rc.Confirmations {
+		target = head - rc.Confirmations
+	}
+
+	cursor, err := rc.loadCursor()
+	if err != nil {
+		return sum, err
+	}
+	from := rc.StartBlock
+	if cursor.LastBlock >= from {
+		from = cursor.LastBlock + 1
+	}
+	sum.FromBlock = from
+	sum.ToBlock = from
+
+	for from <= target {
+		if err := ctx.Err(); err != nil {
+			return sum, err
+		}
+		end := from + rc.ChunkSize - 1
+		if end > target {
+			end = target
+		}
+		events, err := chain.MintEvents(ctx, from, end)
+		if err != nil {
+			return sum, err
+		}
+		for _, ev := range events {
+			sum.Events++
+			if err := rc.handleMint(ctx, chain, ev, &sum); err != nil {
+				return sum, err
+			}
+		}
+		cursor.LastBlock = end
+		if err := db.DB.Save(&cursor).Error; err != nil {
+			return sum, fmt.Errorf("save cursor: %w", err)
+		}
+		sum.ToBlock = end
+		from = end + 1
+	}
+	sum.CaughtUp = from > target
+
+	if err := rc.recheckUnrecorded(&sum); err != nil {
+		return sum, err
+	}
+	if sum.CaughtUp {
+		if err := rc.reportUnminted(&sum); err != nil {
+			return sum, err
+		}
+	}
+	sum.FinishedAt = time.Now().UTC()
+	return sum, nil
+}
+
+// Cursor returns the last block processed by the reconciler.
+func (rc *Reconciler) Cursor() (models.ChainCursor, error) {
+	var cursor models.ChainCursor
+	err := db.DB.Where("name = ?", reconcileCursorName).First(&cursor).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.ChainCursor{Name: reconcileCursorName}, nil
+	}
+	return cursor, err
+}
+
+func (rc *Reconciler) loadCursor() (models.ChainCursor, error) {
+	cursor, err := rc.Cursor()
+	if err != nil {
+		return cursor, fmt.Errorf("load cursor: %w", err)
+	}
+	return cursor, nil
+}
+
+func (rc *Reconciler) handleMint(ctx context.Context, chain *eth.Client, ev eth.MintEvent, sum *ReconcileSummary) error {
+	tokenID := ev.TokenID.String()
+	recipient := ev.To.Hex()
+
+	var linked int64
+	if err := db.DB.Model(&models.Credential{}).Where("token_id = ?", tokenID).Count(&linked).Error; err != nil {
+		return fmt.Errorf("lookup token %s: %w", tokenID, err)
+	}
+	if linked > 0 {
+		return nil
+	}
+
+	// Without the tokenURI nothing can be matched. An outage fails the run so
+	// the chunk is scanned again; a token the contract won't describe (burned
+	// since) is skipped rather than reported with an empty URI.
+	uri, err := chain.TokenURI(ctx, ev.TokenID)
+	if errors.Is(err, eth.ErrUnavailable) {
+		return err
+	}
+	if err != nil {
+		log.Printf("reconciler: skipping token %s: %v", tokenID, err)
+		return nil
+	}
+
+	d := models.CredentialDiscrepancy{
+		TokenID:     tokenID,
+		TokenURI:    uri,
+		Recipient:   recipient,
+		TxHash:      ev.TxHash.Hex(),
+		BlockNumber: ev.BlockNumber,
+	}
+	return rc.matchToken(d, sum)
+}
+
+// matchToken links the minted token described by d to a credential, or
+// records why it could not be linked.
+func (rc *Reconciler) matchToken(d models.CredentialDiscrepancy, sum *ReconcileSummary) error {
+	matches, err := credentialsForURI(d.TokenURI)
+	if err != nil {
+		return err
+	}
+
+	var sameRecipient []models.Credential
+	for _, c := range matches {
+		if strings.EqualFold(strings.TrimSpace(c.StudentWallet), d.Recipient) {
+			sameRecipient = append(sameRecipient, c)
+		}
+	}
+
+	for _, c := range sameRecipient {
+		if c.TokenID != "" {
+			continue
+		}
+		res := db.DB.Model(&models.Credential{}).
+			Where("id = ? AND (token_id = '' OR token_id IS NULL)", c.ID).
+			Updates(map[string]any{"token_id": d.TokenID, "mint_tx_hash": d.TxHash})
+		if res.Error != nil {
+			return fmt.Errorf("backfill credential %s: %w", c.ID, res.Error)
+		}
+		if res.RowsAffected == 0 {
+			continue
+		}
+		sum.Backfilled++
+		n, err := resolveDiscrepancies(db.DB.Where("credential_id = ? OR token_id = ?", c.ID, d.TokenID))
+		if err != nil {
+			return err
+		}
+		sum.Resolved += n
+		return nil
+	}
+
+	switch {
+	case len(sameRecipient) > 0:
+		d.Kind = models.DiscrepancyDuplicateMint
+		d.CredentialID = &sameRecipient[0].ID
+		d.Details = fmt.Sprintf("credential already linked to token %s", sameRecipient[0].TokenID)
+	case len(matches) > 0:
+		d.Kind = models.DiscrepancyRecipientMismatch
+		d.CredentialID = &matches[0].ID
+		d.Details = fmt.Sprintf("token minted to %s but credential belongs to %s", d.Recipient, matches[0].StudentWallet)
+	default:
+		d.Kind = models.DiscrepancyMintedNotRecorded
+		d.Details = "no credential row matches this tokenURI"
+	}
+	created, err := recordDiscrepancy(d)
+	if err != nil {
+		return err
+	}
+	if created {
+		sum.NewDiscrepancies++
+	}
+	return nil
+}
+
+// recheckUnrecorded retries open minted_not_recorded discrepancies, since the
+// credential row is often persisted after the mint has been scanned.
+func (rc *Reconciler) recheckUnrecorded(sum *ReconcileSummary) error {
+	var open []models.CredentialDiscrepancy
+	if err := db.DB.Where("kind = ? AND resolved_at IS NULL", models.DiscrepancyMintedNotRecorded).
+		Find(&open).Error; err != nil {
+		return fmt.Errorf("list open discrepancies: %w", err)
+	}
+	for _, d := range open {
+		matches, err := credentialsForURI(d.TokenURI)
+		if err != nil {
+			return err
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		retry := d
+		retry.ID = ""
+		if err := rc.matchToken(retry, sum); err != nil {
+			return err
+		}
+		n, err := resolveDiscrepancies(db.DB.Where("id = ?", d.ID))
+		if err != nil {
+			return err
+		}
+		sum.Resolved += n
+	}
+	return nil
+}
+
+// reportUnminted flags credentials older than the grace period that still have
+// no token, and resolves reports for credentials that have since been linked.
+func (rc *Reconciler) reportUnminted(sum *ReconcileSummary) error {
+	n, err := resolveDiscrepancies(db.DB.
+		Where("kind = ?", models.DiscrepancyRecordedNotMinted).
+		Where("credential_id IN (?)", db.DB.Model(&models.Credential{}).Select("id").Where("token_id <> ''")))
+	if err != nil {
+		return err
+	}
+	sum.Resolved += n
+
+	var creds []models.Credential
+	cutoff := time.Now().Add(-rc.Grace)
+	if err := db.DB.Where("(token_id = '' OR token_id IS NULL) AND created_at < ? AND source = ?", cutoff, models.CredentialSourceNative).
+		Find(&creds).Error; err != nil {
+		return fmt.Errorf("list unminted credentials: %w", err)
+	}
+	for _, c := range creds {
+		id := c.ID
+		created, err := recordDiscrepancy(models.CredentialDiscrepancy{
+			Kind:         models.DiscrepancyRecordedNotMinted,
+			CredentialID: &id,
+			TokenURI:     strings.TrimSpace(c.IPFSLink),
+			Recipient:    c.StudentWallet,
+			Details:      "no Transfer event found for this credential's tokenURI",
+		})
+		if err != nil {
+			return err
+		}
+		if created {
+			sum.NewDiscrepancies++
+		}
+	}
+	return nil
+}
+
+// credentialsForURI returns credentials whose IPFS link refers to the same
+// content as uri, comparing by CID when the URI is an IPFS reference.
+func credentialsForURI(uri string) ([]models.Credential, error) {
+	key := ipfs.ContentKey(uri)
+	if key == "" {
+		return nil, nil
+	}
+	var candidates []models.Credential
+	if err := db.DB.Where("ipfs_link LIKE ?", "%"+key+"%").Find(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("match credentials for %q: %w", uri, err)
+	}
+	out := candidates[:0]
+	for _, c := range candidates {
+		if ipfs.ContentKey(c.IPFSLink) == key {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+// recordDiscrepancy inserts d unless an identical open discrepancy exists.
+func recordDiscrepancy(d models.CredentialDiscrepancy) (bool, error) {
+	q := db.DB.Where("kind = ? AND resolved_at IS NULL", d.Kind)
+	if d.TokenID != "" {
+		q = q.Where("token_id = ?", d.TokenID)
+	}
+	if d.CredentialID != nil {
+		q = q.Where("credential_id = ?", *d.CredentialID)
+	}
+	var existing models.CredentialDiscrepancy
+	err := q.First(&existing).Error
+	if err == nil {
+		return false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, fmt.Errorf("lookup discrepancy: %w", err)
+	}
+	if err := db.DB.Create(&d).Error; err != nil {
+		return false, fmt.Errorf("create discrepancy: %w", err)
+	}
+	return true, nil
+}
+
+// resolveDiscrepancies marks the open discrepancies selected by scope resolved.
+func resolveDiscrepancies(scope *gorm.DB) (int, error) {
+	res := scope.Model(&models.CredentialDiscrepancy{}).
+		Where("resolved_at IS NULL").
+		Update("resolved_at", time.Now().UTC())
+	if res.Error != nil {
+		return 0, fmt.Errorf("resolve discrepancies: %w", res.Error)
+	}
+	return int(res.RowsAffected), nil
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	if v == "0" {
+		return 0
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("invalid %s=%q, using %s", key, v, def)
+		return def
+	}
+	return d
+}
+
+func envUint(key string, def uint64) uint64 {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	n, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		log.Printf("invalid %s=%q, using %d", key, v, def)
+		return def
+	}
+	return n
+}
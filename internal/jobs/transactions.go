@@ -0,0 +1,74 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"vericred/internal/eth"
+)
+
+// ErrTxMonitorRunning is returned by RunOnce when another run is in progress.
+var ErrTxMonitorRunning = errors.New("transaction check already running")
+
+// TxMonitor watches transactions sent by the server wallet until they are
+// final, re-sending stuck ones with higher fees (see eth.Client.CheckPending).
+type TxMonitor struct {
+	Interval time.Duration
+
+	mu sync.Mutex
+}
+
+var (
+	txMonitor     *TxMonitor
+	txMonitorOnce sync.Once
+)
+
+// GetTxMonitor returns the process-wide monitor configured from env:
+// ETH_TX_CHECK_INTERVAL (default 1m, 0 disables the loop).
+func GetTxMonitor() *TxMonitor {
+	txMonitorOnce.Do(func() {
+		txMonitor = &TxMonitor{Interval: envDuration("ETH_TX_CHECK_INTERVAL", time.Minute)}
+	})
+	return txMonitor
+}
+
+// Start checks pending transactions every Interval until ctx is cancelled.
+// It returns at once when the interval is not positive.
+func (tm *TxMonitor) Start(ctx context.Context) {
+	if tm.Interval <= 0 {
+		log.Println("transactions: disabled (ETH_TX_CHECK_INTERVAL <= 0)")
+		return
+	}
+	ticker := time.NewTicker(tm.Interval)
+	defer ticker.Stop()
+	for {
+		if sum, err := tm.RunOnce(ctx); err != nil && !errors.Is(err, ErrTxMonitorRunning) {
+			log.Printf("transactions: run failed: %v", err)
+		} else if err == nil && sum.Checked > 0 {
+			log.Printf("transactions: checked=%d confirmed=%d failed=%d dropped=%d bumped=%d rebroadcast=%d errors=%d",
+				sum.Checked, sum.Confirmed, sum.Failed, sum.Dropped, sum.Bumped, sum.Rebroadcast, sum.Errors)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce checks every pending transaction once.
+func (tm *TxMonitor) RunOnce(ctx context.Context) (eth.TxSummary, error) {
+	if !tm.mu.TryLock() {
+		return eth.TxSummary{}, ErrTxMonitorRunning
+	}
+	defer tm.mu.Unlock()
+
+	chain, err := eth.Default(ctx)
+	if err != nil {
+		return eth.TxSummary{}, err
+	}
+	return chain.CheckPending(ctx)
+}
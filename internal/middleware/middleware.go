@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"os"
 	"strings"
 	"vericred/internal/logging"
 	"vericred/pkg"
@@ -42,6 +43,34 @@ func AuthMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// AdminMiddleware only lets through wallets listed in the comma-separated
+// ADMIN_WALLETS env var. It must run after AuthMiddleware.
+func AdminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		address, ok := r.Context().Value(MetamaskAddressKey).(string)
+		if !ok || address == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if !IsAdminWallet(address) {
+			logging.Logger.Println("Admin access denied for:", address)
+			http.Error(w, "forbidden: admin only", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// IsAdminWallet reports whether address is one of the platform admin wallets.
+func IsAdminWallet(address string) bool {
+	for _, a := range strings.Split(os.Getenv("ADMIN_WALLETS"), ",") {
+		if a = strings.TrimSpace(a); a != "" && strings.EqualFold(a, strings.TrimSpace(address)) {
+			return true
+		}
+	}
+	return false
+}
+
 // func ProtectedHandler(w http.ResponseWriter, r *http.Request) {
 // 	w.Header().Set("Content-Type", "application/json")
 // 	tokenString := r.Header.Get("Authorization")
@@ -1,6 +1,9 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
@@ -20,6 +23,9 @@ type Accounts struct {
 	Credentials     int        `gorm:"default:0" json:"credentials"`
 	LastLoginAt     *time.Time `json:"last_login_at"`
 	IsActive        bool       `gorm:"default:true" json:"is_active"`
+	// Uncompressed secp256k1 public key (0x04...) recovered from the login
+	// signature; private credential data is encrypted to it.
+	PublicKey       string     `gorm:"size:132" json:"public_key,omitempty"`
 	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt       time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
 
@@ -83,28 +89,77 @@ type PendingRequest struct {
 
 
 type Credential struct {
-	ID 				 string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`	
-	DegreeID        *uint     `gorm:"column:degree_id;default:NULL" json:"degree_id"`
+	ID 				 string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid();index:idx_credentials_org_issued,priority:3;index:idx_credentials_org_created,priority:3;index:idx_credentials_org_degree,priority:3" json:"id"`	
+	DegreeID        *uint     `gorm:"column:degree_id;default:NULL;index" json:"degree_id"`
+	Degree          *Program  `gorm:"foreignKey:DegreeID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"degree,omitempty"`
 	StudentWallet    string    `gorm:"not null;size:42" json:"student_wallet"`
 	UniversityWallet string    `gorm:"not null;size:42" json:"university_wallet"`
-	DegreeName      string    `gorm:"not null;size:255" json:"degree_name"`
+	DegreeName      string    `gorm:"not null;size:255;index:idx_credentials_org_degree,priority:2" json:"degree_name"`
 	Description     string    `gorm:"type:text" json:"description"`
-	Type            string    `gorm:"not null;size:100" json:"type"`
+	Type            string    `gorm:"not null;size:100;index:idx_credentials_org_type,priority:2" json:"type"`
 	Major           string    `gorm:"size:255" json:"major"`
-	IssuedDate      time.Time `gorm:"not null" json:"issued_date"`
+	IssuedDate      time.Time `gorm:"not null;index:idx_credentials_org_issued,priority:2" json:"issued_date"`
 	GraduationDate  string    `gorm:"size:50" json:"graduation_date"`
-	CreatedAt       time.Time `gorm:"autoCreateTime" json:"created_at"`
+	CreatedAt       time.Time `gorm:"autoCreateTime;index:idx_credentials_org_created,priority:2" json:"created_at"`
 	UpdatedAt       time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 	IPFSLink 		string 	  `gorm:"not null" json:"ipfs_link"`
+	IPFSURL         string    `gorm:"-" json:"ipfs_url,omitempty"`
 	DeanSig 		string 	  `gorm:"not null" json:"dean_sig"`
+	TokenID         string    `gorm:"size:78;index" json:"token_id"`
+	MintTxHash      string    `gorm:"size:66" json:"mint_tx_hash"`
+	Status          string     `gorm:"size:20;not null;default:active;index;index:idx_credentials_org_status,priority:2" json:"status"`
+	RevokedAt       *time.Time `json:"revoked_at"`
+	RevocationReason string    `gorm:"type:text" json:"revocation_reason"`
+	VCProof         string     `gorm:"type:text" json:"-"`
+	OBProof         string     `gorm:"type:text" json:"-"`
+	Source          string     `gorm:"size:30;not null;default:native" json:"source"`
+	ExternalID      string     `gorm:"size:255;index" json:"external_id,omitempty"`
+	SourceDocument  string     `gorm:"type:text" json:"-"`
+	TemplateID      *uint      `gorm:"index" json:"template_id"`
+	SupersedesID       *string    `gorm:"type:uuid;index" json:"supersedes_id"`
+	SupersededByID     *string    `gorm:"type:uuid;index" json:"superseded_by_id"`
+	SupersededAt       *time.Time `json:"superseded_at"`
+	SupersessionReason string     `gorm:"type:text" json:"supersession_reason,omitempty"`
+	SignatoryID        *uint      `gorm:"index" json:"signatory_id"`
+	CanonicalHash      string     `gorm:"size:66;index" json:"canonical_hash"`
+	// Salted per-field disclosures (kept server-side, handed to the holder)
+	// and the digests they commit to (public).
+	SDDisclosures      StringList `gorm:"type:jsonb" json:"-"`
+	SDDigests          StringList `gorm:"type:jsonb" json:"sd_digests,omitempty"`
 
 	UserID         uint         `json:"user_id"`
 	User           Users        `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"user"`
 
-	OrganizationID uint         `json:"organization_id"`
+	OrganizationID uint         `gorm:"index:idx_credentials_org_issued,priority:1;index:idx_credentials_org_created,priority:1;index:idx_credentials_org_degree,priority:1;index:idx_credentials_org_type,priority:1;index:idx_credentials_org_status,priority:1" json:"organization_id"`
 	Organization   Organization `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"organization"`
 }
 
+// ContentURL resolves a stored ipfs:// link to a fetchable URL. The ipfs
+// package installs its gateway resolver here.
+var ContentURL = func(link string) string { return link }
+
+// AfterFind fills IPFSURL from the canonical link, so gateway changes apply
+// to every credential without rewriting rows.
+func (c *Credential) AfterFind(tx *gorm.DB) error {
+	if c.IPFSLink != "" {
+		c.IPFSURL = ContentURL(c.IPFSLink)
+	}
+	return nil
+}
+
+// Credential sources: issued through this platform or imported.
+const (
+	CredentialSourceNative     = "native"
+	CredentialSourceOpenBadges = "openbadges"
+)
+
+// Credential lifecycle states.
+const (
+	CredentialStatusActive     = "active"
+	CredentialStatusRevoked    = "revoked"
+	CredentialStatusSuperseded = "superseded"
+)
+
 type Transaction struct {
 	ID            string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
 	TxHash        string    `gorm:"not null;size:66" json:"tx_hash"`
@@ -132,6 +187,482 @@ type LegacyCredential struct {
 	GraduationDate  string        `gorm:"size:50" json:"graduation_date"`
 	UniversityID    uint          `gorm:"not null;index" json:"university_id"`
 	University      Organization  `gorm:"foreignKey:UniversityID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	ProgramID       *uint         `gorm:"index" json:"program_id"`
+	ProgramRef      *Program      `gorm:"foreignKey:ProgramID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"program_ref,omitempty"`
 	CreatedAt       time.Time     `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt       time.Time     `gorm:"autoUpdateTime" json:"updated_at"`
 }
+
+// ChainCursor records the last block a background chain scanner has processed,
+// keyed by scanner name, so restarts resume where they left off.
+type ChainCursor struct {
+	Name      string    `gorm:"primaryKey;size:100" json:"name"`
+	LastBlock uint64    `gorm:"not null;default:0" json:"last_block"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// Discrepancy kinds produced by the on-chain reconciler.
+const (
+	DiscrepancyMintedNotRecorded = "minted_not_recorded"
+	DiscrepancyRecordedNotMinted = "recorded_not_minted"
+	DiscrepancyRecipientMismatch = "recipient_mismatch"
+	DiscrepancyDuplicateMint     = "duplicate_mint"
+)
+
+// CredentialDiscrepancy is a mismatch between the credentials table and the
+// Transfer events of the contract, found by the reconciler for admin review.
+// Open discrepancies have a nil ResolvedAt.
+type CredentialDiscrepancy struct {
+	ID           string     `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	Kind         string     `gorm:"not null;size:50;index" json:"kind"`
+	CredentialID *string    `gorm:"type:uuid;index" json:"credential_id"`
+	TokenID      string     `gorm:"size:78;index" json:"token_id"`
+	TokenURI     string     `gorm:"type:text" json:"token_uri"`
+	Recipient    string     `gorm:"size:42" json:"recipient"`
+	TxHash       string     `gorm:"size:66" json:"tx_hash"`
+	BlockNumber  uint64     `json:"block_number"`
+	Details      string     `gorm:"type:text" json:"details"`
+	ResolvedAt   *time.Time `json:"resolved_at"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// CertificateTemplate controls how an organization's printable PDF
+// certificates are laid out. Text fields may use the placeholders
+// {{student_name}}, {{degree_name}}, {{major}}, {{type}}, {{issued_date}},
+// {{graduation_date}} and {{org_name}}.
+type CertificateTemplate struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	OrganizationID uint      `gorm:"not null;uniqueIndex" json:"organization_id"`
+	Layout         string    `gorm:"size:20;not null;default:classic" json:"layout"`
+	Title          string    `gorm:"size:255" json:"title"`
+	Preamble       string    `gorm:"type:text" json:"preamble"`
+	Body           string    `gorm:"type:text" json:"body"`
+	AccentColor    string    `gorm:"size:7" json:"accent_color"`
+	SignatoryName  string    `gorm:"size:255" json:"signatory_name"`
+	SignatoryTitle string    `gorm:"size:255" json:"signatory_title"`
+	Footer         string    `gorm:"type:text" json:"footer"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Organization Organization `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// OrganizationBranding is how an organization's credentials look: the
+// display name, logo and seal assets (ipfs:// URIs of asset pins), colors
+// (#RRGGBB) and the footer shown on verification pages. It feeds metadata
+// image and background_color, QR codes and certificate PDFs.
+type OrganizationBranding struct {
+	ID                 uint      `gorm:"primaryKey" json:"id"`
+	OrganizationID     uint      `gorm:"not null;uniqueIndex" json:"organization_id"`
+	DisplayName        string    `gorm:"size:255" json:"display_name"`
+	LogoURI            string    `gorm:"size:255" json:"logo"`
+	SealURI            string    `gorm:"size:255" json:"seal"`
+	PrimaryColor       string    `gorm:"size:7" json:"primary_color"`
+	SecondaryColor     string    `gorm:"size:7" json:"secondary_color"`
+	VerificationFooter string    `gorm:"type:text" json:"verification_footer"`
+	CreatedAt          time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt          time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Organization Organization `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// Value types an AttributeSpec can require.
+const (
+	AttributeTypeString = "string"
+	AttributeTypeNumber = "number"
+	AttributeTypeDate   = "date"
+	AttributeTypeBool   = "boolean"
+	AttributeTypeEnum   = "enum"
+	AttributeTypeWallet = "wallet"
+)
+
+// AttributeSpec describes one metadata attribute (trait_type) a credential
+// template expects.
+type AttributeSpec struct {
+	TraitType string   `json:"trait_type"`
+	Type      string   `json:"type"`
+	Required  bool     `json:"required"`
+	Enum      []string `json:"enum,omitempty"`
+	Default   string   `json:"default,omitempty"`
+}
+
+// AttributeSpecs is stored as a jsonb column.
+type AttributeSpecs []AttributeSpec
+
+func (a AttributeSpecs) Value() (driver.Value, error) {
+	if a == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(a)
+	return string(b), err
+}
+
+func (a *AttributeSpecs) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	default:
+		return fmt.Errorf("AttributeSpecs: unsupported scan type %T", src)
+	}
+}
+
+// CredentialTemplate is an organization-defined credential shape. Issuance
+// and metadata uploads that name a template are validated against it.
+type CredentialTemplate struct {
+	ID                 uint           `gorm:"primaryKey" json:"id"`
+	OrganizationID     uint           `gorm:"not null;index;uniqueIndex:idx_cred_template_org_name" json:"organization_id"`
+	Name               string         `gorm:"not null;size:255;uniqueIndex:idx_cred_template_org_name" json:"name"`
+	CredentialType     string         `gorm:"not null;size:100" json:"credential_type"`
+	DegreeName         string         `gorm:"size:255" json:"degree_name"`
+	DefaultDescription string         `gorm:"type:text" json:"default_description"`
+	Attributes         AttributeSpecs `gorm:"type:jsonb;not null;default:'[]'" json:"attributes"`
+	Active             bool           `gorm:"not null;default:true" json:"active"`
+	CreatedAt          time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt          time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Organization Organization `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// StringList is a list of strings stored as a jsonb column.
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(l)
+	return string(b), err
+}
+
+func (l *StringList) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, l)
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	default:
+		return fmt.Errorf("StringList: unsupported scan type %T", src)
+	}
+}
+
+// Program levels.
+const (
+	ProgramLevelCertificate = "certificate"
+	ProgramLevelDiploma     = "diploma"
+	ProgramLevelBachelor    = "bachelor"
+	ProgramLevelMaster      = "master"
+	ProgramLevelDoctorate   = "doctorate"
+)
+
+// Program is an entry in an organization's degree catalog. Credentials point
+// at it through DegreeID and legacy records through ProgramID; Aliases are
+// the free-text variants ("B.Tech", "BTech") that resolve to it.
+type Program struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	OrganizationID uint       `gorm:"not null;index;uniqueIndex:idx_program_org_name" json:"organization_id"`
+	Name           string     `gorm:"not null;size:255;uniqueIndex:idx_program_org_name" json:"name"`
+	Level          string     `gorm:"not null;size:20" json:"level"`
+	DurationMonths int        `json:"duration_months"`
+	Majors         StringList `gorm:"type:jsonb;not null;default:'[]'" json:"majors"`
+	Aliases        StringList `gorm:"type:jsonb;not null;default:'[]'" json:"aliases"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Organization Organization `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// StringMap is a string-to-string map stored as a jsonb column.
+type StringMap map[string]string
+
+func (m StringMap) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(m)
+	return string(b), err
+}
+
+func (m *StringMap) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("StringMap: unsupported scan type %T", src)
+	}
+}
+
+// Issuance job states.
+const (
+	IssuanceJobQueued    = "queued"
+	IssuanceJobRunning   = "running"
+	IssuanceJobCompleted = "completed"
+	IssuanceJobPartial   = "completed_with_errors"
+	IssuanceJobCancelled = "cancelled"
+)
+
+// Issuance job item states, in processing order. Failed items keep the
+// IPFSLink and MintTxHash they reached so a retry resumes from there.
+const (
+	IssuanceItemPending   = "pending"
+	IssuanceItemPinned    = "pinned"
+	IssuanceItemSubmitted = "submitted"
+	IssuanceItemCompleted = "completed"
+	IssuanceItemFailed    = "failed"
+)
+
+// IssuanceJob is a batch of credentials an organization issues in one go.
+// The issuance worker pins, mints and records each item.
+type IssuanceJob struct {
+	ID             string     `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	OrganizationID uint       `gorm:"not null;index" json:"organization_id"`
+	CreatedBy      string     `gorm:"not null;size:42" json:"created_by"`
+	Status         string     `gorm:"not null;size:30;index" json:"status"`
+	Total          int        `json:"total"`
+	Completed      int        `json:"completed"`
+	Failed         int        `json:"failed"`
+	StartedAt      *time.Time `json:"started_at"`
+	FinishedAt     *time.Time `json:"finished_at"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Organization Organization `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// IssuanceJobItem is one recipient of an IssuanceJob with the fields that
+// were validated when the job was created.
+type IssuanceJobItem struct {
+	ID             string     `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	JobID          string     `gorm:"type:uuid;not null;index" json:"job_id"`
+	Row            int        `gorm:"not null" json:"row"`
+	Status         string     `gorm:"not null;size:20;index" json:"status"`
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	Error          string     `gorm:"type:text" json:"error,omitempty"`
+	StudentWallet  string     `gorm:"not null;size:42" json:"student_wallet"`
+	UserID         uint       `json:"user_id"`
+	DegreeName     string     `gorm:"not null;size:255" json:"degree_name"`
+	Type           string     `gorm:"not null;size:100" json:"type"`
+	Major          string     `gorm:"size:255" json:"major"`
+	IssuedDate     time.Time  `gorm:"not null" json:"issued_date"`
+	GraduationDate string     `gorm:"size:50" json:"graduation_date"`
+	Description    string     `gorm:"type:text" json:"description"`
+	DegreeID       *uint      `json:"degree_id"`
+	TemplateID     *uint      `json:"template_id"`
+	Values         StringMap  `gorm:"type:jsonb;not null;default:'{}'" json:"values"`
+	DeanSig        string     `gorm:"not null" json:"dean_sig"`
+	SignatoryID    *uint      `json:"signatory_id"`
+	IPFSLink       string     `json:"ipfs_link"`
+	// ImageURI is the image asset the pinned metadata references.
+	ImageURI       string     `gorm:"type:text" json:"image_uri,omitempty"`
+	// SDDisclosures and SDDigests were salted when the metadata was pinned;
+	// the metadata carries only their root.
+	SDDisclosures  StringList `gorm:"type:jsonb" json:"-"`
+	SDDigests      StringList `gorm:"type:jsonb" json:"-"`
+	MintTxHash     string     `gorm:"size:66" json:"mint_tx_hash"`
+	TokenID        string     `gorm:"size:78" json:"token_id"`
+	CredentialID   *string    `gorm:"type:uuid" json:"credential_id"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Job IssuanceJob `gorm:"foreignKey:JobID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// Signatory roles.
+const (
+	SignatoryRoleDean      = "dean"
+	SignatoryRoleRegistrar = "registrar"
+	SignatoryRoleOther     = "other"
+)
+
+// Signatory is a wallet an organization authorizes to sign credential
+// attestations (Credential.DeanSig). A key is valid for credentials issued
+// from ValidFrom up to ValidUntil and before RevokedAt.
+type Signatory struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	OrganizationID uint       `gorm:"not null;index;uniqueIndex:idx_signatory_org_wallet" json:"organization_id"`
+	Name           string     `gorm:"not null;size:255" json:"name"`
+	Role           string     `gorm:"not null;size:20" json:"role"`
+	Wallet         string     `gorm:"not null;size:42;uniqueIndex:idx_signatory_org_wallet" json:"wallet"`
+	ValidFrom      time.Time  `gorm:"not null" json:"valid_from"`
+	ValidUntil     *time.Time `json:"valid_until"`
+	RevokedAt      *time.Time `json:"revoked_at"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Organization Organization `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// Transcript is the course-level record behind a credential. Transcripts can
+// be uploaded ahead of issuance; the credential whose metadata carries the
+// transcript's Hash links it when it is recorded.
+type Transcript struct {
+	ID             uint             `gorm:"primaryKey" json:"id"`
+	OrganizationID uint             `gorm:"not null;index" json:"organization_id"`
+	StudentWallet  string           `gorm:"not null;size:42;index" json:"student_wallet"`
+	CredentialID   *string          `gorm:"type:uuid;uniqueIndex" json:"credential_id"`
+	GradeScale     float64          `json:"grade_scale"`
+	TotalCredits   float64          `json:"total_credits"`
+	CGPA           float64          `gorm:"column:cgpa" json:"cgpa"`
+	Hash           string           `gorm:"not null;size:66;index" json:"hash"`
+	Terms          []TranscriptTerm `gorm:"foreignKey:TranscriptID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"terms"`
+	CreatedAt      time.Time        `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time        `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Organization Organization `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// TranscriptTerm is one term (semester, quarter, year) of a transcript.
+type TranscriptTerm struct {
+	ID           uint               `gorm:"primaryKey" json:"id"`
+	TranscriptID uint               `gorm:"not null;index" json:"transcript_id"`
+	Name         string             `gorm:"not null;size:100" json:"name"`
+	Sequence     int                `gorm:"not null" json:"sequence"`
+	Credits      float64            `json:"credits"`
+	SGPA         float64            `gorm:"column:sgpa" json:"sgpa"`
+	Courses      []TranscriptCourse `gorm:"foreignKey:TermID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"courses"`
+}
+
+// TranscriptCourse is a course taken in a term. GradePoints is nil for
+// pass/fail and other ungraded courses, which don't count toward the GPA.
+type TranscriptCourse struct {
+	ID          uint     `gorm:"primaryKey" json:"id"`
+	TermID      uint     `gorm:"not null;index" json:"term_id"`
+	Code        string   `gorm:"not null;size:50" json:"code"`
+	Title       string   `gorm:"size:255" json:"title"`
+	Credits     float64  `gorm:"not null" json:"credits"`
+	Grade       string   `gorm:"not null;size:10" json:"grade"`
+	GradePoints *float64 `json:"grade_points"`
+}
+
+// PrivateEnvelope holds credential attributes that were encrypted instead of
+// pinned. Ciphertext is the AES-256-GCM encryption of a salted JSON payload
+// under a random content key; WrappedKeys maps each reader's lowercased
+// wallet to that key ECIES-encrypted to the wallet's public key. The pinned
+// metadata carries only Commitment, the keccak256 of the payload.
+type PrivateEnvelope struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	OrganizationID uint       `gorm:"not null;index" json:"organization_id"`
+	StudentWallet  string     `gorm:"not null;size:42;index" json:"student_wallet"`
+	CredentialID   *string    `gorm:"type:uuid;uniqueIndex" json:"credential_id"`
+	Commitment     string     `gorm:"not null;size:66;uniqueIndex" json:"commitment"`
+	Traits         StringList `gorm:"type:jsonb" json:"traits"`
+	Nonce          string     `gorm:"not null;size:64" json:"nonce"`
+	Ciphertext     string     `gorm:"type:text;not null" json:"ciphertext"`
+	WrappedKeys    StringMap  `gorm:"type:jsonb" json:"-"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+
+	Organization Organization `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// DisclosureSet holds the salted disclosures of a credential whose metadata
+// was pinned before the credential was recorded. The pinned metadata carries
+// only Root, the commitment to Digests; Withheld names the attributes that
+// were taken out of it and are readable only through disclosures.
+type DisclosureSet struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	OrganizationID uint       `gorm:"not null;index" json:"organization_id"`
+	StudentWallet  string     `gorm:"not null;size:42;index" json:"student_wallet"`
+	CredentialID   *string    `gorm:"type:uuid;uniqueIndex" json:"credential_id"`
+	Root           string     `gorm:"not null;size:64;uniqueIndex" json:"root"`
+	Disclosures    StringList `gorm:"type:jsonb" json:"-"`
+	Digests        StringList `gorm:"type:jsonb" json:"digests"`
+	Withheld       StringList `gorm:"type:jsonb" json:"withheld"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+
+	Organization Organization `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// Pin statuses.
+const (
+	PinStatusPinned   = "pinned"
+	PinStatusUnpinned = "unpinned"
+	// Missing pins were found gone from the store and could not be re-pinned.
+	PinStatusMissing = "missing"
+)
+
+// Pin kinds.
+const (
+	PinKindMetadata = "metadata"
+	// Assets are images and media referenced from metadata; one asset can
+	// back many credentials.
+	PinKindAsset = "asset"
+)
+
+// Pin records content pinned to the content store, so uploads that never
+// became credentials can be garbage-collected and lost pins re-pinned.
+type Pin struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	CID            string     `gorm:"column:cid;not null;size:100;uniqueIndex" json:"cid"`
+	Store          string     `gorm:"not null;size:20" json:"store"`
+	Kind           string     `gorm:"not null;size:20;default:metadata;index" json:"kind"`
+	Name           string     `gorm:"size:255" json:"name"`
+	ContentType    string     `gorm:"size:100" json:"content_type,omitempty"`
+	Size           int64      `json:"size"`
+	Width          int        `json:"width,omitempty"`
+	Height         int        `json:"height,omitempty"`
+	UploaderWallet string     `gorm:"size:42;index" json:"uploader_wallet"`
+	OrganizationID *uint      `gorm:"index" json:"organization_id"`
+	CredentialID   *string    `gorm:"type:uuid;index" json:"credential_id"`
+	Status         string     `gorm:"not null;size:20;default:pinned;index" json:"status"`
+	PinnedAt       time.Time  `gorm:"not null" json:"pinned_at"`
+	LastCheckedAt  *time.Time `json:"last_checked_at"`
+	UnpinnedAt     *time.Time `json:"unpinned_at"`
+	Error          string     `gorm:"size:500" json:"error,omitempty"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// Chain transaction states. A pending transaction is re-sent with higher
+// fees while it stays unmined; failed means it was mined but reverted, and
+// dropped means its nonce was used by something else or it was never
+// accepted by the node.
+const (
+	ChainTxPending   = "pending"
+	ChainTxConfirmed = "confirmed"
+	ChainTxFailed    = "failed"
+	ChainTxDropped   = "dropped"
+)
+
+// ChainTransaction is a transaction sent from the server wallet, kept until
+// it is final so restarts can keep watching it. Hash is the latest
+// broadcast; Hashes holds every one, since a fee bump replaces the
+// transaction under a new hash and any of them may be the one mined.
+type ChainTransaction struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	Kind            string     `gorm:"not null;size:30;index" json:"kind"`
+	Reference       string     `gorm:"size:255" json:"reference,omitempty"`
+	ChainID         string     `gorm:"not null;size:78" json:"chain_id"`
+	Sender          string     `gorm:"not null;size:42;index:idx_chain_tx_sender_nonce" json:"sender"`
+	Nonce           uint64     `gorm:"not null;index:idx_chain_tx_sender_nonce" json:"nonce"`
+	To              string     `gorm:"size:42" json:"to,omitempty"`
+	Data            string     `gorm:"type:text" json:"-"`
+	Value           string     `gorm:"not null;default:0" json:"value"`
+	GasLimit        uint64     `json:"gas_limit"`
+	GasTipCap       string     `gorm:"size:78" json:"gas_tip_cap"`
+	GasFeeCap       string     `gorm:"size:78" json:"gas_fee_cap"`
+	Hash            string     `gorm:"not null;size:66;index" json:"hash"`
+	Hashes          StringList `gorm:"type:jsonb;not null;default:'[]'" json:"hashes"`
+	Status          string     `gorm:"not null;size:20;index" json:"status"`
+	Attempts        int        `gorm:"not null;default:1" json:"attempts"`
+	LastSentAt      time.Time  `json:"last_sent_at"`
+	BlockNumber     uint64     `json:"block_number,omitempty"`
+	GasUsed         uint64     `json:"gas_used,omitempty"`
+	ContractAddress string     `gorm:"size:42" json:"contract_address,omitempty"`
+	Error           string     `gorm:"size:500" json:"error,omitempty"`
+	MinedAt         *time.Time `json:"mined_at"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
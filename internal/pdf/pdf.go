@@ -0,0 +1,385 @@
+// Package pdf is a small PDF 1.4 writer covering what rendered certificates
+// need: text in the standard fonts, filled and stroked shapes, raster images
+// and document info metadata. Coordinates are in points with the origin at the
+// bottom-left corner of the page.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A4 landscape page size in points.
+const (
+	A4Width  = 841.89
+	A4Height = 595.28
+)
+
+// Document is a PDF under construction.
+type Document struct {
+	width, height float64
+	pages         []*Page
+	images        []image.Image
+	info          map[string]string
+	created       time.Time
+}
+
+// Page is a single page whose content stream is built by the drawing methods.
+type Page struct {
+	doc     *Document
+	content bytes.Buffer
+	fonts   map[Font]bool
+	images  map[int]bool
+}
+
+// New starts a document whose pages are width x height points.
+func New(width, height float64) *Document {
+	return &Document{width: width, height: height, info: map[string]string{}, created: time.Now()}
+}
+
+// SetInfo sets a document information entry such as Title, Author, Subject
+// or Keywords. Custom keys are allowed and show up as custom properties.
+func (d *Document) SetInfo(key, value string) {
+	d.info[key] = value
+}
+
+// AddPage appends a blank page.
+func (d *Document) AddPage() *Page {
+	p := &Page{doc: d, fonts: map[Font]bool{}, images: map[int]bool{}}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// SetFillColor sets the RGB color for text and filled shapes.
+func (p *Page) SetFillColor(r, g, b uint8) {
+	fmt.Fprintf(&p.content, "%s %s %s rg\n", unit(r), unit(g), unit(b))
+}
+
+// SetStrokeColor sets the RGB color for lines and outlines.
+func (p *Page) SetStrokeColor(r, g, b uint8) {
+	fmt.Fprintf(&p.content, "%s %s %s RG\n", unit(r), unit(g), unit(b))
+}
+
+// SetLineWidth sets the stroke width in points.
+func (p *Page) SetLineWidth(w float64) {
+	fmt.Fprintf(&p.content, "%s w\n", num(w))
+}
+
+// Rect draws a rectangle with its lower-left corner at (x, y).
+func (p *Page) Rect(x, y, w, h float64, fill, stroke bool) {
+	op := "S"
+	switch {
+	case fill && stroke:
+		op = "B"
+	case fill:
+		op = "f"
+	}
+	fmt.Fprintf(&p.content, "%s %s %s %s re %s\n", num(x), num(y), num(w), num(h), op)
+}
+
+// Line strokes a straight line.
+func (p *Page) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&p.content, "%s %s m %s %s l S\n", num(x1), num(y1), num(x2), num(y2))
+}
+
+// Text draws s with its baseline starting at (x, y).
+func (p *Page) Text(font Font, size, x, y float64, s string) {
+	p.fonts[font] = true
+	fmt.Fprintf(&p.content, "BT /%s %s Tf %s %s Td %s Tj ET\n", fontKey(font), num(size), num(x), num(y), literal(encode(s)))
+}
+
+// TextCentered draws s horizontally centered on cx.
+func (p *Page) TextCentered(font Font, size, cx, y float64, s string) {
+	p.Text(font, size, cx-TextWidth(font, size, s)/2, y, s)
+}
+
+// TextRight draws s so that it ends at x.
+func (p *Page) TextRight(font Font, size, x, y float64, s string) {
+	p.Text(font, size, x-TextWidth(font, size, s), y, s)
+}
+
+// Image draws img scaled into the box with lower-left corner (x, y).
+func (p *Page) Image(img image.Image, x, y, w, h float64) {
+	idx := len(p.doc.images)
+	p.doc.images = append(p.doc.images, img)
+	p.images[idx] = true
+	fmt.Fprintf(&p.content, "q %s 0 0 %s %s %s cm /Im%d Do Q\n", num(w), num(h), num(x), num(y), idx)
+}
+
+// Wrap splits s into lines no wider than maxWidth when set in font at size.
+func Wrap(font Font, size, maxWidth float64, s string) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if line != "" && TextWidth(font, size, candidate) > maxWidth {
+			lines = append(lines, line)
+			line = word
+			continue
+		}
+		line = candidate
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Bytes renders the document.
+func (d *Document) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := d.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteTo renders the document to w.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	wr := &writer{}
+	wr.buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	// Object numbers: 1 catalog, 2 page tree, 3 info, then fonts, images and pages.
+	const catalogID, pagesID, infoID = 1, 2, 3
+	next := 4
+
+	fontIDs := map[Font]int{}
+	for _, f := range []Font{Helvetica, HelveticaBold, TimesRoman, TimesBold} {
+		for _, p := range d.pages {
+			if p.fonts[f] {
+				fontIDs[f] = next
+				next++
+				break
+			}
+		}
+	}
+
+	imageIDs := make([]int, len(d.images))
+	maskIDs := make([]int, len(d.images))
+	for i, img := range d.images {
+		imageIDs[i] = next
+		next++
+		if hasAlpha(img) {
+			maskIDs[i] = next
+			next++
+		}
+	}
+
+	pageIDs := make([]int, len(d.pages))
+	contentIDs := make([]int, len(d.pages))
+	for i := range d.pages {
+		pageIDs[i] = next
+		contentIDs[i] = next + 1
+		next += 2
+	}
+
+	wr.object(catalogID, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+
+	kids := make([]string, len(pageIDs))
+	for i, id := range pageIDs {
+		kids[i] = fmt.Sprintf("%d 0 R", id)
+	}
+	wr.object(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pageIDs)))
+
+	wr.object(infoID, d.infoDict())
+
+	for f, id := range fontIDs {
+		wr.object(id, fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", f))
+	}
+
+	for i, img := range d.images {
+		rgb, alpha, bw, bh := pixels(img)
+		extra := ""
+		if maskIDs[i] != 0 {
+			extra = fmt.Sprintf(" /SMask %d 0 R", maskIDs[i])
+		}
+		if err := wr.stream(imageIDs[i], fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8%s", bw, bh, extra), rgb); err != nil {
+			return 0, err
+		}
+		if maskIDs[i] != 0 {
+			if err := wr.stream(maskIDs[i], fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8", bw, bh), alpha); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	for i, p := range d.pages {
+		var res strings.Builder
+		res.WriteString("<< /Font <<")
+		for _, f := range sortedFonts(p.fonts) {
+			fmt.Fprintf(&res, " /%s %d 0 R", fontKey(f), fontIDs[f])
+		}
+		res.WriteString(" >> /XObject <<")
+		for idx := range d.images {
+			if p.images[idx] {
+				fmt.Fprintf(&res, " /Im%d %d 0 R", idx, imageIDs[idx])
+			}
+		}
+		res.WriteString(" >> >>")
+		wr.object(pageIDs[i], fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources %s /Contents %d 0 R >>",
+			pagesID, num(d.width), num(d.height), res.String(), contentIDs[i]))
+		if err := wr.stream(contentIDs[i], "", p.content.Bytes()); err != nil {
+			return 0, err
+		}
+	}
+
+	xref := wr.buf.Len()
+	fmt.Fprintf(&wr.buf, "xref\n0 %d\n0000000000 65535 f \n", next)
+	for id := 1; id < next; id++ {
+		fmt.Fprintf(&wr.buf, "%010d 00000 n \n", wr.offsets[id])
+	}
+	fmt.Fprintf(&wr.buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", next, catalogID, infoID, xref)
+
+	n, err := w.Write(wr.buf.Bytes())
+	return int64(n), err
+}
+
+func (d *Document) infoDict() string {
+	var b strings.Builder
+	b.WriteString("<< /Producer (VeriCred) ")
+	fmt.Fprintf(&b, "/CreationDate %s ", literal([]byte(d.created.UTC().Format("D:20060102150405Z"))))
+	keys := make([]string, 0, len(d.info))
+	for k := range d.info {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "/%s %s ", nameToken(k), literal(encode(d.info[k])))
+	}
+	b.WriteString(">>")
+	return b.String()
+}
+
+type writer struct {
+	buf     bytes.Buffer
+	offsets map[int]int
+}
+
+func (wr *writer) object(id int, body string) {
+	if wr.offsets == nil {
+		wr.offsets = map[int]int{}
+	}
+	wr.offsets[id] = wr.buf.Len()
+	fmt.Fprintf(&wr.buf, "%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+func (wr *writer) stream(id int, dict string, data []byte) error {
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if wr.offsets == nil {
+		wr.offsets = map[int]int{}
+	}
+	wr.offsets[id] = wr.buf.Len()
+	fmt.Fprintf(&wr.buf, "%d 0 obj\n<< %s /Filter /FlateDecode /Length %d >>\nstream\n", id, dict, z.Len())
+	wr.buf.Write(z.Bytes())
+	wr.buf.WriteString("\nendstream\nendobj\n")
+	return nil
+}
+
+// pixels returns 8-bit RGB and alpha planes for img.
+func pixels(img image.Image) (rgb, alpha []byte, w, h int) {
+	b := img.Bounds()
+	w, h = b.Dx(), b.Dy()
+	rgb = make([]byte, 0, w*h*3)
+	alpha = make([]byte, 0, w*h)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, a := img.At(x, y).RGBA()
+			// Un-premultiply so the SMask composites correctly
+			if a > 0 && a < 0xffff {
+				r, g, bl = r*0xffff/a, g*0xffff/a, bl*0xffff/a
+			}
+			rgb = append(rgb, byte(r>>8), byte(g>>8), byte(bl>>8))
+			alpha = append(alpha, byte(a>>8))
+		}
+	}
+	return rgb, alpha, w, h
+}
+
+func hasAlpha(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return !o.Opaque()
+	}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0xffff {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sortedFonts(m map[Font]bool) []Font {
+	out := make([]Font, 0, len(m))
+	for f := range m {
+		out = append(out, f)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+func fontKey(f Font) string {
+	return strings.ReplaceAll(string(f), "-", "")
+}
+
+// literal encodes b as a PDF literal string.
+func literal(b []byte) string {
+	var s strings.Builder
+	s.WriteByte('(')
+	for _, c := range b {
+		switch c {
+		case '(', ')', '\\':
+			s.WriteByte('\\')
+			s.WriteByte(c)
+		case '\n':
+			s.WriteString(`\n`)
+		case '\r':
+			s.WriteString(`\r`)
+		default:
+			s.WriteByte(c)
+		}
+	}
+	s.WriteByte(')')
+	return s.String()
+}
+
+// nameToken keeps only characters that are safe in a PDF name.
+func nameToken(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' || r == '-' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func num(f float64) string {
+	s := fmt.Sprintf("%.2f", f)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "" || s == "-" {
+		return "0"
+	}
+	return s
+}
+
+func unit(c uint8) string {
+	return num(float64(c) / 255)
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"net/http"
 
-	"vericred/internal/eth/ipfs"
 	"vericred/internal/handlers"
 	"vericred/internal/middleware"
 
@@ -22,7 +21,6 @@ func RegisterRouter() http.Handler {
 	r.Post("/auth/metamasklogin", handlers.LoginInMetamask)
 	r.Get("/universities", handlers.AllOrgs)
 	r.Get("/students", handlers.AllUsers)
-	r.Post("/credmint", handlers.MintCredentials)
 	r.Post("/showuser", handlers.SearchUser)
 	r.Post("/usercreds", handlers.ShowSearchedUserCreds)
 	r.Get("/transactions", handlers.ShowAllTransactions)
@@ -40,6 +38,17 @@ func RegisterRouter() http.Handler {
 	// Public verify data (token required via query param)
 	r.Get("/api/v1/credential-info/{id}", handlers.GetCredentialInfo)
 
+	// W3C Verifiable Credentials: export (holder/issuer or share token), status and verification
+	r.Get("/api/v1/credentials/{id}/vc", handlers.ExportVerifiableCredential)
+	r.Get("/api/v1/credentials/{id}/status", handlers.CredentialStatus)
+	r.Get("/api/v1/credentials/{id}/history", handlers.CredentialHistory)
+	r.Post("/api/v1/vc/verify", handlers.VerifyVerifiableCredential)
+	// One-call verdict by token id, credential id or share token
+	r.Get("/api/v1/verify", handlers.VerifyCredentialVerdict)
+	r.Get("/api/v1/credentials/{id}/openbadge", handlers.ExportOpenBadge)
+	r.Get("/api/v1/credentials/{id}/certificate.pdf", handlers.CredentialCertificatePDF)
+	r.Get("/api/v1/credentials/{id}/transcript", handlers.CredentialTranscript)
+
 	// New: Privy login (public)
 	r.Post("/api/v1/auth/privy-login", handlers.PrivyLogin)
 
@@ -49,7 +58,9 @@ func RegisterRouter() http.Handler {
 		r.Post("/api/create/org", handlers.CreateUniversity)
 		r.Get("/dashboard", handlers.ShowUser)
 		r.Get("/university", handlers.ShowOrg)
-		r.Post("/api/uploadtoipfs", ipfs.CreateJSONFileAndStoreToIPFS)
+		// Credential issuance by the authenticated, verified organization
+		r.Post("/credmint", handlers.MintCredentials)
+		r.Post("/api/uploadtoipfs", handlers.UploadCredentialMetadata)
 		r.Get("/api/creds", handlers.UserCreds)
 		r.Post("/transactionhash", handlers.SetTransactionInfo)
 		// pending requests for org
@@ -59,7 +70,74 @@ func RegisterRouter() http.Handler {
 		r.Post("/api/v1/institution/bulk-upload", handlers.BulkUploadHandler)
 		// Create short-lived share link for credential (requires student auth)
 		r.Post("/api/v1/credentials/generate-share-link", handlers.GenerateShareLink)
+		r.Get("/api/v1/credentials/{id}/disclosures", handlers.ListCredentialDisclosures)
+		// Encrypted private attributes with the key wrapped for the caller
+		r.Get("/api/v1/credentials/{id}/private", handlers.CredentialPrivateData)
+		// Issuer signs exported VCs and manages revocation
+		r.Get("/api/v1/credentials/{id}/vc/signing-payload", handlers.VCSigningPayload)
+		r.Post("/api/v1/credentials/{id}/vc/proof", handlers.AttachVCProof)
+		r.Post("/api/v1/credentials/{id}/revoke", handlers.RevokeCredential)
+		r.Post("/api/v1/credentials/{id}/reissue", handlers.ReissueCredential)
+		// Open Badges 3.0 signing and import
+		r.Get("/api/v1/credentials/{id}/openbadge/signing-payload", handlers.OpenBadgeSigningPayload)
+		r.Post("/api/v1/credentials/{id}/openbadge/proof", handlers.AttachOpenBadgeProof)
+		r.Post("/api/v1/openbadges/import", handlers.ImportOpenBadge)
+		// Branding applied to metadata, QR codes and certificates
+		r.Get("/api/v1/org/branding", handlers.GetBranding)
+		r.Put("/api/v1/org/branding", handlers.PutBranding)
+		r.Post("/api/v1/org/branding/{slot}", handlers.UploadBrandingImage)
+		// Certificate images and media referenced from metadata
+		r.Post("/api/v1/org/assets", handlers.UploadAsset)
+		r.Get("/api/v1/org/assets", handlers.ListAssets)
+		// Course-level transcripts, uploaded before or after issuance
+		r.Post("/api/v1/org/transcripts/upload", handlers.UploadTranscripts)
+		r.Get("/api/v1/org/transcripts", handlers.ListTranscripts)
+		r.Delete("/api/v1/org/transcripts/{id}", handlers.DeleteTranscript)
+		// Search and export everything the org has issued
+		r.Get("/api/v1/org/credentials", handlers.ListOrgCredentials)
+		r.Get("/api/v1/org/credentials/export.csv", handlers.ExportOrgCredentials)
+		// Printable certificate layout for the org
+		r.Get("/api/v1/org/certificate-template", handlers.GetCertificateTemplate)
+		r.Put("/api/v1/org/certificate-template", handlers.PutCertificateTemplate)
+		// Credential templates that issuance and metadata uploads validate against
+		r.Get("/api/v1/org/credential-templates", handlers.ListCredentialTemplates)
+		r.Post("/api/v1/org/credential-templates", handlers.CreateCredentialTemplate)
+		r.Get("/api/v1/org/credential-templates/{id}", handlers.GetCredentialTemplate)
+		r.Put("/api/v1/org/credential-templates/{id}", handlers.UpdateCredentialTemplate)
+		r.Delete("/api/v1/org/credential-templates/{id}", handlers.DeleteCredentialTemplate)
+		// Program/degree catalog
+		r.Get("/api/v1/org/programs", handlers.ListPrograms)
+		r.Post("/api/v1/org/programs", handlers.CreateProgram)
+		r.Get("/api/v1/org/programs/{id}", handlers.GetProgram)
+		r.Put("/api/v1/org/programs/{id}", handlers.UpdateProgram)
+		r.Delete("/api/v1/org/programs/{id}", handlers.DeleteProgram)
+		// Authorized signatories and the attestation they sign (dean_sig)
+		r.Get("/api/v1/org/signatories", handlers.ListSignatories)
+		r.Post("/api/v1/org/signatories", handlers.CreateSignatory)
+		r.Put("/api/v1/org/signatories/{id}", handlers.UpdateSignatory)
+		r.Post("/api/v1/org/signatories/{id}/revoke", handlers.RevokeSignatory)
+		r.Post("/api/v1/credentials/signing-payload", handlers.CredentialSigningPayload)
+		// Batch issuance jobs
+		r.Post("/api/v1/issuance/jobs", handlers.CreateIssuanceJob)
+		r.Get("/api/v1/issuance/jobs", handlers.ListIssuanceJobs)
+		r.Get("/api/v1/issuance/jobs/{id}", handlers.GetIssuanceJob)
+		r.Get("/api/v1/issuance/jobs/{id}/items", handlers.ListIssuanceJobItems)
+		r.Post("/api/v1/issuance/jobs/{id}/retry", handlers.RetryIssuanceJob)
+		r.Post("/api/v1/issuance/jobs/{id}/cancel", handlers.CancelIssuanceJob)
 		// r.Get("/university", handlers.ShowUniversity)
 	})
+
+	// Platform admin routes (wallets listed in ADMIN_WALLETS)
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware)
+		r.Use(middleware.AdminMiddleware)
+		r.Get("/api/admin/reconciliation", handlers.ReconciliationReport)
+		r.Post("/api/admin/reconciliation/run", handlers.RunReconciliation)
+		r.Patch("/api/admin/reconciliation/{id}/resolve", handlers.ResolveDiscrepancy)
+		r.Get("/api/admin/pins", handlers.ListPins)
+		r.Post("/api/admin/pins/run", handlers.RunPinMaintenance)
+		r.Get("/api/admin/chain-transactions", handlers.ListChainTransactions)
+		r.Post("/api/admin/chain-transactions/run", handlers.RunTxMonitor)
+	})
 	return r
 }
@@ -0,0 +1,127 @@
+// Package transcript computes transcript aggregates and the transcript hash
+// pinned in credential metadata as the "Transcript Hash" attribute.
+package transcript
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"vericred/internal/models"
+)
+
+// Normalize orders terms by sequence and courses by code, and fills in
+// per-term credits and SGPA, total credits and, when cgpa is nil, the CGPA.
+// GPAs are credit-weighted over courses with grade points, to two decimals.
+func Normalize(t *models.Transcript, cgpa *float64) {
+	sort.SliceStable(t.Terms, func(i, j int) bool { return t.Terms[i].Sequence < t.Terms[j].Sequence })
+	var total, gpCredits, gpSum float64
+	for i := range t.Terms {
+		term := &t.Terms[i]
+		sort.SliceStable(term.Courses, func(a, b int) bool { return term.Courses[a].Code < term.Courses[b].Code })
+		var credits, termGPCredits, termGPSum float64
+		for _, c := range term.Courses {
+			credits += c.Credits
+			if c.GradePoints != nil {
+				termGPCredits += c.Credits
+				termGPSum += c.Credits * *c.GradePoints
+			}
+		}
+		term.Credits = round2(credits)
+		term.SGPA = 0
+		if termGPCredits > 0 {
+			term.SGPA = round2(termGPSum / termGPCredits)
+		}
+		total += credits
+		gpCredits += termGPCredits
+		gpSum += termGPSum
+	}
+	t.TotalCredits = round2(total)
+	switch {
+	case cgpa != nil:
+		t.CGPA = round2(*cgpa)
+	case gpCredits > 0:
+		t.CGPA = round2(gpSum / gpCredits)
+	default:
+		t.CGPA = 0
+	}
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func num(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+type hashCourse struct {
+	Code        string `json:"code"`
+	Title       string `json:"title"`
+	Credits     string `json:"credits"`
+	Grade       string `json:"grade"`
+	GradePoints string `json:"grade_points"`
+}
+
+type hashTerm struct {
+	Sequence int          `json:"sequence"`
+	Name     string       `json:"name"`
+	Credits  string       `json:"credits"`
+	SGPA     string       `json:"sgpa"`
+	Courses  []hashCourse `json:"courses"`
+}
+
+type hashDoc struct {
+	StudentWallet string     `json:"student_wallet"`
+	GradeScale    string     `json:"grade_scale"`
+	TotalCredits  string     `json:"total_credits"`
+	CGPA          string     `json:"cgpa"`
+	Terms         []hashTerm `json:"terms"`
+}
+
+// Hash is the keccak256 of the transcript's canonical JSON: terms by
+// sequence, courses by code, fixed key order, numbers as shortest decimal
+// strings and the wallet lowercased. Aggregates are hashed as stored, so t
+// should be normalized before its first hash.
+func Hash(t models.Transcript) string {
+	terms := append([]models.TranscriptTerm(nil), t.Terms...)
+	sort.SliceStable(terms, func(i, j int) bool { return terms[i].Sequence < terms[j].Sequence })
+	doc := hashDoc{
+		StudentWallet: strings.ToLower(strings.TrimSpace(t.StudentWallet)),
+		GradeScale:    num(t.GradeScale),
+		TotalCredits:  num(t.TotalCredits),
+		CGPA:          num(t.CGPA),
+		Terms:         make([]hashTerm, 0, len(terms)),
+	}
+	for _, term := range terms {
+		courses := append([]models.TranscriptCourse(nil), term.Courses...)
+		sort.SliceStable(courses, func(i, j int) bool { return courses[i].Code < courses[j].Code })
+		ht := hashTerm{
+			Sequence: term.Sequence,
+			Name:     strings.TrimSpace(term.Name),
+			Credits:  num(term.Credits),
+			SGPA:     num(term.SGPA),
+			Courses:  make([]hashCourse, 0, len(courses)),
+		}
+		for _, c := range courses {
+			hc := hashCourse{
+				Code:    strings.TrimSpace(c.Code),
+				Title:   strings.TrimSpace(c.Title),
+				Credits: num(c.Credits),
+				Grade:   strings.TrimSpace(c.Grade),
+			}
+			if c.GradePoints != nil {
+				hc.GradePoints = num(*c.GradePoints)
+			}
+			ht.Courses = append(ht.Courses, hc)
+		}
+		doc.Terms = append(doc.Terms, ht)
+	}
+	b, _ := json.Marshal(doc)
+	return hexutil.Encode(crypto.Keccak256(b))
+}
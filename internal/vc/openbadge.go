@@ -0,0 +1,284 @@
+package vc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+
+	"vericred/internal/eip712"
+	"vericred/internal/models"
+)
+
+const (
+	ContextV1          = "https://www.w3.org/2018/credentials/v1"
+	ContextOBv3        = "https://purl.imsglobal.org/spec/ob/v3p0/context-3.0.3.json"
+	contextOBv3Prefix  = "https://purl.imsglobal.org/spec/ob/v3p0/context"
+	OpenBadgeType      = "OpenBadgeCredential"
+	openBadgeTypeAlias = "AchievementCredential"
+	obPrimaryType      = "OpenBadgeCredential"
+)
+
+// Profile is an Open Badges issuer profile.
+type Profile struct {
+	ID   string   `json:"id"`
+	Type []string `json:"type,omitempty"`
+	Name string   `json:"name,omitempty"`
+	URL  string   `json:"url,omitempty"`
+}
+
+// UnmarshalJSON accepts an issuer given either as a profile or as a bare id.
+func (p *Profile) UnmarshalJSON(b []byte) error {
+	var id string
+	if err := json.Unmarshal(b, &id); err == nil {
+		*p = Profile{ID: id}
+		return nil
+	}
+	type plain Profile
+	return json.Unmarshal(b, (*plain)(p))
+}
+
+// Criteria describes how the achievement is earned.
+type Criteria struct {
+	Narrative string `json:"narrative,omitempty"`
+}
+
+// Achievement is what the badge recognizes.
+type Achievement struct {
+	ID              string   `json:"id"`
+	Type            []string `json:"type"`
+	Name            string   `json:"name"`
+	Description     string   `json:"description"`
+	Criteria        Criteria `json:"criteria"`
+	AchievementType string   `json:"achievementType,omitempty"`
+	FieldOfStudy    string   `json:"fieldOfStudy,omitempty"`
+}
+
+// AchievementSubject is the badge recipient.
+type AchievementSubject struct {
+	ID          string      `json:"id"`
+	Type        []string    `json:"type"`
+	Achievement Achievement `json:"achievement"`
+}
+
+// OpenBadge is an Open Badges 3.0 OpenBadgeCredential.
+type OpenBadge struct {
+	Context           []string           `json:"@context"`
+	ID                string             `json:"id"`
+	Type              []string           `json:"type"`
+	Issuer            Profile            `json:"issuer"`
+	Name              string             `json:"name"`
+	ValidFrom         string             `json:"validFrom,omitempty"`
+	ValidUntil        string             `json:"validUntil,omitempty"`
+	IssuanceDate      string             `json:"issuanceDate,omitempty"`
+	CredentialSubject AchievementSubject `json:"credentialSubject"`
+	CredentialStatus  *Status            `json:"credentialStatus,omitempty"`
+	Proof             *Proof             `json:"proof,omitempty"`
+}
+
+var obFields = []apitypes.Type{
+	{Name: "id", Type: "string"},
+	{Name: "issuer", Type: "string"},
+	{Name: "subject", Type: "string"},
+	{Name: "validFrom", Type: "string"},
+	{Name: "achievementId", Type: "string"},
+	{Name: "achievementType", Type: "string"},
+	{Name: "name", Type: "string"},
+	{Name: "description", Type: "string"},
+	{Name: "fieldOfStudy", Type: "string"},
+}
+
+// achievementType maps our free-text credential type onto the Open Badges
+// achievementType vocabulary.
+func achievementType(credType string) string {
+	t := strings.ToLower(credType)
+	switch {
+	case strings.Contains(t, "degree"), strings.Contains(t, "bachelor"), strings.Contains(t, "master"), strings.Contains(t, "doctor"):
+		return "Degree"
+	case strings.Contains(t, "diploma"):
+		return "Diploma"
+	case strings.Contains(t, "certificat"):
+		return "Certificate"
+	case strings.Contains(t, "course"):
+		return "Course"
+	case strings.Contains(t, "micro"):
+		return "MicroCredential"
+	default:
+		return "Achievement"
+	}
+}
+
+// OpenBadgeFromCredential renders cred (with its Organization loaded) as an
+// unsigned OpenBadgeCredential.
+func OpenBadgeFromCredential(cred models.Credential) OpenBadge {
+	desc := strings.TrimSpace(cred.Description)
+	if desc == "" {
+		desc = fmt.Sprintf("Awarded %s", cred.DegreeName)
+		if cred.Major != "" {
+			desc += " in " + cred.Major
+		}
+		if cred.Organization.OrgName != "" {
+			desc += " by " + cred.Organization.OrgName
+		}
+		desc += "."
+	}
+	narrative := "Successful completion of the " + cred.DegreeName + " program"
+	if cred.Major != "" {
+		narrative += " with a major in " + cred.Major
+	}
+	narrative += "."
+
+	return OpenBadge{
+		Context: []string{ContextV2, ContextOBv3},
+		ID:      CredentialID(cred.ID),
+		Type:    []string{"VerifiableCredential", OpenBadgeType},
+		Issuer: Profile{
+			ID:   DIDEthr(cred.UniversityWallet),
+			Type: []string{"Profile"},
+			Name: cred.Organization.OrgName,
+			URL:  cred.Organization.OrgUrl,
+		},
+		Name:      cred.DegreeName,
+		ValidFrom: cred.IssuedDate.UTC().Format(time.RFC3339),
+		CredentialSubject: AchievementSubject{
+			ID:   DIDEthr(cred.StudentWallet),
+			Type: []string{"AchievementSubject"},
+			Achievement: Achievement{
+				ID:              CredentialID(cred.ID) + "#achievement",
+				Type:            []string{"Achievement"},
+				Name:            cred.DegreeName,
+				Description:     desc,
+				Criteria:        Criteria{Narrative: narrative},
+				AchievementType: achievementType(cred.Type),
+				FieldOfStudy:    cred.Major,
+			},
+		},
+		CredentialStatus: &Status{ID: StatusURL(cred.ID), Type: StatusType},
+	}
+}
+
+// OpenBadgeTypedData returns the EIP-712 data the issuer signs for b.
+func OpenBadgeTypedData(b OpenBadge) apitypes.TypedData {
+	a := b.CredentialSubject.Achievement
+	return eip712.New(obPrimaryType, obFields, apitypes.TypedDataMessage{
+		"id":              b.ID,
+		"issuer":          b.Issuer.ID,
+		"subject":         b.CredentialSubject.ID,
+		"validFrom":       b.ValidFrom,
+		"achievementId":   a.ID,
+		"achievementType": a.AchievementType,
+		"name":            a.Name,
+		"description":     a.Description,
+		"fieldOfStudy":    a.FieldOfStudy,
+	})
+}
+
+// NewOpenBadgeProof builds a proof for b from the issuer's signature.
+func NewOpenBadgeProof(b OpenBadge, signature string, created time.Time) *Proof {
+	return newProof(OpenBadgeTypedData(b), b.Issuer.ID, signature, created)
+}
+
+// VerifyOpenBadgeSignature checks an EthereumEip712Signature2021 proof on b.
+func VerifyOpenBadgeSignature(b OpenBadge) (common.Address, error) {
+	return verifyProof(OpenBadgeTypedData(b), b.Issuer.ID, b.Proof)
+}
+
+// IssuedAt returns validFrom, falling back to the VC 1.1 issuanceDate.
+func (b OpenBadge) IssuedAt() (time.Time, error) {
+	s := b.ValidFrom
+	if s == "" {
+		s = b.IssuanceDate
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// IssuerHost returns the host of the issuer's URL or https id, if any.
+func (b OpenBadge) IssuerHost() string {
+	for _, raw := range []string{b.Issuer.URL, b.Issuer.ID} {
+		if u, err := url.Parse(raw); err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != "" {
+			return strings.ToLower(strings.TrimPrefix(u.Host, "www."))
+		}
+	}
+	return ""
+}
+
+// ValidateOpenBadge performs structural validation of an incoming badge.
+func ValidateOpenBadge(b OpenBadge, now time.Time) []string {
+	var errs []string
+	if !hasPrefixed(b.Context, ContextV2) && !hasPrefixed(b.Context, ContextV1) {
+		errs = append(errs, "@context must include the W3C credentials context")
+	}
+	if !hasPrefixed(b.Context, contextOBv3Prefix) {
+		errs = append(errs, "@context must include the Open Badges 3.0 context")
+	}
+	if !contains(b.Type, "VerifiableCredential") || !(contains(b.Type, OpenBadgeType) || contains(b.Type, openBadgeTypeAlias)) {
+		errs = append(errs, "type must include VerifiableCredential and OpenBadgeCredential")
+	}
+	if strings.TrimSpace(b.ID) == "" {
+		errs = append(errs, "id is required")
+	}
+	if strings.TrimSpace(b.Issuer.ID) == "" {
+		errs = append(errs, "issuer.id is required")
+	}
+	a := b.CredentialSubject.Achievement
+	if strings.TrimSpace(a.Name) == "" {
+		errs = append(errs, "credentialSubject.achievement.name is required")
+	}
+	if strings.TrimSpace(a.ID) == "" {
+		errs = append(errs, "credentialSubject.achievement.id is required")
+	}
+	if !contains(a.Type, "Achievement") {
+		errs = append(errs, "credentialSubject.achievement.type must include Achievement")
+	}
+	if _, err := b.IssuedAt(); err != nil {
+		errs = append(errs, "validFrom must be an RFC3339 date-time")
+	}
+	if b.ValidUntil != "" {
+		until, err := time.Parse(time.RFC3339, b.ValidUntil)
+		if err != nil {
+			errs = append(errs, "validUntil must be an RFC3339 date-time")
+		} else if until.Before(now) {
+			errs = append(errs, "badge expired at "+b.ValidUntil)
+		}
+	}
+	return errs
+}
+
+// ErrUnsupportedProof means the badge is signed with a suite this service
+// cannot check (e.g. Ed25519 Data Integrity proofs from an LMS).
+var ErrUnsupportedProof = errors.New("unsupported proof type")
+
+// CheckOpenBadgeProof verifies the proof when it uses a supported suite.
+func CheckOpenBadgeProof(b OpenBadge) error {
+	if b.Proof == nil {
+		return nil
+	}
+	if b.Proof.Type != ProofType {
+		return fmt.Errorf("%w %q", ErrUnsupportedProof, b.Proof.Type)
+	}
+	_, err := VerifyOpenBadgeSignature(b)
+	return err
+}
+
+func hasPrefixed(list []string, prefix string) bool {
+	for _, v := range list {
+		if strings.HasPrefix(v, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, want string) bool {
+	for _, v := range list {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
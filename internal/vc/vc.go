@@ -0,0 +1,214 @@
+// Package vc renders credentials as W3C Verifiable Credentials (Data Model
+// 2.0) with did:ethr identifiers and an EthereumEip712Signature2021 proof
+// produced by the issuing organization's wallet.
+package vc
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+
+	"vericred/internal/eip712"
+	"vericred/internal/eth"
+	"vericred/internal/models"
+)
+
+const (
+	ContextV2    = "https://www.w3.org/ns/credentials/v2"
+	ProofType    = "EthereumEip712Signature2021"
+	PrimaryType  = "VerifiableCredential"
+	StatusType   = "VeriCredStatus"
+	ProofPurpose = "assertionMethod"
+)
+
+// Issuer identifies the issuing organization.
+type Issuer struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// Degree is the achievement claimed in the credential subject.
+type Degree struct {
+	Type           string `json:"type"`
+	Name           string `json:"name"`
+	Major          string `json:"major,omitempty"`
+	Description    string `json:"description,omitempty"`
+	GraduationDate string `json:"graduationDate,omitempty"`
+}
+
+// Subject is the holder the credential is about.
+type Subject struct {
+	ID     string `json:"id"`
+	Degree Degree `json:"degree"`
+}
+
+// Status points verifiers at the credential's revocation status.
+type Status struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// EIP712Info carries what a verifier needs to rebuild the signed typed data.
+type EIP712Info struct {
+	Domain      apitypes.TypedDataDomain `json:"domain"`
+	Types       apitypes.Types           `json:"types"`
+	PrimaryType string                   `json:"primaryType"`
+}
+
+// Proof is an EthereumEip712Signature2021 proof.
+type Proof struct {
+	Type               string      `json:"type"`
+	Created            string      `json:"created"`
+	ProofPurpose       string      `json:"proofPurpose"`
+	VerificationMethod string      `json:"verificationMethod"`
+	ProofValue         string      `json:"proofValue"`
+	EIP712             *EIP712Info `json:"eip712,omitempty"`
+}
+
+// Credential is a VC Data Model 2.0 document.
+type Credential struct {
+	Context           []string `json:"@context"`
+	ID                string   `json:"id"`
+	Type              []string `json:"type"`
+	Issuer            Issuer   `json:"issuer"`
+	ValidFrom         string   `json:"validFrom"`
+	CredentialSubject Subject  `json:"credentialSubject"`
+	CredentialStatus  *Status  `json:"credentialStatus,omitempty"`
+	Proof             *Proof   `json:"proof,omitempty"`
+}
+
+var fields = []apitypes.Type{
+	{Name: "id", Type: "string"},
+	{Name: "issuer", Type: "string"},
+	{Name: "subject", Type: "string"},
+	{Name: "validFrom", Type: "string"},
+	{Name: "credentialType", Type: "string"},
+	{Name: "degreeName", Type: "string"},
+	{Name: "major", Type: "string"},
+	{Name: "description", Type: "string"},
+	{Name: "graduationDate", Type: "string"},
+}
+
+// DIDEthr returns the did:ethr identifier of addr on the configured chain.
+func DIDEthr(addr string) string {
+	return fmt.Sprintf("did:ethr:0x%x:%s", eth.ChainID(), strings.ToLower(strings.TrimSpace(addr)))
+}
+
+// AddressFromDID extracts the Ethereum address from a did:ethr identifier,
+// ignoring any network segment and fragment.
+func AddressFromDID(did string) (common.Address, error) {
+	did = strings.SplitN(did, "#", 2)[0]
+	if !strings.HasPrefix(did, "did:ethr:") {
+		return common.Address{}, fmt.Errorf("unsupported DID %q (want did:ethr)", did)
+	}
+	parts := strings.Split(did, ":")
+	last := parts[len(parts)-1]
+	if !common.IsHexAddress(last) {
+		return common.Address{}, fmt.Errorf("DID %q does not end in an address", did)
+	}
+	return common.HexToAddress(last), nil
+}
+
+// CredentialID returns the VC id for a credential row.
+func CredentialID(credID string) string {
+	return "urn:uuid:" + credID
+}
+
+// StatusURL is the public status endpoint for a credential.
+func StatusURL(credID string) string {
+	base := os.Getenv("API_BASE_URL")
+	if base == "" {
+		base = "http://localhost:8080"
+	}
+	return strings.TrimRight(base, "/") + "/api/v1/credentials/" + credID + "/status"
+}
+
+// FromCredential renders cred (with its Organization loaded) as an unsigned VC.
+func FromCredential(cred models.Credential) Credential {
+	return Credential{
+		Context: []string{ContextV2},
+		ID:      CredentialID(cred.ID),
+		Type:    []string{"VerifiableCredential", "UniversityDegreeCredential"},
+		Issuer: Issuer{
+			ID:   DIDEthr(cred.UniversityWallet),
+			Name: cred.Organization.OrgName,
+		},
+		ValidFrom: cred.IssuedDate.UTC().Format(time.RFC3339),
+		CredentialSubject: Subject{
+			ID: DIDEthr(cred.StudentWallet),
+			Degree: Degree{
+				Type:           cred.Type,
+				Name:           cred.DegreeName,
+				Major:          cred.Major,
+				Description:    cred.Description,
+				GraduationDate: cred.GraduationDate,
+			},
+		},
+		CredentialStatus: &Status{ID: StatusURL(cred.ID), Type: StatusType},
+	}
+}
+
+// TypedData returns the EIP-712 data the issuer signs for c. Only the claims
+// are covered, so the same proof stays valid when re-rendered.
+func TypedData(c Credential) apitypes.TypedData {
+	d := c.CredentialSubject.Degree
+	return eip712.New(PrimaryType, fields, apitypes.TypedDataMessage{
+		"id":             c.ID,
+		"issuer":         c.Issuer.ID,
+		"subject":        c.CredentialSubject.ID,
+		"validFrom":      c.ValidFrom,
+		"credentialType": d.Type,
+		"degreeName":     d.Name,
+		"major":          d.Major,
+		"description":    d.Description,
+		"graduationDate": d.GraduationDate,
+	})
+}
+
+// NewProof builds a proof for c from the issuer's signature over TypedData(c).
+func NewProof(c Credential, signature string, created time.Time) *Proof {
+	return newProof(TypedData(c), c.Issuer.ID, signature, created)
+}
+
+// VerifySignature recovers the signer of c.Proof and checks that it is the
+// address in the issuer DID. It returns the recovered signer.
+func VerifySignature(c Credential) (common.Address, error) {
+	return verifyProof(TypedData(c), c.Issuer.ID, c.Proof)
+}
+
+func newProof(td apitypes.TypedData, issuerID, signature string, created time.Time) *Proof {
+	return &Proof{
+		Type:               ProofType,
+		Created:            created.UTC().Format(time.RFC3339),
+		ProofPurpose:       ProofPurpose,
+		VerificationMethod: issuerID + "#controller",
+		ProofValue:         signature,
+		EIP712:             &EIP712Info{Domain: td.Domain, Types: td.Types, PrimaryType: td.PrimaryType},
+	}
+}
+
+func verifyProof(td apitypes.TypedData, issuerID string, proof *Proof) (common.Address, error) {
+	if proof == nil {
+		return common.Address{}, errors.New("credential has no proof")
+	}
+	if proof.Type != ProofType {
+		return common.Address{}, fmt.Errorf("unsupported proof type %q", proof.Type)
+	}
+	issuer, err := AddressFromDID(issuerID)
+	if err != nil {
+		return common.Address{}, err
+	}
+	signer, err := eip712.Recover(td, proof.ProofValue)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if signer != issuer {
+		return signer, fmt.Errorf("proof signed by %s, not issuer %s", signer.Hex(), issuer.Hex())
+	}
+	return signer, nil
+}
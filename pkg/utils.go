@@ -1,6 +1,7 @@
 package pkg
 
 import (
+	"crypto/ecdsa"
 	"encoding/hex"
 	"fmt"
 	"log"
@@ -99,6 +100,20 @@ func VerifySignature(address, message, sigHex string) (bool, error) {
 	return strings.EqualFold(recoveredAddr, address), nil
 }
 
+// RecoverPublicKey returns the secp256k1 public key that produced a
+// personal_sign signature over message.
+func RecoverPublicKey(message, sigHex string) (*ecdsa.PublicKey, error) {
+	data := []byte("\x19Ethereum Signed Message:\n" + strconv.Itoa(len(message)) + message)
+	sig := hexToBytes(sigHex)
+	if len(sig) != 65 {
+		return nil, fmt.Errorf("signature must be 65 bytes")
+	}
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	return crypto.SigToPub(crypto.Keccak256(data), sig)
+}
+
 func hexToBytes(hexStr string) []byte {
 	b, _ := hex.DecodeString(strings.TrimPrefix(hexStr, "0x"))
 	return b